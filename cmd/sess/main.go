@@ -1,16 +1,36 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"os"
 	"os/exec"
+	"os/signal"
+	"path/filepath"
 	"runtime"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"time"
 
+	tea "github.com/charmbracelet/bubbletea"
 	"github.com/datapointchris/sess/internal/config"
+	"github.com/datapointchris/sess/internal/doctor"
+	"github.com/datapointchris/sess/internal/events"
+	"github.com/datapointchris/sess/internal/history"
+	"github.com/datapointchris/sess/internal/metadata"
+	"github.com/datapointchris/sess/internal/output"
+	"github.com/datapointchris/sess/internal/resolver"
+	"github.com/datapointchris/sess/internal/runner"
 	"github.com/datapointchris/sess/internal/session"
 	"github.com/datapointchris/sess/internal/tmux"
+	"github.com/datapointchris/sess/internal/ui"
+	"github.com/datapointchris/sess/internal/zoxide"
 	"github.com/spf13/cobra"
 )
 
@@ -29,6 +49,59 @@ func getVersion() string {
 	return "dev"
 }
 
+// socketName selects a tmux server other than the default (tmux -L)
+// socketPath does the same by the socket's path (tmux -S)
+// Set by the global --socket and --socket-path flags, which can't be combined
+var socketName string
+var socketPath string
+
+// tmuxConfigPath is the tmux config reload sources instead of the usual locations
+// Set by the --config flag of reload
+var tmuxConfigPath string
+
+// cmdTimeout bounds how long a single tmux/tmuxinator command may run
+// Resolved from --timeout, then $SESS_CMD_TIMEOUT, then the runner's default
+var cmdTimeout = runner.DefaultTimeout
+
+// dryRun prints the tmux and tmuxinator commands sess would run, instead of running them
+// Set by the global --dry-run flag
+var dryRun bool
+
+// noHooks keeps default sessions' on_attach commands from running
+// Set by the global --no-hooks flag
+var noHooks bool
+
+// verbosity is how many times -v was given: warnings only by default,
+// what sess decides at -v, and every command it runs at -vv
+var verbosity int
+
+// takeover detaches other clients when attaching to a session that's already open
+// Set by the --takeover flag of commands that support it
+var takeover bool
+
+// watchPicker keeps the picker's list current, listing the sessions again every watchInterval
+// Set by the --watch and --interval flags of the bare command
+var watchPicker bool
+var watchInterval = 2 * time.Second
+
+// platform selects the sessions-<platform>.yml file
+// Resolved from --platform, $SESS_PLATFORM, the platform file, then auto-detection
+var platform string
+
+// platformFlag is the value of the --platform flag
+var platformFlag string
+
+// noIcons draws ASCII markers instead of icons, in lists and the picker
+var noIcons bool
+
+// pickerFlag is the value of the --ui flag, --picker, or $SESS_PICKER: auto, bubbletea, gum, or fzf
+var pickerFlag string
+
+// missingDir is what to do when a default session's directory doesn't exist
+// Only go asks (or follows its --create-missing-dir and --no flags); everywhere
+// else tmux starts in the home directory as it always has
+var missingDir = session.MissingDirIgnore
+
 // Detect the platform (macos or wsl)
 func detectPlatform() string {
 	// Check if we're on macOS
@@ -46,21 +119,206 @@ func detectPlatform() string {
 	return runtime.GOOS
 }
 
+// resolvePlatform picks the platform whose sessions file is used: the
+// --platform flag wins over $SESS_PLATFORM, which wins over the platform
+// file in the config directory, which wins over auto-detection
+func resolvePlatform(flagValue string, flagSet bool, env string, loader *config.Loader) (string, error) {
+	if flagSet && strings.TrimSpace(flagValue) == "" {
+		return "", fmt.Errorf("--platform needs a platform name")
+	}
+
+	name := flagValue
+	if name == "" {
+		name = env
+	}
+	if name == "" {
+		fromFile, err := loader.PlatformOverride()
+		if err != nil {
+			return "", err
+		}
+		name = fromFile
+	}
+	if name == "" {
+		return detectPlatform(), nil
+	}
+
+	// The platform becomes part of a file name (sessions-<platform>.yml)
+	if strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("invalid platform %q", name)
+	}
+	return name, nil
+}
+
+// warnMissingPlatform warns when the platform asked for has no sessions
+// file, which is usually a typo rather than a platform with no sessions
+// The shared sessions.yml is enough on its own, so it only warns when none
+// of the files the sessions are read from exist
+func warnMissingPlatform(w io.Writer, loader *config.Loader, platform string) {
+	for _, path := range loader.SourcePaths(platform) {
+		if _, err := os.Stat(path); !errors.Is(err, fs.ErrNotExist) {
+			return
+		}
+	}
+	fmt.Fprintf(w, "Warning: there's no sessions file for platform '%s' (%s)\n", platform, loader.SessionsPath(platform))
+}
+
+// resolveTimeout picks the command timeout: the --timeout flag wins over
+// $SESS_CMD_TIMEOUT, which wins over the default
+func resolveTimeout(flagValue time.Duration, flagSet bool, env string) (time.Duration, error) {
+	if flagSet {
+		return flagValue, nil
+	}
+
+	if env != "" {
+		timeout, err := time.ParseDuration(env)
+		if err != nil {
+			return 0, fmt.Errorf("invalid SESS_CMD_TIMEOUT %q: %w", env, err)
+		}
+		return timeout, nil
+	}
+
+	return runner.DefaultTimeout, nil
+}
+
+// resolveSort picks the order sessions are listed in: the sort setting in
+// config.yml wins; without one, a saved picker order means custom, and a
+// history means recent, so the sessions in use come first
+func resolveSort(setting string, order []string, hasHistory bool) (session.SortMode, error) {
+	switch {
+	case setting != "":
+		return session.ParseSortMode(setting)
+	case len(order) > 0:
+		return session.SortByCustom, nil
+	case hasHistory:
+		return session.SortByRecent, nil
+	}
+	return session.SortByName, nil
+}
+
+// newRunner creates the command runner honoring the global --timeout, --dry-run, and -v flags
+func newRunner() runner.Runner {
+	var r runner.Runner = runner.NewWithTimeout(cmdTimeout)
+	if dryRun {
+		r = runner.NewDryRun(os.Stderr)
+	}
+	return runner.NewLogging(r, newLogger())
+}
+
+// skipForDryRun reports whether --dry-run is on, and if so prints (as a
+// comment after the commands) what sess would have written instead
+func skipForDryRun(format string, args ...any) bool {
+	if dryRun {
+		fmt.Fprintf(os.Stderr, "# would "+format+"\n", args...)
+	}
+	return dryRun
+}
+
+// newLogger creates the logger for the global -v flag, writing to stderr
+func newLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: logLevel(verbosity)}))
+}
+
+// logLevel maps the number of -v flags to the lowest level logged
+func logLevel(verbosity int) slog.Level {
+	switch {
+	case verbosity >= 2:
+		return slog.LevelDebug
+	case verbosity == 1:
+		return slog.LevelInfo
+	}
+	return slog.LevelWarn
+}
+
+// newTmuxClient creates a tmux client honoring the global flags (socket, timeout)
+// and $SESS_TMUX_BIN, which points sess at a tmux that isn't on PATH
+func newTmuxClient() *tmux.Client {
+	return tmux.NewClientWithRunner(newRunner()).
+		WithBinary(os.Getenv("SESS_TMUX_BIN")).
+		WithSocket(socketName).
+		WithSocketPath(socketPath).
+		WithConfigPath(tmuxConfigPath)
+}
+
 // createSessionManager is a factory function that creates a fully-configured session manager
 // This is where we wire up all the dependencies (dependency injection)
 func createSessionManager() *session.Manager {
 	// Create the real implementations
-	tmuxClient := tmux.NewClient()
+	tmuxClient := newTmuxClient()
 	tmuxinatorClient := tmux.NewTmuxinatorClient(tmuxClient)
 	configLoader := config.NewLoader()
-	platform := detectPlatform()
+
+	// Listing tmuxinator projects is the slowest part of startup
+	// With SESS_PREFETCH set, start it now so it runs while everything else loads
+	if os.Getenv("SESS_PREFETCH") != "" {
+		tmuxinatorClient.Prefetch()
+	}
 
 	// Create the manager with all dependencies
-	return session.NewManager(tmuxClient, tmuxinatorClient, configLoader, platform)
+	manager := session.NewManager(tmuxClient, tmuxinatorClient, configLoader, platform)
+	logger := newLogger()
+	manager.SetLogger(logger)
+	manager.SetNotices(os.Stderr)
+	manager.SetTakeover(takeover)
+	manager.SetSkipHooks(noHooks)
+	manager.SetDryRun(dryRun)
+	manager.SetMissingDirPolicy(missingDir)
+	manager.SetZoxide(zoxide.NewClient(newRunner()))
+	manager.SetMetadataStore(metadata.NewStore(filepath.Join(configLoader.Dir(), "metadata.json")))
+
+	historyStore := history.NewStore(filepath.Join(configLoader.Dir(), "history.json"))
+	manager.SetHistory(historyStore)
+
+	// Only ask questions when someone is there to answer them
+	if ui.IsTerminal(os.Stdin) {
+		manager.SetConfirmer(ui.NewPromptConfirmer(os.Stdin, os.Stderr))
+	}
+
+	// A broken config.yml is reported by the commands that read it, not here
+	var sortSetting string
+	fuzzyThreshold := config.DefaultFuzzyConfirmThreshold
+	appConfig, err := configLoader.LoadAppConfig()
+	if err != nil {
+		logger.Info("ignoring config.yml", "error", err)
+	} else {
+		// Broadcast session events if a socket is configured
+		if appConfig.EventSocket != "" {
+			manager.SetEventSink(events.NewUnixSocketSink(appConfig.EventSocket))
+		}
+
+		// Let the user's script place names sess doesn't know
+		if appConfig.ResolverCommand != "" {
+			manager.SetResolver(resolver.NewCommandResolver(newRunner(), appConfig.ResolverCommand))
+		}
+
+		fuzzyThreshold = appConfig.FuzzyConfirmThreshold
+		manager.SetNormalizeNames(appConfig.NormalizeNames)
+		sortSetting = appConfig.Sort
+	}
+	manager.SetFuzzyConfirmThreshold(fuzzyThreshold)
+
+	// Follow the order the user arranged in the picker, if they have, and
+	// otherwise put the sessions they use first
+	order, err := configLoader.LoadOrder()
+	if err != nil {
+		logger.Warn("failed to load the saved session order", "error", err)
+	}
+	entries, err := historyStore.Entries()
+	if err != nil {
+		logger.Warn("failed to load the session history", "error", err)
+	}
+	if mode, err := resolveSort(sortSetting, order, len(entries) > 0); err == nil {
+		manager.SetSort(mode, order)
+	}
+
+	return manager
 }
 
 // main is the entry point of the program
 func main() {
+	var showAll bool
+	var fuzzy bool
+	var detach bool
+
 	// Create the root command
 	// Cobra organizes commands in a tree structure
 	// The root command is the base command (just "session")
@@ -70,13 +328,38 @@ func main() {
 		Long: `A fast and lightweight tmux session manager.
 
 USAGE:
-  session                    Show interactive picker
-  session <name>             Create or switch to session <name>
+  session                    Show interactive picker (--watch keeps it current)
+  session <name>             Create or switch to session <name> (--fuzzy forgives typos, -d stays put)
   session go <name>          Open session if it exists, otherwise show picker
-  session delete <name>      Delete an active session
+  session new <name>         Create a new session (--clone-env copies the environment)
+  session up                 Start every default session in the background
+  session z <query>          Open a session for a directory found with zoxide
+  session here               Open a session for the current directory
+  session delete <name>      Delete an active session (asks first)
+  session stop <project>     Stop a tmuxinator project, running its stop hooks
+  session rename <old> <new> Rename an active session
+  session kill-all           Kill every active session (asks first)
+  session restart <name>     Kill and recreate a session
+  session info <name>        Show where a session comes from
+  session capture <name>     Save a session's pane content (-o file)
+  session peek <name>        Show what's on a session's screen
+  session move-window        Move a window to another session
+  session scratch            Switch to the throwaway scratch session
+  session sync [on|off]      Toggle synchronize-panes in the current window
+  session config add         Add a default session with an interactive form
+  session config validate    Check the sessions file for mistakes
+  session edit               Open the sessions file in $EDITOR
+  session import-running     Save the running sessions to the config file
+  session save <name>        Save a session's windows and panes as a snapshot
+  session restore <name>     Recreate a session from its snapshot
+  session history            Show the session history (also: prune, clear)
+  session doctor [--fix]     Check the setup for problems (and fix them)
   session list               List all available sessions
   session last               Switch to last active session
-  session reload             Reload tmux config in all sessions
+  session attach <name>      Attach this terminal to a running session (-d detaches others)
+  session detach             Detach from tmux, leaving the session running
+  session prev-unique        Cycle back past the last two sessions
+  session reload [name]      Reload tmux config in all sessions (or one)
 
 SESSIONS:
   • Active tmux sessions (●)
@@ -85,14 +368,56 @@ SESSIONS:
 
 CONFIG:
   Default sessions: ~/.config/sess/sessions-<platform>.yml
+  Shared sessions:  ~/.config/sess/sessions.yml (platform file wins)
+  App settings:     ~/.config/sess/config.yml
   Platform detected automatically (macos, wsl, etc.)`,
 		Version: getVersion(),
+		// Without this, cobra treats any argument to a command with
+		// subcommands as an unknown subcommand, and "sess <name>" fails
+		Args: cobra.MaximumNArgs(1),
+		// main prints the error itself, so keep cobra from printing it twice
+		SilenceErrors: true,
+		// PersistentPreRunE runs before every command, so global settings are resolved once here
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			timeout, err := resolveTimeout(cmdTimeout, cmd.Flags().Changed("timeout"), os.Getenv("SESS_CMD_TIMEOUT"))
+			if err != nil {
+				// A bad environment variable isn't a usage mistake, so skip the usage dump
+				cmd.SilenceUsage = true
+				return err
+			}
+			cmdTimeout = timeout
+
+			if env := os.Getenv("SESS_PICKER"); env != "" && !cmd.Flags().Changed("ui") && !cmd.Flags().Changed("picker") {
+				if err := validatePicker(env); err != nil {
+					cmd.SilenceUsage = true
+					return fmt.Errorf("SESS_PICKER: %w", err)
+				}
+				pickerFlag = env
+			}
+			if err := validatePicker(pickerFlag); err != nil {
+				return err
+			}
+
+			loader := config.NewLoader()
+			resolved, err := resolvePlatform(platformFlag, cmd.Flags().Changed("platform"), os.Getenv("SESS_PLATFORM"), loader)
+			if err != nil {
+				cmd.SilenceUsage = true
+				return err
+			}
+			platform = resolved
+			if platformFlag != "" {
+				warnMissingPlatform(os.Stderr, loader, platform)
+			}
+			return nil
+		},
 		// Run is called when the user runs "session" with no subcommands
 		Run: func(cmd *cobra.Command, args []string) {
 			// If the user provided a session name as argument, create/switch to it
 			if len(args) > 0 {
 				sessionName := args[0]
 				manager := createSessionManager()
+				manager.SetFuzzy(fuzzy)
+				manager.SetDetached(detach)
 				if err := manager.CreateOrSwitch(sessionName); err != nil {
 					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 					os.Exit(1)
@@ -100,17 +425,66 @@ CONFIG:
 				return
 			}
 
+			if watchPicker && watchInterval <= 0 {
+				fmt.Fprintln(os.Stderr, "Error: --interval must be positive")
+				os.Exit(1)
+			}
+
 			// No arguments - show the interactive list
-			showInteractiveList()
+			showInteractiveList(showAll, true)
 		},
 	}
 
+	rootCmd.Flags().BoolVarP(&showAll, "all", "a", false, "Include hidden sessions in the picker")
+	rootCmd.Flags().BoolVar(&fuzzy, "fuzzy", false, "Switch to the closest matching session instead of creating a new one for a typo")
+	rootCmd.PersistentFlags().StringVar(&platformFlag, "platform", "", "Platform whose sessions file to use, e.g. macos or work (env: SESS_PLATFORM)")
+	rootCmd.PersistentFlags().DurationVar(&cmdTimeout, "timeout", runner.DefaultTimeout, "How long a tmux command may run before giving up (env: SESS_CMD_TIMEOUT)")
+	rootCmd.PersistentFlags().CountVarP(&verbosity, "verbose", "v", "Log what sess does to stderr (-vv also logs every command it runs)")
+	rootCmd.PersistentFlags().BoolVar(&dryRun, "dry-run", false, "Print the tmux and tmuxinator commands instead of running them")
+	rootCmd.PersistentFlags().BoolVar(&noHooks, "no-hooks", false, "Don't run on_attach commands when switching to a session")
+	rootCmd.PersistentFlags().StringVarP(&socketName, "socket", "L", "", "Use the tmux server on this socket name")
+	rootCmd.PersistentFlags().StringVarP(&socketPath, "socket-path", "S", "", "Use the tmux server on the socket at this path")
+	rootCmd.MarkFlagsMutuallyExclusive("socket", "socket-path")
+	rootCmd.PersistentFlags().StringVar(&pickerFlag, "ui", pickerAuto, "Session picker: auto, bubbletea, gum, or fzf (env: SESS_PICKER)")
+	rootCmd.PersistentFlags().StringVar(&pickerFlag, "picker", pickerAuto, "Same as --ui")
+	rootCmd.MarkFlagsMutuallyExclusive("ui", "picker")
+	rootCmd.PersistentFlags().BoolVar(&noIcons, "no-icons", false, "Use ASCII markers instead of icons (env: NO_COLOR)")
+	rootCmd.Flags().BoolVarP(&detach, "detach", "d", false, "Create the session in the background instead of switching to it")
+	rootCmd.Flags().BoolVar(&takeover, "takeover", false, "Detach other clients when attaching to a session that's already open")
+	rootCmd.Flags().BoolVarP(&watchPicker, "watch", "w", false, "Keep the picker's list current as sessions come and go")
+	rootCmd.Flags().DurationVar(&watchInterval, "interval", watchInterval, "How often --watch lists the sessions again")
+
 	// Add subcommands
 	rootCmd.AddCommand(listCmd())
 	rootCmd.AddCommand(lastCmd())
+	rootCmd.AddCommand(attachCmd())
+	rootCmd.AddCommand(detachCmd())
+	rootCmd.AddCommand(prevUniqueCmd())
 	rootCmd.AddCommand(reloadCmd())
 	rootCmd.AddCommand(goCmd())
+	rootCmd.AddCommand(newCmd())
+	rootCmd.AddCommand(upCmd())
+	rootCmd.AddCommand(zCmd())
+	rootCmd.AddCommand(hereCmd())
 	rootCmd.AddCommand(deleteCmd())
+	rootCmd.AddCommand(stopCmd())
+	rootCmd.AddCommand(renameCmd())
+	rootCmd.AddCommand(killAllCmd())
+	rootCmd.AddCommand(restartCmd())
+	rootCmd.AddCommand(infoCmd())
+	rootCmd.AddCommand(metaCmd())
+	rootCmd.AddCommand(captureCmd())
+	rootCmd.AddCommand(peekCmd())
+	rootCmd.AddCommand(moveWindowCmd())
+	rootCmd.AddCommand(scratchCmd())
+	rootCmd.AddCommand(syncCmd())
+	rootCmd.AddCommand(configCmd())
+	rootCmd.AddCommand(editCmd())
+	rootCmd.AddCommand(importRunningCmd())
+	rootCmd.AddCommand(saveCmd())
+	rootCmd.AddCommand(restoreCmd())
+	rootCmd.AddCommand(historyCmd())
+	rootCmd.AddCommand(doctorCmd())
 
 	// Execute the root command
 	// This parses command-line arguments and runs the appropriate command
@@ -120,20 +494,39 @@ CONFIG:
 	}
 }
 
-// showInteractiveList displays the gum-based UI
-func showInteractiveList() {
-	// Check if gum is available
-	if _, err := exec.LookPath("gum"); err != nil {
-		fmt.Fprintln(os.Stderr, "Error: gum is not installed")
-		fmt.Fprintln(os.Stderr, "Install with: brew install gum")
-		os.Exit(1)
+// listVisibleSessions lists sessions, leaving out hidden ones unless opts.IncludeHidden is set
+// The names hidden in config.yml are added to opts
+// Sources that couldn't be read are reported on stderr, and whatever
+// sessions could be gathered are still returned
+func listVisibleSessions(manager *session.Manager, opts session.ListOptions) ([]session.Session, error) {
+	appConfig, err := config.NewLoader().LoadAppConfig()
+	if err != nil {
+		return nil, err
+	}
+	opts.Hidden = appConfig.Hidden
+
+	sessions, warnings, err := manager.ListFiltered(opts)
+	printWarnings(os.Stderr, warnings)
+	return sessions, err
+}
+
+// printWarnings writes one line per list warning
+func printWarnings(w io.Writer, warnings []session.ListWarning) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, "Warning: %v\n", warning)
 	}
+}
 
+// showInteractiveList displays the session picker (see resolvePicker)
+// With autoSwitch, a list of exactly one session skips the picker when
+// auto_switch_single is set; it's off for fallbacks like "sess go <missing>",
+// where the one session isn't what was asked for
+func showInteractiveList(includeHidden, autoSwitch bool) {
 	// Create session manager
 	manager := createSessionManager()
 
 	// Get all sessions
-	sessions, err := manager.ListAll()
+	sessions, err := listVisibleSessions(manager, session.ListOptions{IncludeHidden: includeHidden})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error listing sessions: %v\n", err)
 		os.Exit(1)
@@ -144,54 +537,176 @@ func showInteractiveList() {
 		fmt.Println("No sessions found.")
 		fmt.Println("")
 		fmt.Println("Create a new session with: session <name>")
-		fmt.Println("Or add default sessions to ~/.config/sess/sessions-" + detectPlatform() + ".yml")
+		fmt.Println("Or add default sessions to ~/.config/sess/sessions-" + platform + ".yml")
 		return
 	}
 
-	// Format sessions for gum
+	// A picker with a single entry is just an extra keypress
+	if name, ok := soleSession(sessions, autoSwitch && autoSwitchSingle()); ok {
+		if err := manager.CreateOrSwitch(name); err != nil {
+			fmt.Fprintf(os.Stderr, "Error switching to session: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var sessionName string
+	switch resolvePicker(pickerFlag, ui.IsTerminal(os.Stdout), isInstalled) {
+	case pickerGum:
+		sessionName, err = chooseWith(ui.GumPicker{}, sessions)
+	case pickerFzf:
+		sessionName, err = chooseWith(ui.FzfPicker{}, sessions)
+	default:
+		var refresh ui.Refresher
+		if watchPicker {
+			refresh = pickerRefresher(manager, includeHidden)
+		}
+		sessionName, err = chooseWithBubbletea(manager, sessions, refresh)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if sessionName == "" {
+		// User canceled
+		return
+	}
+
+	// Create or switch to the chosen session (or the new name typed in)
+	if err := manager.CreateOrSwitch(sessionName); err != nil {
+		fmt.Fprintf(os.Stderr, "Error switching to session: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// Pickers the interactive list can be shown with
+const (
+	pickerAuto      = "auto"
+	pickerBubbletea = "bubbletea"
+	pickerGum       = "gum"
+	pickerFzf       = "fzf"
+)
+
+// resolvePicker picks the interactive list to show for the --ui flag
+// auto prefers the built-in bubbletea list, and when stdout isn't a
+// terminal for bubbletea to draw on, falls back to gum or else fzf,
+// whichever is installed (gum when neither is, so the error names it)
+func resolvePicker(flagValue string, stdoutIsTerminal bool, installed func(string) bool) string {
+	if flagValue != pickerAuto {
+		return flagValue
+	}
+	if stdoutIsTerminal {
+		return pickerBubbletea
+	}
+	if !installed(pickerGum) && installed(pickerFzf) {
+		return pickerFzf
+	}
+	return pickerGum
+}
+
+// isInstalled reports whether a program is on PATH
+func isInstalled(name string) bool {
+	_, err := exec.LookPath(name)
+	return err == nil
+}
+
+// validatePicker checks the --ui flag's value
+func validatePicker(value string) error {
+	switch value {
+	case pickerAuto, pickerBubbletea, pickerGum, pickerFzf:
+		return nil
+	}
+	return fmt.Errorf("invalid --ui %q (want auto, bubbletea, gum, or fzf)", value)
+}
+
+// chooseWithBubbletea shows the built-in list and returns the chosen
+// session, or the name typed for a new one ("" if the user quit)
+// Sessions moved with K/J are saved as the custom order
+// With refresh, the list is kept current every watchInterval
+func chooseWithBubbletea(manager *session.Manager, sessions []session.Session, refresh ui.Refresher) (string, error) {
+	model := newPicker(manager, sessions, refresh)
+	finalModel, err := tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if err != nil {
+		return "", fmt.Errorf("failed to run the session list: %w", err)
+	}
+	result := finalModel.(ui.Model)
+
+	// A failed save loses the new order, not the switch
+	if result.Reordered() && !skipForDryRun("save the new session order") {
+		if err := saveOrder(result); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+	}
+
+	return result.GetChoice(), nil
+}
+
+// newPicker builds the built-in list with everything the CLI turns on:
+// the configured icons, the window preview, deleting with d and D, and
+// with refresh, --watch
+func newPicker(manager *session.Manager, sessions []session.Session, refresh ui.Refresher) ui.Model {
+	model := ui.NewModel(sessions)
+	model.SetIcons(configuredIcons())
+	model.SetPreview(manager.Windows)
+	model.SetDeleter(manager)
+	if refresh != nil {
+		model.SetWatch(refresh, watchInterval)
+	}
+	return model
+}
+
+// saveOrder saves the picker's order over the custom order, keeping the
+// places of sessions the picker didn't show
+func saveOrder(result ui.Model) error {
+	loader := config.NewLoader()
+	saved, err := loader.LoadOrder()
+	if err != nil {
+		return err
+	}
+	return loader.SaveOrder(result.Order(saved))
+}
+
+// pickerRefresher lists the same sessions the picker started with, for --watch
+func pickerRefresher(manager *session.Manager, includeHidden bool) ui.Refresher {
+	opts := session.ListOptions{IncludeHidden: includeHidden}
+	// The picker only opens once config.yml has been read, so this can't fail differently
+	if appConfig, err := config.NewLoader().LoadAppConfig(); err == nil {
+		opts.Hidden = appConfig.Hidden
+	}
+
+	return func() ([]session.Session, error) {
+		manager.RefreshProjects()
+		// Warnings would draw over the picker, and the first listing already printed them
+		sessions, _, err := manager.ListFiltered(opts)
+		return sessions, err
+	}
+}
+
+// chooseWith shows the list with an external picker and returns the chosen
+// session, or the name typed for a new one ("" if the user canceled)
+func chooseWith(picker ui.Picker, sessions []session.Session) (string, error) {
+	// Format sessions for the picker
 	var options []string
 	sessionMap := make(map[string]string) // Map display text to session name
 
+	icons := configuredIcons()
 	for _, sess := range sessions {
-		displayText := fmt.Sprintf("%s %s", sess.Icon(), sess.DisplayInfo())
+		displayText := output.FormatSessionLine(sess, icons)
 		options = append(options, displayText)
 		sessionMap[displayText] = sess.Name
 	}
 
 	// Add "Create New Session" option
-	options = append(options, "+ Create New Session")
-
-	// Call gum choose
-	cmd := exec.Command("gum", append([]string{"choose", "--header=Tmux Sessions"}, options...)...)
-	cmd.Stderr = os.Stderr
-	output, err := cmd.Output()
-	if err != nil {
-		// User canceled or error occurred
-		return
-	}
+	options = append(options, ui.CreateLabel)
 
-	choice := strings.TrimSpace(string(output))
-	if choice == "" {
-		return
+	choice, err := picker.Choose(options, "Tmux Sessions")
+	if err != nil || choice == "" {
+		return "", err
 	}
 
 	// Handle "Create New Session"
-	if choice == "+ Create New Session" {
-		newNameCmd := exec.Command("gum", "input", "--placeholder", "Session name")
-		newNameCmd.Stderr = os.Stderr
-		newNameOutput, err := newNameCmd.Output()
-		if err != nil {
-			return
-		}
-		newName := strings.TrimSpace(string(newNameOutput))
-		if newName == "" {
-			return
-		}
-		if err := manager.CreateOrSwitch(newName); err != nil {
-			fmt.Fprintf(os.Stderr, "Error creating session: %v\n", err)
-			os.Exit(1)
-		}
-		return
+	if choice == ui.CreateLabel {
+		return picker.Input("Session name")
 	}
 
 	// Get the session name from the display text
@@ -204,17 +719,42 @@ func showInteractiveList() {
 		}
 	}
 
-	// Create or switch to the chosen session
-	if err := manager.CreateOrSwitch(sessionName); err != nil {
-		fmt.Fprintf(os.Stderr, "Error switching to session: %v\n", err)
-		os.Exit(1)
+	return sessionName, nil
+}
+
+// soleSession returns the only session in the list, if enabled and there is exactly one
+func soleSession(sessions []session.Session, enabled bool) (string, bool) {
+	if !enabled || len(sessions) != 1 {
+		return "", false
+	}
+	return sessions[0].Name, true
+}
+
+// autoSwitchSingle returns the auto_switch_single setting from config.yml
+// A config.yml that can't be read leaves it off, and the picker shows as usual
+func autoSwitchSingle() bool {
+	appConfig, err := config.NewLoader().LoadAppConfig()
+	if err != nil {
+		return false
 	}
+	return appConfig.AutoSwitchSingle
 }
 
 // listCmd creates the "session list" subcommand
 func listCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "list",
+	var showAll bool
+	var porcelain bool
+	var jsonOut bool
+	var watch bool
+	var interval time.Duration
+	var allSockets bool
+	var tag string
+	var exact bool
+	var glob bool
+	var summary bool
+
+	cmd := &cobra.Command{
+		Use:   "list [pattern]",
 		Short: "List all sessions",
 		Long: `List all available sessions with details.
 
@@ -223,78 +763,400 @@ Shows:
   ⚙ Tmuxinator projects (not yet started)
   ○ Default sessions from config (not yet started)
 
+Piped or redirected, or with --no-icons or $NO_COLOR set, the icons are
+plain ASCII instead: * active, + tmuxinator, - default.
+
+Hidden sessions are left out unless --all is given.
+
+With a pattern, only sessions whose name contains it (ignoring case) are
+listed. --exact lists only the session with exactly that name, and --glob
+treats the pattern as a shell glob like 'api-*'.
+
+With --summary, the list ends with how many sessions there are of each
+type, e.g. "3 active, 2 tmuxinator, 5 default (10 total)".
+
+With --tag, only sessions with that tag are listed. Tags come from the
+tags: of a session's config, and a running session gets the tags of the
+config with its name. --tag untagged lists the sessions without any.
+
+With --porcelain, each session is printed as TYPE, NAME, WINDOWS and DIR
+separated by tabs. This format is stable across versions, for scripts.
+
+With --json, the sessions are printed as a JSON array on one line.
+
+With --all-sockets, the active sessions of every tmux server (every socket
+in $TMUX_TMPDIR/tmux-<uid> or /tmp/tmux-<uid>) are listed, each tagged with
+its socket name. Sockets left behind by dead servers are skipped.
+
+With --watch, the list is printed again every --interval until ctrl-c.
+The human format redraws the screen; --json and --porcelain append, so
+--json --watch is a stream of JSON lines (one array per interval).
+
 Example:
-  sess list`,
+  sess list
+  sess list --all
+  sess list api
+  sess list --glob 'web-*'
+  sess list --tag work
+  sess list --porcelain | cut -f2
+  sess list --watch --interval 2s
+  sess list --watch --json
+  sess list --all-sockets`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			manager := createSessionManager()
-			sessions, err := manager.ListAll()
+			style := listStyle{format: formatHuman, icons: configuredIcons().ForWriter(os.Stdout), summary: summary}
+
+			pattern := ""
+			if len(args) > 0 {
+				pattern = args[0]
+			}
+			match := session.MatchSubstring
+			switch {
+			case exact:
+				match = session.MatchExact
+			case glob:
+				match = session.MatchGlob
+			}
+			switch {
+			case porcelain:
+				style.format = formatPorcelain
+			case jsonOut:
+				style.format = formatJSON
+			}
+
+			var list func() ([]session.Session, error)
+			if allSockets {
+				// Every server's sessions; tmuxinator projects and defaults
+				// don't belong to a server, so they're left out, but their
+				// config tags still apply to running sessions with their names
+				tmuxClient := newTmuxClient()
+				manager := createSessionManager()
+				list = func() ([]session.Session, error) {
+					sessions, err := tmuxClient.ListSessionsAllSockets(tmux.SocketDir())
+					if err != nil {
+						return nil, err
+					}
+					printWarnings(os.Stderr, manager.AttachTags(sessions))
+					if tag != "" {
+						sessions = session.FilterByTag(sessions, tag)
+					}
+					return session.FilterByName(sessions, pattern, match)
+				}
+			} else {
+				manager := createSessionManager()
+				list = func() ([]session.Session, error) {
+					if watch {
+						manager.RefreshProjects()
+					}
+					return listVisibleSessions(manager, session.ListOptions{IncludeHidden: showAll, Tag: tag, Pattern: pattern, Match: match})
+				}
+			}
+
+			if watch {
+				if interval <= 0 {
+					fmt.Fprintf(os.Stderr, "Error: --interval must be greater than zero\n")
+					os.Exit(1)
+				}
+
+				// ctrl-c cancels the context, which ends the watch cleanly
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+				defer stop()
+
+				ticker := time.NewTicker(interval)
+				defer ticker.Stop()
+
+				if err := watchList(ctx, os.Stdout, os.Stderr, ticker.C, list, style); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			sessions, err := list()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 
-			if len(sessions) == 0 {
-				fmt.Println("No sessions found")
-				return
+			if err := writeSessions(os.Stdout, sessions, style); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
 			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&showAll, "all", "a", false, "Include hidden sessions")
+	cmd.Flags().BoolVar(&porcelain, "porcelain", false, "Print a stable tab-separated format for scripts")
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "Print sessions as JSON")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Reprint the list every --interval until interrupted")
+	cmd.Flags().DurationVar(&interval, "interval", 2*time.Second, "How often --watch reprints the list")
+	cmd.Flags().StringVar(&tag, "tag", "", "Only list sessions with this tag ('untagged' for those without)")
+	cmd.Flags().BoolVar(&allSockets, "all-sockets", false, "List active sessions on every tmux server, tagged with their socket")
+	cmd.Flags().BoolVar(&exact, "exact", false, "Only list the session named exactly pattern")
+	cmd.Flags().BoolVar(&glob, "glob", false, "Treat pattern as a shell glob (*, ?, [abc])")
+	cmd.MarkFlagsMutuallyExclusive("porcelain", "json")
+	cmd.Flags().BoolVar(&summary, "summary", false, "End the list with the number of sessions of each type")
+	cmd.MarkFlagsMutuallyExclusive("exact", "glob")
+	// The summary would break the machine-readable formats
+	cmd.MarkFlagsMutuallyExclusive("summary", "porcelain")
+	cmd.MarkFlagsMutuallyExclusive("summary", "json")
+	// The porcelain columns are fixed, so there's nowhere to put the socket
+	cmd.MarkFlagsMutuallyExclusive("porcelain", "all-sockets")
+	return cmd
+}
+
+// listFormat is an output format for the list command
+type listFormat int
+
+const (
+	formatHuman listFormat = iota
+	formatPorcelain
+	formatJSON
+)
+
+// clearScreen moves the cursor home and clears the terminal (ANSI escape codes)
+const clearScreen = "\033[H\033[2J"
+
+// listStyle is how the list command prints sessions
+type listStyle struct {
+	format listFormat
+
+	// icons are the icons the human format draws, and their column width
+	icons output.Icons
+
+	// summary ends the human format with the number of sessions of each type
+	summary bool
+}
+
+// writeSessions prints sessions in the given style
+// The whole list is rendered into memory and written in one go: a write per
+// line is a syscall per line, which adds up for long lists piped elsewhere
+func writeSessions(w io.Writer, sessions []session.Session, style listStyle) error {
+	var buf bytes.Buffer
+	if err := renderSessions(&buf, sessions, style); err != nil {
+		return err
+	}
 
-			// Print sessions in a simple format
-			for _, sess := range sessions {
-				fmt.Printf("%s %s\n", sess.Icon(), sess.DisplayInfo())
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// renderSessions writes sessions to w in the style's format
+func renderSessions(w io.Writer, sessions []session.Session, style listStyle) error {
+	switch style.format {
+	case formatPorcelain:
+		return output.WritePorcelain(w, sessions)
+	case formatJSON:
+		return output.WriteJSON(w, sessions)
+	default:
+		if err := output.WriteList(w, sessions, style.icons); err != nil {
+			return err
+		}
+		if style.summary && len(sessions) > 0 {
+			_, err := fmt.Fprintln(w, output.FormatSummary(session.CountByType(sessions)))
+			return err
+		}
+		return nil
+	}
+}
+
+// configuredIcons returns the theme and icon_width settings from config.yml
+// A config.yml that can't be read falls back to the default icons at auto
+// width; the commands that depend on the rest of it report the problem
+// --no-icons and $NO_COLOR switch to plain ASCII markers
+func configuredIcons() output.Icons {
+	icons := output.Icons{ASCII: noIcons || os.Getenv("NO_COLOR") != ""}
+	appConfig, err := config.NewLoader().LoadAppConfig()
+	if err != nil {
+		return icons
+	}
+	icons.Theme = appConfig.Theme
+	icons.Width = appConfig.IconWidth
+	return icons
+}
+
+// watchList prints the list, then prints it again every time ticks fires,
+// until ctx is canceled. It's a plain loop rather than a TUI so the output
+// can be piped into a status bar or panel
+// Polls that fail are reported on errW, keeping them out of the piped output
+func watchList(
+	ctx context.Context,
+	w, errW io.Writer,
+	ticks <-chan time.Time,
+	list func() ([]session.Session, error),
+	style listStyle,
+) error {
+	for {
+		sessions, err := list()
+		if err != nil {
+			// A failed poll (tmux restarting, say) shouldn't end the watch
+			fmt.Fprintf(errW, "Error: %v\n", err)
+		} else {
+			// Each refresh is written at once, so a reader never sees a
+			// cleared screen or half a list
+			var frame bytes.Buffer
+
+			// Only the human format redraws; machine formats are a stream
+			if style.format == formatHuman {
+				frame.WriteString(clearScreen)
 			}
-		},
+			if err := renderSessions(&frame, sessions, style); err != nil {
+				return err
+			}
+			if _, err := w.Write(frame.Bytes()); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticks:
+		}
 	}
 }
 
 // lastCmd creates the "session last" subcommand
 func lastCmd() *cobra.Command {
-	return &cobra.Command{
+	var pick int
+
+	cmd := &cobra.Command{
 		Use:   "last",
 		Short: "Switch to last session",
-		Long: `Switch to the previously active tmux session.
+		Long: `Switch to the session you were in before this one.
 
-Useful for quickly toggling between two sessions.
-Must be run from inside tmux.
+Useful for quickly toggling between two sessions. The sessions come from
+sess's history (see 'sess history'), skipping any that aren't running, so
+--pick can go further back than tmux's own last session. Outside tmux,
+the most recently opened session is attached.
 
-Example:
-  sess last`,
+Inside tmux, with nothing in the history to go back to, tmux's own last
+session is used.
+
+Examples:
+  sess last            # The previous session
+  sess last --pick 3   # Three sessions back`,
+		Args: cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
 			manager := createSessionManager()
-			if err := manager.SwitchToLast(); err != nil {
+			if err := manager.SwitchToLast(pick); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 		},
 	}
+
+	cmd.Flags().IntVarP(&pick, "pick", "n", 1, "How many sessions to go back")
+	return cmd
 }
 
-// reloadCmd creates the "session reload" subcommand
-func reloadCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "reload",
-		Short: "Reload tmux config in all sessions",
-		Long: `Reload tmux configuration file in all active sessions.
+// attachCmd creates the "session attach" subcommand
+func attachCmd() *cobra.Command {
+	var detachOthers bool
 
-Useful after:
-  • Changing tmux theme
+	cmd := &cobra.Command{
+		Use:   "attach <session-name>",
+		Short: "Attach this terminal to a running session",
+		Long: `Attach this terminal to a running session with tmux attach-session.
+
+Unlike 'sess <name>', this never switches another client, and never
+creates a session: the session has to be running already. It's meant for
+a fresh terminal, so it refuses to run inside tmux.
+
+With -d, every other client attached to the session is detached, like
+--takeover.
+
+Example:
+  sess attach api
+  sess attach -d api   # Take api over from the terminal that has it`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			if err := manager.Attach(args[0], detachOthers); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&detachOthers, "detach-others", "d", false, "Detach the session's other clients")
+	return cmd
+}
+
+// detachCmd creates the "session detach" subcommand
+func detachCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "detach",
+		Short: "Detach from tmux, leaving the session running",
+		Long: `Detach the current tmux client without killing its session.
+
+Handy over SSH: the session keeps running, and "sess <name>" attaches it
+again later. Only works from inside tmux.
+
+Example:
+  sess detach`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			if err := manager.Detach(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// reloadCmd creates the "session reload" subcommand
+func reloadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reload [session-name]",
+		Short: "Reload tmux config in all sessions",
+		Long: `Reload tmux configuration file in all active sessions,
+or in just one session when a name is given.
+
+Useful after:
+  • Changing tmux theme
   • Modifying tmux.conf
   • Updating keybindings
 
-Example:
-  sess reload`,
+The config file is the first that exists of: --config, $SESS_TMUX_CONF,
+$XDG_CONFIG_HOME/tmux/tmux.conf, ~/.config/tmux/tmux.conf, ~/.tmux.conf
+
+Examples:
+  sess reload                          # Reload every session
+  sess reload api                      # Reload only the 'api' session
+  sess reload --config ~/.tmux.conf    # Source a specific file`,
+		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
-			tmuxClient := tmux.NewClient()
+			if len(args) > 0 {
+				manager := createSessionManager()
+				if err := manager.ReloadSession(args[0]); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				return
+			}
+
+			tmuxClient := newTmuxClient()
 			if err := tmuxClient.ReloadConfig(); err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 		},
 	}
+
+	cmd.Flags().StringVar(&tmuxConfigPath, "config", "", "tmux config file to source (env: SESS_TMUX_CONF)")
+	return cmd
 }
 
 // goCmd creates the "session go" subcommand
 func goCmd() *cobra.Command {
-	return &cobra.Command{
+	var createMissingDir bool
+	var noCreate bool
+	var elseCommand string
+	var fuzzy bool
+
+	cmd := &cobra.Command{
 		Use:   "go [session-name]",
 		Short: "Go to session if it exists, otherwise show picker",
 		Long: `Open a session if it exists, otherwise show the interactive picker.
@@ -302,32 +1164,183 @@ func goCmd() *cobra.Command {
 Different from 'session <name>' which creates a new session if not found.
 This command will fall back to the picker instead of creating.
 
+With --socket, the session is looked up and opened on that tmux server,
+and the picker fallback lists that server's sessions.
+
+If a default session's directory doesn't exist, you're asked whether to
+create it. --create-missing-dir answers yes and --no answers no, for
+scripts and keybindings where there's no terminal to ask on.
+
+With --else, a missing session runs the given shell command instead of
+showing the picker, and sess exits with that command's exit code.
+
+With --fuzzy, a name that doesn't exist goes to the one session close to
+it (a typo away, or starting with what you typed). If that session's name
+is quite different from what you typed, you're asked first ("Did you mean
+'production-db'?"); fuzzy_confirm_threshold in config.yml sets how
+different is too different.
+
 Examples:
   sess go dotfiles        # Open dotfiles if it exists, otherwise show picker
+  sess go api --else 'echo not found'   # Run a command when api doesn't exist
+  sess go --fuzzy dotfile # Open dotfiles, forgiving the typo
+  sess go --socket work api   # Open 'api' on the tmux server at socket 'work'
+  sess go --create-missing-dir api   # Create api's directory if it's missing
   sess go                 # Show picker (same as just 'sess')`,
 		Args: cobra.MaximumNArgs(1),
 		Run: func(cmd *cobra.Command, args []string) {
 			if len(args) == 0 {
-				showInteractiveList()
+				showInteractiveList(false, true)
 				return
 			}
 
+			switch {
+			case createMissingDir:
+				missingDir = session.MissingDirCreate
+			case noCreate:
+				missingDir = session.MissingDirAbort
+			default:
+				missingDir = session.MissingDirAsk
+			}
+
 			sessionName := args[0]
 			manager := createSessionManager()
+			manager.SetFuzzy(fuzzy)
+
+			if cmd.Flags().Changed("else") {
+				code, err := goOrElse(manager, newRunner(), sessionName, elseCommand)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				}
+				os.Exit(code)
+			}
 
 			err := manager.GoToSession(sessionName)
-			if err != nil {
+			if errors.Is(err, session.ErrSessionNotFound) {
 				// Session doesn't exist, show the picker
-				showInteractiveList()
+				showInteractiveList(false, false)
 				return
 			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&takeover, "takeover", false, "Detach other clients when attaching to a session that's already open")
+	cmd.Flags().BoolVar(&createMissingDir, "create-missing-dir", false, "Create a default session's directory without asking if it doesn't exist")
+	cmd.Flags().BoolVar(&noCreate, "no", false, "Don't create a missing directory, fail instead of asking")
+	cmd.Flags().BoolVar(&fuzzy, "fuzzy", false, "Go to the closest matching session when the name doesn't exist")
+	cmd.Flags().StringVar(&elseCommand, "else", "", "Shell command to run when the session doesn't exist (instead of the picker)")
+	cmd.MarkFlagsMutuallyExclusive("create-missing-dir", "no")
+	return cmd
+}
+
+// sessionOpener is the part of the manager goOrElse needs
+type sessionOpener interface {
+	SessionExists(name string) (bool, error)
+	GoToSession(name string) error
+}
+
+// goOrElse opens the session if it exists, and otherwise runs elseCommand
+// through the shell, for scripts that want their own fallback
+// It returns the exit code sess should exit with: the else command's own
+// exit code when it ran, or 1 if anything failed
+func goOrElse(opener sessionOpener, r runner.Runner, name, elseCommand string) (int, error) {
+	exists, err := opener.SessionExists(name)
+	if err != nil {
+		return 1, err
+	}
+
+	if exists {
+		if err := opener.GoToSession(name); err != nil {
+			return 1, err
+		}
+		return 0, nil
+	}
+
+	// The command gets the terminal, it may want to print or prompt
+	err = r.Interactive("sh", "-c", elseCommand)
+
+	// A non-zero exit isn't an error of ours, just the result to pass on
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitCode(), nil
+	}
+	if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// zCmd creates the "session z" subcommand
+func zCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "z <query>...",
+		Short: "Open a session for a directory found with zoxide",
+		Long: `Find a directory with zoxide and open a session rooted there.
+
+The query works like zoxide's own "z": the best ranked directory matching
+all the keywords wins. The session is named after the directory (with '.'
+and ':' replaced by '_'), and switched to if it's already running.
+
+If zoxide isn't installed, sess says where to get it and uses the query
+as a session name instead.
+
+Examples:
+  sess z api              # e.g. ~/code/api -> session 'api'
+  sess z code web         # Directory matching both 'code' and 'web'`,
+		Args: cobra.MinimumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+
+			if err := manager.Zoxide(strings.Join(args, " ")); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// hereCmd creates the "session here" subcommand
+func hereCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "here",
+		Short: "Open a session for the current directory",
+		Long: `Open a session rooted in the current directory, named after it.
+
+The name is the directory's name with '.' and ':' replaced by '_', like
+"sess z" uses. The home directory's session is called "home", and the
+filesystem root's "root". If the session is already running, sess switches
+to it.
+
+Examples:
+  cd ~/code/example.com && sess here   # Session 'example_com'
+  cd ~ && sess here                    # Session 'home'`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			dir, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			manager := createSessionManager()
+			if err := manager.OpenDirectory(dir); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
 		},
 	}
 }
 
 // deleteCmd creates the "session delete" subcommand
 func deleteCmd() *cobra.Command {
-	return &cobra.Command{
+	var opts session.TeardownOptions
+	var allButCurrent bool
+
+	cmd := &cobra.Command{
 		Use:   "delete <session-name>",
 		Short: "Delete a tmux session",
 		Long: `Delete an active tmux session.
@@ -335,20 +1348,1062 @@ func deleteCmd() *cobra.Command {
 Only works for active tmux sessions (●).
 Cannot delete tmuxinator projects or default sessions.
 
+You're asked to confirm first; --force skips the question (and is required
+when sess isn't running in a terminal).
+
+A session started from a tmuxinator project can be stopped with
+'tmuxinator stop' instead, which runs the project's on_project_stop hooks.
+You're asked whether to; --stop-project does it without asking.
+
+--all-but-current deletes every active session except the one you're in,
+like kill-all.
+
 Examples:
   sess delete old-project     # Delete the 'old-project' session
-  sess delete test            # Delete the 'test' session`,
-		Args: cobra.ExactArgs(1),
+  sess delete test -f         # Delete the 'test' session without asking
+  sess delete api --stop-project   # Stop the api project with tmuxinator
+  sess delete --all-but-current    # Clear out everything but this session`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if allButCurrent {
+				return cobra.NoArgs(cmd, args)
+			}
+			return cobra.ExactArgs(1)(cmd, args)
+		},
 		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+
+			if allButCurrent {
+				killed, err := manager.KillAll(session.KillAllOptions{Force: opts.Force})
+				for _, name := range killed {
+					fmt.Printf("Session '%s' deleted\n", name)
+				}
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				if len(killed) == 0 {
+					fmt.Println("No sessions deleted")
+				}
+				return
+			}
+
 			sessionName := args[0]
+			result, err := manager.Teardown(sessionName, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			switch result {
+			case session.TeardownKept:
+				fmt.Printf("Kept session '%s'\n", sessionName)
+			case session.TeardownStopped:
+				fmt.Printf("Stopped tmuxinator project '%s'\n", sessionName)
+			default:
+				fmt.Printf("Session '%s' deleted successfully\n", sessionName)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Don't ask for confirmation")
+	cmd.Flags().BoolVar(&opts.StopProject, "stop-project", false, "Stop a tmuxinator project with 'tmuxinator stop' without asking")
+	cmd.Flags().BoolVar(&allButCurrent, "all-but-current", false, "Delete every active session except the one you're in")
+	return cmd
+}
+
+// stopCmd creates the "session stop" subcommand
+func stopCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "stop <project>",
+		Short: "Stop a tmuxinator project",
+		Long: `Stop a running tmuxinator project with 'tmuxinator stop'.
+
+Unlike 'sess delete', this runs the project's on_project_stop hooks before
+its session is killed. The name has to be a tmuxinator project.
+
+Example:
+  sess stop api`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			if err := manager.StopProject(args[0]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Stopped tmuxinator project '%s'\n", args[0])
+		},
+	}
+}
+
+// killAllCmd creates the "session kill-all" subcommand
+func killAllCmd() *cobra.Command {
+	var opts session.KillAllOptions
+
+	cmd := &cobra.Command{
+		Use:   "kill-all",
+		Short: "Kill every active tmux session",
+		Long: `Kill every active tmux session at once.
+
+You're asked to confirm first; --force skips the question (and is required
+when sess isn't running in a terminal). The session you're in is left
+alone unless --include-current is given.
+
+--server ends the tmux server itself (tmux kill-server) instead of killing
+the sessions one by one. That takes the current session with it, so it
+needs --include-current when run from inside tmux.
+
+Examples:
+  sess kill-all                       # Kill everything but this session
+  sess kill-all --force               # Don't ask
+  sess kill-all --server --include-current`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			killed, err := manager.KillAll(opts)
+			for _, name := range killed {
+				fmt.Printf("Session '%s' deleted\n", name)
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(killed) == 0 {
+				fmt.Println("No sessions killed")
+			}
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.Force, "force", "f", false, "Don't ask for confirmation")
+	cmd.Flags().BoolVar(&opts.IncludeCurrent, "include-current", false, "Kill the session you're in too")
+	cmd.Flags().BoolVar(&opts.Server, "server", false, "End the tmux server with kill-server")
+	return cmd
+}
+
+// renameCmd creates the "session rename" subcommand
+func renameCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rename <old-name> <new-name>",
+		Short: "Rename an active tmux session",
+		Long: `Rename an active tmux session.
+
+Fails if the session isn't running or another session already has the new name.
+
+Example:
+  sess rename scratch api-spike`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			if err := manager.RenameSession(args[0], args[1]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Renamed session '%s' to '%s'\n", args[0], args[1])
+		},
+	}
+}
+
+// infoCmd creates the "session info" subcommand
+func infoCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "info <session-name>",
+		Short: "Show where a session comes from",
+		Long: `Show what a session is and how it would be started.
+
+Reports whether the name is an active session, a tmuxinator project, or a
+default session from config. A default session that references a tmuxinator
+project shows the project it will start through.
+
+Examples:
+  sess info api               # e.g. "default → tmuxinator: api-proj"`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+
+			info, err := manager.GetSessionInfo(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("%s: %s\n", args[0], info)
+		},
+	}
+}
+
+// metaCmd creates the "session meta" subcommand
+func metaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "meta <session-name> <key> [value]",
+		Short: "Set or remove a detail attached to a session",
+		Long: `Set a key=value detail on a session, or remove it when no value is given.
+
+Details are kept in ~/.config/sess/metadata.json by session name, so they
+stay with a name whether or not the session is running. sess reads these keys:
+
+  note    shown in the picker's preview
+  tags    comma-separated tags, added to the ones from config
+
+Examples:
+  sess meta api note "deploy on fridays"
+  sess meta api tags work,backend
+  sess meta api note                      # Remove the note`,
+		Args: cobra.RangeArgs(2, 3),
+		Run: func(cmd *cobra.Command, args []string) {
+			name, key := args[0], args[1]
+			value := ""
+			if len(args) == 3 {
+				value = args[2]
+			}
+
+			if value == "" {
+				if skipForDryRun("remove %s from %s's metadata", key, name) {
+					return
+				}
+			} else if skipForDryRun("set %s=%q in %s's metadata", key, value, name) {
+				return
+			}
+
+			manager := createSessionManager()
+			if err := manager.SetSessionMetadata(name, key, value); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// newCmd creates the "session new" subcommand
+func newCmd() *cobra.Command {
+	var cloneEnv bool
+	var detach bool
+
+	cmd := &cobra.Command{
+		Use:   "new <session-name>",
+		Short: "Create a new session",
+		Long: `Create a new tmux session and switch to it.
+
+Unlike 'sess <name>', this always creates a plain session and fails if one
+with that name is already running.
+
+With --clone-env, the new session starts with the current session's tmux
+environment (tmux show-environment), including in its first shell. It only
+works inside tmux.
+
+With --detach, the session is created in the background and you stay
+where you are, inside tmux or not.
+
+Examples:
+  sess new spike               # Create and switch to 'spike'
+  sess new spike --clone-env   # Same, with this session's environment
+  sess new spike -d            # Create 'spike' without switching to it`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			manager.SetDetached(detach)
+			if err := manager.NewSession(args[0], cloneEnv); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&cloneEnv, "clone-env", false, "Copy the current session's tmux environment into the new session")
+	cmd.Flags().BoolVarP(&detach, "detach", "d", false, "Create the session in the background instead of switching to it")
+	return cmd
+}
+
+// upCmd creates the "session up" subcommand
+func upCmd() *cobra.Command {
+	var opts session.UpOptions
+
+	cmd := &cobra.Command{
+		Use:   "up",
+		Short: "Start every default session in the background",
+		Long: `Start every default session that isn't running yet, without switching
+to any of them. Each gets its windows, options, and startup commands, just
+as if it were opened with 'sess <name>'. Sessions already running are left alone.
+
+--only limits it to the named sessions, and --tag to the sessions with a tag.
+
+Examples:
+  sess up                       # Everything in the sessions file
+  sess up --only api,web        # Just these two
+  sess up --tag work            # The sessions tagged work`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
 			manager := createSessionManager()
 
-			if err := manager.DeleteSession(sessionName); err != nil {
+			result, err := manager.BringUpDefaults(opts)
+			for _, name := range result.Created {
+				fmt.Printf("Started session '%s'\n", name)
+			}
+			fmt.Printf("%d %s started, %d already running\n", len(result.Created), pluralize(len(result.Created), "session", "sessions"), len(result.Skipped))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&opts.Only, "only", nil, "Only start these sessions (comma-separated)")
+	cmd.Flags().StringVar(&opts.Tag, "tag", "", "Only start sessions with this tag ('untagged' for those without)")
+	return cmd
+}
+
+// captureCmd creates the "session capture" subcommand
+func captureCmd() *cobra.Command {
+	var outputFile string
+	var allPanes bool
+	var scrollback int
+
+	cmd := &cobra.Command{
+		Use:   "capture <session-name>",
+		Short: "Save a session's pane content to a file",
+		Long: `Capture the text of a running session's active pane and write it to
+stdout, or to a file with -o. Handy for keeping a log before killing a session.
+
+--all-panes captures every pane in every window, each under a
+"==> session:window.pane <==" header. --scrollback includes that many
+lines of history above what's on screen.
+
+Examples:
+  sess capture api                      # Print api's active pane
+  sess capture api -o api.log           # Save it to a file
+  sess capture api --all-panes --scrollback 5000 -o api.log`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			if scrollback < 0 {
+				fmt.Fprintln(os.Stderr, "Error: --scrollback can't be negative")
+				os.Exit(1)
+			}
+
+			manager := createSessionManager()
+
+			text, err := manager.Capture(args[0], allPanes, scrollback)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if outputFile == "" {
+				fmt.Print(text)
+				return
+			}
+			if skipForDryRun("write %s", outputFile) {
+				return
+			}
+
+			if err := os.WriteFile(outputFile, []byte(text), 0o644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Captured session '%s' to %s\n", args[0], outputFile)
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputFile, "output", "o", "", "Write to this file instead of stdout")
+	cmd.Flags().BoolVar(&allPanes, "all-panes", false, "Capture every pane, not just the active one")
+	cmd.Flags().IntVar(&scrollback, "scrollback", 0, "Lines of history to include above the visible screen")
+	return cmd
+}
+
+// peekCmd creates the "session peek" subcommand
+func peekCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "peek <session-name>",
+		Short: "Show what's on a session's screen",
+		Long: `Print what's currently on screen in a running session's active pane,
+without switching to it. Blank lines below the last output are left off.
+
+Use "sess capture" to include scrollback or every pane, or to save to a file.
+
+Examples:
+  sess peek api           # Is the build in 'api' done yet?`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+
+			text, err := manager.Peek(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Print(text)
+		},
+	}
+}
+
+// moveWindowCmd creates the "session move-window" subcommand
+func moveWindowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "move-window <src-session> <window-index> <dst-session>",
+		Short: "Move a window to another session",
+		Long: `Move a window opened in the wrong session to another running one.
+
+The window goes to the end of the destination session, so it never
+collides with a window already there. Moving a session's only window
+leaves the session empty, and tmux closes it.
+
+Examples:
+  sess move-window api 3 web     # Move window 3 of 'api' to 'web'`,
+		Args: cobra.ExactArgs(3),
+		Run: func(cmd *cobra.Command, args []string) {
+			src, dst := args[0], args[2]
+			index, err := strconv.Atoi(args[1])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: window index must be a number, got %q\n", args[1])
+				os.Exit(1)
+			}
+
+			manager := createSessionManager()
+			ended, err := manager.MoveWindow(src, index, dst)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Moved window %d of '%s' to '%s'\n", index, src, dst)
+			if ended {
+				fmt.Printf("That was the last window, so session '%s' is closed\n", src)
+			}
+		},
+	}
+}
+
+// restartCmd creates the "session restart" subcommand
+func restartCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart <session-name>",
+		Short: "Kill and recreate a session",
+		Long: `Kill a session and recreate it fresh.
+
+Default sessions are rebuilt from their config.
+Ad-hoc active sessions are rebuilt with the same windows and directories.
+
+Examples:
+  sess restart api        # Recreate the 'api' session from scratch`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			sessionName := args[0]
+			manager := createSessionManager()
+
+			if err := manager.RestartSession(sessionName); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// scratchCmd creates the "session scratch" subcommand
+func scratchCmd() *cobra.Command {
+	var reset bool
+
+	cmd := &cobra.Command{
+		Use:   "scratch",
+		Short: "Switch to the throwaway scratch session",
+		Long: `Switch to an always-available throwaway session.
+
+The session is created in a temporary directory the first time.
+Use --clear to kill it and start over with an empty session.
+The name defaults to 'scratch' and can be changed with scratch_name in config.yml.
+
+Examples:
+  sess scratch            # Switch to (or create) the scratch session
+  sess scratch --clear    # Throw away the scratch session and start fresh`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			appConfig, err := config.NewLoader().LoadAppConfig()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			// The scratch directory lives in the temp dir so the OS cleans it up eventually
+			dir := filepath.Join(os.TempDir(), "sess-"+appConfig.ScratchName)
+			if !skipForDryRun("create %s", dir) {
+				if reset {
+					if err := os.RemoveAll(dir); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+				}
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+			}
+
+			manager := createSessionManager()
+			if err := manager.Scratch(appConfig.ScratchName, dir, reset); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&reset, "clear", false, "Kill the scratch session and recreate it empty")
+	return cmd
+}
+
+// syncCmd creates the "session sync" subcommand
+func syncCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "sync [on|off|toggle]",
+		Short: "Toggle synchronize-panes in the current window",
+		Long: `Turn synchronize-panes on or off for the current window.
+
+With synchronize-panes on, keystrokes go to every pane in the window.
+With no argument, the current state is toggled.
+Must be run from inside tmux.
+
+Examples:
+  sess sync               # Toggle synchronize-panes
+  sess sync on            # Type into every pane at once
+  sess sync off           # Back to normal`,
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"on", "off", "toggle"},
+		Run: func(cmd *cobra.Command, args []string) {
+			mode := "toggle"
+			if len(args) > 0 {
+				mode = args[0]
+			}
+
+			manager := createSessionManager()
+			on, err := manager.SyncPanes(mode)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if on {
+				fmt.Println("synchronize-panes: on")
+			} else {
+				fmt.Println("synchronize-panes: off")
+			}
+		},
+	}
+}
+
+// prevUniqueCmd creates the "session prev-unique" subcommand
+func prevUniqueCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "prev-unique",
+		Short: "Switch to the most recent session that isn't one of the last two",
+		Long: `Switch to the most recent session in the history other than the current
+session and the one before it.
+
+'sess last' toggles between the same two sessions. Running prev-unique
+repeatedly cycles back through more of them instead.
+
+Example:
+  sess prev-unique`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			if err := manager.PrevUnique(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// doctorChecks returns the checks "sess doctor" runs
+func doctorChecks() []doctor.Check {
+	loader := config.NewLoader()
+	r := newRunner()
+
+	return []doctor.Check{
+		doctor.TmuxCheck(r, newTmuxClient().Binary()),
+		doctor.InsideTmuxCheck(os.Getenv("TMUX")),
+		doctor.ToolCheck("tmuxinator", r.LookPath, "only needed for tmuxinator projects: gem install tmuxinator", tmux.TmuxinatorBinaries...),
+		doctor.ToolCheck("gum", r.LookPath, "only needed for --ui=gum: https://github.com/charmbracelet/gum", "gum"),
+		doctor.ToolCheck("fzf", r.LookPath, "only needed for --ui=fzf: https://github.com/junegunn/fzf", "fzf"),
+		doctor.DirCheck("Config directory", loader.Dir()),
+		doctor.SessionsFileCheck(loader.SessionsPath(platform), loader.GlobalSessionsPath()),
+		doctor.SessionsValidCheck(func() (int, int, error) {
+			return countIssues(loader.ValidateSessions(platform))
+		}),
+		doctor.TmuxConfigCheck(func() (string, error) {
+			return tmux.ResolveConfigPath(tmux.ConfigCandidates(tmuxConfigPath))
+		}),
+	}
+}
+
+// countIssues totals the errors and warnings across validated sessions files
+func countIssues(validations []*config.Validation, err error) (errs, warnings int, _ error) {
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, validation := range validations {
+		errs += validation.Errors()
+		warnings += len(validation.Issues) - validation.Errors()
+	}
+	return errs, warnings, nil
+}
+
+// printDiagnostics prints one line per result, marking what --fix could fix
+func printDiagnostics(w io.Writer, results []doctor.DiagnosticResult, canFix bool) {
+	for _, result := range results {
+		if result.OK {
+			fmt.Fprintf(w, "✓ %s: %s\n", result.Name, result.Detail)
+			continue
+		}
+
+		name := result.Name
+		if result.Optional {
+			name += " (optional)"
+		}
+		line := fmt.Sprintf("✗ %s: %s", name, result.Detail)
+		if canFix && result.Fix != nil {
+			line += " [fixable with --fix]"
+		}
+		fmt.Fprintln(w, line)
+	}
+}
+
+// doctorCmd creates the "session doctor" subcommand
+func doctorCmd() *cobra.Command {
+	var fix bool
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check sess's setup for problems",
+		Long: `Check sess's setup and explain anything that's wrong.
+
+Checks that tmux is installed and runs, whether sess is running inside
+tmux, whether tmuxinator (or mux), gum, and fzf are installed, that the
+config directory and sessions file exist and the sessions file is valid,
+and that there's a tmux config for reload to source. Missing tmuxinator,
+gum, fzf, or tmux config are reported but don't count as failures.
+
+With --fix, problems sess can remedy itself are fixed (creating the config
+directory and a starter sessions file), and the
+checks run again to show the result. Exits non-zero while anything fails.
+
+Examples:
+  sess doctor         # Report problems
+  sess doctor --fix   # Fix what can be fixed automatically`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			results := doctor.Run(doctorChecks())
+
+			if fix && !skipForDryRun("fix what --fix can fix") {
+				for _, outcome := range doctor.ApplyFixes(results) {
+					if outcome.Err != nil {
+						fmt.Printf("Couldn't fix %s: %v\n", outcome.Name, outcome.Err)
+						continue
+					}
+					fmt.Printf("Fixed %s\n", outcome.Name)
+				}
+
+				// Show where things stand now
+				results = doctor.Run(doctorChecks())
+			}
+
+			printDiagnostics(os.Stdout, results, !fix)
+			if doctor.Failed(results) {
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&fix, "fix", false, "Fix the problems sess can fix itself")
+	return cmd
+}
+
+// historyCmd creates the "session history" subcommand and its children
+func historyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the sessions you've opened",
+		Long: `Show the sessions you've opened, most recent first.
+
+sess records every session it creates or switches to in
+~/.config/sess/history.json, keeping the last 100 entries. 'sess last'
+goes back through it.
+
+Examples:
+  sess history            # Show the history
+  sess history prune      # Drop sessions that no longer exist
+  sess history clear      # Forget everything`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+
+			entries, err := manager.History()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(entries) == 0 {
+				fmt.Println("No history yet")
+				return
+			}
+
+			// Newest first, the way people think about "recent"
+			for i := len(entries) - 1; i >= 0; i-- {
+				fmt.Printf("%s  %s\n", entries[i].Time.Local().Format("2006-01-02 15:04"), entries[i].Name)
+			}
+		},
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "prune",
+		Short: "Remove history entries for sessions that no longer exist",
+		Long: `Remove history entries for sessions that aren't an active session,
+a tmuxinator project, or a default session anymore.
+
+The remaining entries keep their order.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+
+			removed, err := manager.PruneHistory()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Removed %d history %s\n", removed, pluralize(removed, "entry", "entries"))
+		},
+	})
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove the whole history",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+
+			if err := manager.ClearHistory(); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Println("History cleared")
+		},
+	})
+
+	return cmd
+}
+
+// pluralize picks the singular or plural form of a word for count
+func pluralize(count int, singular, plural string) string {
+	if count == 1 {
+		return singular
+	}
+	return plural
+}
+
+// configCmd creates the "session config" command group
+func configCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage default session config",
+	}
+
+	cmd.AddCommand(configAddCmd())
+	cmd.AddCommand(configValidateCmd())
+	return cmd
+}
+
+// configValidateCmd creates the "session config validate" subcommand
+func configValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Check the sessions file for mistakes",
+		Long: `Check ~/.config/sess/sessions-<platform>.yml, and the shared
+sessions.yml next to it, and list every problem with the line and column
+it's on.
+
+Sessions without a name, names used twice, and anything else that would
+stop the file from loading are errors. A directory that doesn't exist is
+only a warning, since sess go offers to create it when the session is opened,
+and so is a name with '.' or ':' in it, which tmux runs under another name.
+Exits with status 1 if there are errors.
+
+Example:
+  sess config validate
+  sess config validate --platform work`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			validations, err := config.NewLoader().ValidateSessions(platform)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			failed := false
+			for _, validation := range validations {
+				printValidation(os.Stdout, validation)
+				failed = failed || validation.Errors() > 0
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// printValidation lists a sessions file's issues followed by a one-line summary
+func printValidation(w io.Writer, validation *config.Validation) {
+	for _, issue := range validation.Issues {
+		level := "error"
+		if issue.Warning {
+			level = "warning"
+		}
+		fmt.Fprintf(w, "%s: %s\n", level, issue)
+	}
+
+	errs := validation.Errors()
+	warnings := len(validation.Issues) - errs
+	fmt.Fprintf(w, "%s: %d session(s), %d error(s), %d warning(s)\n", validation.Path, validation.Sessions, errs, warnings)
+}
+
+// editCmd creates the "session edit" subcommand
+func editCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "edit",
+		Short: "Open the sessions file in $EDITOR",
+		Long: `Open ~/.config/sess/sessions-<platform>.yml in $EDITOR (vi or nano
+when it isn't set). A missing file is created first from a commented
+template showing the format.
+
+Once the editor exits, the file is validated like 'sess config validate'
+and any problems are printed.
+
+Example:
+  sess edit
+  sess edit --platform work`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			loader := config.NewLoader()
+			path := loader.SessionsPath(platform)
+			if !skipForDryRun("create %s if it's missing", path) {
+				ensured, created, err := loader.EnsureSessionsFile(platform)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+					os.Exit(1)
+				}
+				if created {
+					fmt.Printf("Created %s\n", ensured)
+				}
+			}
+
+			editor, err := resolveEditor(os.Getenv("EDITOR"), exec.LookPath)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := newRunner().Interactive(editor[0], append(editor[1:], path)...); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s: %v\n", editor[0], err)
+				os.Exit(1)
+			}
+
+			validations, err := loader.ValidateSessions(platform)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			// A clean file needs no comment
+			failed := false
+			for _, validation := range validations {
+				if len(validation.Issues) > 0 {
+					printValidation(os.Stdout, validation)
+				}
+				failed = failed || validation.Errors() > 0
+			}
+			if failed {
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+// resolveEditor picks the command edit opens files with: $EDITOR, which
+// may include arguments ("code --wait"), otherwise vi or nano
+func resolveEditor(env string, lookPath func(string) (string, error)) ([]string, error) {
+	if fields := strings.Fields(env); len(fields) > 0 {
+		return fields, nil
+	}
+
+	for _, name := range []string{"vi", "nano"} {
+		if _, err := lookPath(name); err == nil {
+			return []string{name}, nil
+		}
+	}
+
+	return nil, errors.New("no editor found: set $EDITOR")
+}
+
+// importRunningCmd creates the "session import-running" subcommand
+func importRunningCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "import-running",
+		Short: "Save the running tmux sessions as default sessions",
+		Long: `Snapshot every running tmux session into the platform config file so it
+can be recreated later.
+
+Each session becomes a default session in the directory of its first
+window, with the rest of its windows and their directories listed under
+windows:. Sessions already in the config file are skipped, and comments in
+the file are kept.
+
+Use --platform to write to another platform's sessions file.
+
+Example:
+  sess import-running
+  sess import-running --platform work`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			imports, err := manager.ImportRunning()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if skipForDryRun("import %d running session(s) into %s", len(imports), config.NewLoader().SessionsPath(platform)) {
+				return
+			}
+			path, added, err := config.NewLoader().ImportSessions(platform, imports)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if len(added) == 0 {
+				fmt.Printf("Nothing to import: every running session is already in %s\n", path)
+				return
+			}
+
+			for _, name := range added {
+				fmt.Printf("Imported session '%s'\n", name)
+			}
+			fmt.Printf("Wrote %d session(s) to %s\n", len(added), path)
+		},
+	}
+}
+
+// saveCmd creates the "session save" subcommand
+func saveCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "save <session-name>",
+		Short: "Save a session's windows and panes as a snapshot",
+		Long: `Save a running session's layout so "sess restore" can recreate it.
+
+The snapshot records each window's name and pane layout, and each pane's
+directory and the program running in it. It's written to
+~/.config/sess/snapshots/<name>.yml, replacing any earlier snapshot.
+
+Example:
+  sess save api`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			manager := createSessionManager()
+			snapshot, err := manager.Snapshot(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			if skipForDryRun("save '%s' to %s", args[0], config.NewLoader().SnapshotPath(args[0])) {
+				return
+			}
+			path, err := config.NewLoader().SaveSnapshot(snapshot)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("Saved '%s' (%d windows) to %s\n", args[0], len(snapshot.Windows), path)
+		},
+	}
+}
+
+// restoreCmd creates the "session restore" subcommand
+func restoreCmd() *cobra.Command {
+	var opts session.RestoreOptions
+
+	cmd := &cobra.Command{
+		Use:   "restore <session-name>",
+		Short: "Recreate a session from its snapshot",
+		Long: `Recreate a session saved with "sess save" and switch to it.
+
+Windows and panes come back in their saved directories and layout. With
+--run-commands, the program each pane was running is started again (tmux
+only records the program, so "nvim main.go" comes back as "nvim").
+
+A session with the same name that's already running is left alone unless
+--overwrite is given, which kills it first.
+
+Example:
+  sess restore api
+  sess restore api --run-commands --overwrite`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			snapshot, err := config.NewLoader().LoadSnapshot(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			manager := createSessionManager()
+			if err := manager.Restore(snapshot, opts); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.RunCommands, "run-commands", false, "Start each pane's saved program again")
+	cmd.Flags().BoolVar(&opts.Overwrite, "overwrite", false, "Kill a running session with the same name first")
+
+	return cmd
+}
+
+// configAddCmd creates the "session config add" subcommand
+func configAddCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "add",
+		Short: "Add a default session with an interactive form",
+		Long: `Open a form to add a default session to the platform config file.
+
+The new entry is appended to ~/.config/sess/sessions-<platform>.yml,
+which is created if it doesn't exist yet.
+
+Example:
+  sess config add`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			finalModel, err := tea.NewProgram(ui.NewFormModel()).Run()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+
+			sessionConfig, ok := finalModel.(ui.FormModel).Result()
+			if !ok {
+				// User canceled the form
+				return
+			}
+
+			if skipForDryRun("add session '%s' to %s", sessionConfig.Name, config.NewLoader().SessionsPath(platform)) {
+				return
+			}
+			path, err := config.NewLoader().AddSessionConfig(platform, sessionConfig)
+			if err != nil {
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				os.Exit(1)
 			}
 
-			fmt.Printf("Session '%s' deleted successfully\n", sessionName)
+			fmt.Printf("Added session '%s' to %s\n", sessionConfig.Name, path)
 		},
 	}
 }
@@ -0,0 +1,648 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/datapointchris/sess/internal/config"
+	"github.com/datapointchris/sess/internal/output"
+	"github.com/datapointchris/sess/internal/runner"
+	"github.com/datapointchris/sess/internal/session"
+	"github.com/datapointchris/sess/internal/tmux"
+	"github.com/datapointchris/sess/internal/ui"
+)
+
+// TestLogLevel tests what each number of -v flags logs
+func TestLogLevel(t *testing.T) {
+	tests := []struct {
+		verbosity int
+		want      slog.Level
+	}{
+		{verbosity: 0, want: slog.LevelWarn},
+		{verbosity: 1, want: slog.LevelInfo},
+		{verbosity: 2, want: slog.LevelDebug},
+		{verbosity: 5, want: slog.LevelDebug},
+	}
+
+	for _, tt := range tests {
+		if got := logLevel(tt.verbosity); got != tt.want {
+			t.Errorf("logLevel(%d) = %v, want %v", tt.verbosity, got, tt.want)
+		}
+	}
+}
+
+// TestResolveTimeout tests the precedence of the command timeout settings
+func TestResolveTimeout(t *testing.T) {
+	tests := []struct {
+		name      string
+		flagValue time.Duration
+		flagSet   bool
+		env       string
+		want      time.Duration
+		wantError bool
+	}{
+		{
+			name: "default when nothing is set",
+			want: runner.DefaultTimeout,
+		},
+		{
+			name: "env overrides default",
+			env:  "45s",
+			want: 45 * time.Second,
+		},
+		{
+			name:      "flag overrides env",
+			flagValue: 2 * time.Second,
+			flagSet:   true,
+			env:       "45s",
+			want:      2 * time.Second,
+		},
+		{
+			name:      "flag set to zero disables the timeout",
+			flagValue: 0,
+			flagSet:   true,
+			want:      0,
+		},
+		{
+			name:      "invalid env",
+			env:       "soon",
+			wantError: true,
+		},
+		{
+			name:      "invalid env is ignored when the flag wins",
+			flagValue: time.Second,
+			flagSet:   true,
+			env:       "soon",
+			want:      time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveTimeout(tt.flagValue, tt.flagSet, tt.env)
+			if tt.wantError {
+				if err == nil {
+					t.Error("resolveTimeout() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveTimeout() unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("resolveTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWatchList tests that the initial print and a single tick each print the list
+func TestWatchList(t *testing.T) {
+	sessions := []session.Session{
+		{Name: "api", Type: session.SessionTypeTmux, WindowCount: 2, IsActive: true},
+	}
+
+	tests := []struct {
+		name   string
+		format listFormat
+		want   string
+	}{
+		{
+			name:   "human redraws the screen",
+			format: formatHuman,
+			want:   strings.Repeat(clearScreen+"● api (2 windows)\n", 2),
+		},
+		{
+			name:   "json streams one line per tick",
+			format: formatJSON,
+			want: strings.Repeat(`[{"name":"api","type":"tmux","window_count":2,"directory":"","description":"",`+
+				`"is_active":true}]`+"\n", 2),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			ticks := make(chan time.Time, 1)
+			ticks <- time.Now()
+
+			// Stop after the print triggered by the tick
+			calls := 0
+			list := func() ([]session.Session, error) {
+				calls++
+				if calls == 2 {
+					cancel()
+				}
+				return sessions, nil
+			}
+
+			var buf bytes.Buffer
+			if err := watchList(ctx, &buf, io.Discard, ticks, list, listStyle{format: tt.format}); err != nil {
+				t.Fatalf("watchList() unexpected error: %v", err)
+			}
+
+			if buf.String() != tt.want {
+				t.Errorf("watchList() wrote %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestWatchListFailedPoll tests that a failed poll is reported on the error
+// writer and the watch carries on to the next tick
+func TestWatchListFailedPoll(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ticks := make(chan time.Time, 1)
+	ticks <- time.Now()
+
+	calls := 0
+	list := func() ([]session.Session, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("no server running")
+		}
+		cancel()
+		return []session.Session{{Name: "api", Type: session.SessionTypeTmux, WindowCount: 1}}, nil
+	}
+
+	var out, errOut bytes.Buffer
+	if err := watchList(ctx, &out, &errOut, ticks, list, listStyle{format: formatJSON}); err != nil {
+		t.Fatalf("watchList() unexpected error: %v", err)
+	}
+
+	if want := "Error: no server running\n"; errOut.String() != want {
+		t.Errorf("watchList() reported %q, want %q", errOut.String(), want)
+	}
+	if !strings.Contains(out.String(), `"name":"api"`) {
+		t.Errorf("watchList() wrote %q, want the list from the second poll", out.String())
+	}
+}
+
+// fakeOpener is a sessionOpener with a fixed set of existing sessions
+type fakeOpener struct {
+	existing map[string]bool
+	opened   []string
+}
+
+func (f *fakeOpener) SessionExists(name string) (bool, error) {
+	return f.existing[name], nil
+}
+
+func (f *fakeOpener) GoToSession(name string) error {
+	f.opened = append(f.opened, name)
+	return nil
+}
+
+// recordingRunner records interactive commands without running them
+type recordingRunner struct {
+	interactive [][]string
+}
+
+func (r *recordingRunner) Run(name string, args ...string) error { return nil }
+
+func (r *recordingRunner) Output(name string, args ...string) ([]byte, error) { return nil, nil }
+
+func (r *recordingRunner) Interactive(name string, args ...string) error {
+	r.interactive = append(r.interactive, append([]string{name}, args...))
+	return nil
+}
+
+func (r *recordingRunner) InteractiveEnv(env []string, name string, args ...string) error {
+	return r.Interactive(name, args...)
+}
+
+func (r *recordingRunner) LookPath(name string) (string, error) { return name, nil }
+
+// TestGoOrElse tests the switch and run-else branches of go --else
+func TestGoOrElse(t *testing.T) {
+	t.Run("existing session is opened", func(t *testing.T) {
+		opener := &fakeOpener{existing: map[string]bool{"api": true}}
+		r := &recordingRunner{}
+
+		code, err := goOrElse(opener, r, "api", "echo not found")
+		if err != nil || code != 0 {
+			t.Fatalf("goOrElse() = %d, %v, want 0, nil", code, err)
+		}
+		if len(opener.opened) != 1 || opener.opened[0] != "api" {
+			t.Errorf("opened = %v, want [api]", opener.opened)
+		}
+		if len(r.interactive) != 0 {
+			t.Errorf("ran %v, want the else command skipped", r.interactive)
+		}
+	})
+
+	t.Run("missing session runs the else command", func(t *testing.T) {
+		opener := &fakeOpener{}
+		r := &recordingRunner{}
+
+		code, err := goOrElse(opener, r, "api", "echo not found")
+		if err != nil || code != 0 {
+			t.Fatalf("goOrElse() = %d, %v, want 0, nil", code, err)
+		}
+		if len(opener.opened) != 0 {
+			t.Errorf("opened = %v, want nothing", opener.opened)
+		}
+		want := "sh -c echo not found"
+		if len(r.interactive) != 1 || strings.Join(r.interactive[0], " ") != want {
+			t.Errorf("ran %v, want %q", r.interactive, want)
+		}
+	})
+
+	// These run a real shell to get real exit statuses
+	exitCodes := []struct {
+		command string
+		want    int
+	}{
+		{command: "true", want: 0},
+		{command: "exit 3", want: 3},
+		{command: "false", want: 1},
+	}
+	for _, tt := range exitCodes {
+		t.Run("exit code of "+tt.command, func(t *testing.T) {
+			code, err := goOrElse(&fakeOpener{}, runner.New(), "api", tt.command)
+			if err != nil {
+				t.Fatalf("goOrElse() unexpected error: %v", err)
+			}
+			if code != tt.want {
+				t.Errorf("goOrElse() exit code = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+// TestResolvePlatform tests the precedence of the platform settings
+func TestResolvePlatform(t *testing.T) {
+	tests := []struct {
+		name      string
+		flag      string
+		flagSet   bool
+		env       string
+		file      string // contents of the platform file, "" for no file
+		want      string
+		wantError bool
+	}{
+		{name: "auto-detect", want: detectPlatform()},
+		{name: "platform file overrides detection", file: "work\n", want: "work"},
+		{name: "env overrides the file", env: "home", file: "work", want: "home"},
+		{name: "flag wins over everything", flag: "laptop", flagSet: true, env: "home", file: "work", want: "laptop"},
+		{name: "empty flag is rejected", flagSet: true, env: "home", wantError: true},
+		{name: "blank platform file is ignored", file: "  \n", want: detectPlatform()},
+		{name: "path in the platform is rejected", flag: "../etc", flagSet: true, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("XDG_CONFIG_HOME", home)
+			if tt.file != "" {
+				dir := filepath.Join(home, "sess")
+				if err := os.MkdirAll(dir, 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "platform"), []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := resolvePlatform(tt.flag, tt.flagSet, tt.env, config.NewLoader())
+			if tt.wantError {
+				if err == nil {
+					t.Error("resolvePlatform() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolvePlatform() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolvePlatform() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWarnMissingPlatform tests that only a platform without a sessions file is warned about
+func TestWarnMissingPlatform(t *testing.T) {
+	dir := t.TempDir()
+	loader := config.NewLoaderWithDir(dir)
+	if err := os.WriteFile(loader.SessionsPath("work"), []byte("defaults: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	warnMissingPlatform(&out, loader, "work")
+	if out.Len() != 0 {
+		t.Errorf("warned %q about a platform with a sessions file", out.String())
+	}
+
+	warnMissingPlatform(&out, loader, "wrok")
+	if !strings.Contains(out.String(), "sessions-wrok.yml") {
+		t.Errorf("warning = %q, want it to name sessions-wrok.yml", out.String())
+	}
+
+	// The shared file supplies the sessions of every platform
+	out.Reset()
+	if err := os.WriteFile(loader.GlobalSessionsPath(), []byte("defaults: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	warnMissingPlatform(&out, loader, "linux")
+	if out.Len() != 0 {
+		t.Errorf("warned %q with a shared sessions file", out.String())
+	}
+}
+
+// countingWriter counts the Write calls made to it, each of which would be a
+// syscall on a real file
+type countingWriter struct {
+	writes int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.writes++
+	return len(p), nil
+}
+
+// manySessions returns a list long enough to show the cost of writing line by line
+func manySessions(n int) []session.Session {
+	sessions := make([]session.Session, n)
+	for i := range sessions {
+		sessions[i] = session.Session{
+			Name:        fmt.Sprintf("project-%d", i),
+			Type:        session.SessionTypeTmux,
+			WindowCount: 3,
+			Directory:   fmt.Sprintf("/home/me/code/project-%d", i),
+			IsActive:    true,
+		}
+	}
+	return sessions
+}
+
+// TestWriteSessionsSingleWrite tests that a whole list goes out in one write
+func TestWriteSessionsSingleWrite(t *testing.T) {
+	sessions := manySessions(1000)
+
+	for _, format := range []listFormat{formatHuman, formatPorcelain, formatJSON} {
+		w := &countingWriter{}
+		if err := writeSessions(w, sessions, listStyle{format: format}); err != nil {
+			t.Fatalf("writeSessions() unexpected error: %v", err)
+		}
+		if w.writes != 1 {
+			t.Errorf("format %d: %d writes, want 1", format, w.writes)
+		}
+	}
+}
+
+// BenchmarkWriteSessions compares writing a large list line by line with
+// the buffered single write; run with -benchmem and compare writes/op
+func BenchmarkWriteSessions(b *testing.B) {
+	sessions := manySessions(5000)
+
+	b.Run("unbuffered", func(b *testing.B) {
+		w := &countingWriter{}
+		for i := 0; i < b.N; i++ {
+			if err := renderSessions(w, sessions, listStyle{format: formatHuman}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+	})
+
+	b.Run("buffered", func(b *testing.B) {
+		w := &countingWriter{}
+		for i := 0; i < b.N; i++ {
+			if err := writeSessions(w, sessions, listStyle{format: formatHuman}); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(w.writes)/float64(b.N), "writes/op")
+	})
+}
+
+// TestSoleSession tests when the picker is skipped for a single session
+func TestSoleSession(t *testing.T) {
+	one := []session.Session{{Name: "api", Type: session.SessionTypeTmux}}
+	two := []session.Session{{Name: "api", Type: session.SessionTypeTmux}, {Name: "web", Type: session.SessionTypeDefault}}
+
+	tests := []struct {
+		name     string
+		sessions []session.Session
+		enabled  bool
+		want     string
+		wantOK   bool
+	}{
+		{name: "one session switches", sessions: one, enabled: true, want: "api", wantOK: true},
+		{name: "several sessions pick", sessions: two, enabled: true},
+		{name: "disabled always picks", sessions: one, enabled: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := soleSession(tt.sessions, tt.enabled)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("soleSession() = (%q, %v), want (%q, %v)", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestResolvePicker tests which picker --ui selects
+func TestResolvePicker(t *testing.T) {
+	tests := []struct {
+		name      string
+		flag      string
+		terminal  bool
+		installed []string
+		want      string
+	}{
+		{name: "auto in a terminal", flag: pickerAuto, terminal: true, want: pickerBubbletea},
+		{name: "auto without a terminal", flag: pickerAuto, terminal: false, installed: []string{"gum", "fzf"}, want: pickerGum},
+		{name: "auto without a terminal or gum", flag: pickerAuto, terminal: false, installed: []string{"fzf"}, want: pickerFzf},
+		{name: "auto with nothing installed", flag: pickerAuto, terminal: false, want: pickerGum},
+		{name: "gum chosen", flag: pickerGum, terminal: true, want: pickerGum},
+		{name: "fzf chosen", flag: pickerFzf, terminal: true, want: pickerFzf},
+		{name: "bubbletea chosen", flag: pickerBubbletea, terminal: false, want: pickerBubbletea},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			installed := func(name string) bool { return slices.Contains(tt.installed, name) }
+			if got := resolvePicker(tt.flag, tt.terminal, installed); got != tt.want {
+				t.Errorf("resolvePicker(%q, %v) = %q, want %q", tt.flag, tt.terminal, got, tt.want)
+			}
+		})
+	}
+
+	if err := validatePicker("dmenu"); err == nil {
+		t.Error("validatePicker(\"dmenu\") returned no error")
+	}
+}
+
+// stubPicker answers Choose and Input with canned replies
+type stubPicker struct {
+	choice  string
+	input   string
+	options []string
+}
+
+func (s *stubPicker) Choose(options []string, header string) (string, error) {
+	s.options = options
+	return s.choice, nil
+}
+
+func (s *stubPicker) Input(placeholder string) (string, error) {
+	return s.input, nil
+}
+
+// TestChooseWith tests mapping an external picker's answer back to a session name
+func TestChooseWith(t *testing.T) {
+	sessions := []session.Session{
+		{Name: "api", Type: session.SessionTypeTmux, IsActive: true},
+		{Name: "notes", Type: session.SessionTypeDefault},
+	}
+	icons := configuredIcons()
+
+	tests := []struct {
+		name   string
+		picker *stubPicker
+		want   string
+	}{
+		{name: "session", picker: &stubPicker{choice: output.FormatSessionLine(sessions[1], icons)}, want: "notes"},
+		{name: "new session", picker: &stubPicker{choice: ui.CreateLabel, input: "spike"}, want: "spike"},
+		{name: "canceled", picker: &stubPicker{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := chooseWith(tt.picker, sessions)
+			if err != nil {
+				t.Fatalf("chooseWith() unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("chooseWith() = %q, want %q", got, tt.want)
+			}
+			if len(tt.picker.options) != 3 || tt.picker.options[2] != ui.CreateLabel {
+				t.Errorf("options = %q, want both sessions and then %q", tt.picker.options, ui.CreateLabel)
+			}
+		})
+	}
+}
+
+// windowsRunner answers every query with the same list-windows output
+type windowsRunner struct {
+	recordingRunner
+	output string
+}
+
+func (r *windowsRunner) Output(name string, args ...string) ([]byte, error) {
+	return []byte(r.output), nil
+}
+
+// TestNewPicker tests that the picker the CLI opens previews the
+// highlighted session's windows and pads icons to icon_width
+func TestNewPicker(t *testing.T) {
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("NO_COLOR", "")
+	if err := os.MkdirAll(filepath.Join(configHome, "sess"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "sess", "config.yml"), []byte("icon_width: 4\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	client := tmux.NewClientWithRunner(&windowsRunner{output: "0\t1\t1\tc0d1,80x24,0,0,1\tnvim\teditor\t/src/api\n"})
+	manager := session.NewManager(client, nil, nil, "")
+	sessions := []session.Session{{Name: "api", Type: session.SessionTypeTmux, IsActive: true}}
+
+	model, _ := newPicker(manager, sessions, nil).Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	view := model.View()
+	if !strings.Contains(view, "editor") {
+		t.Errorf("View() doesn't preview api's windows:\n%s", view)
+	}
+	if !strings.Contains(view, "●    api") {
+		t.Errorf("View() doesn't pad the icon to icon_width 4:\n%s", view)
+	}
+}
+
+// TestResolveSort tests which sort applies with and without a setting
+func TestResolveSort(t *testing.T) {
+	tests := []struct {
+		name       string
+		setting    string
+		order      []string
+		hasHistory bool
+		want       session.SortMode
+		wantErr    bool
+	}{
+		{name: "nothing to go on", want: session.SortByName},
+		{name: "history", hasHistory: true, want: session.SortByRecent},
+		{name: "saved order beats history", order: []string{"api"}, hasHistory: true, want: session.SortByCustom},
+		{name: "setting beats everything", setting: "active", order: []string{"api"}, hasHistory: true, want: session.SortByActiveFirst},
+		{name: "bad setting", setting: "newest", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveSort(tt.setting, tt.order, tt.hasHistory)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveSort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveSort() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestResolveEditor tests picking the editor for sess edit
+func TestResolveEditor(t *testing.T) {
+	onlyNano := func(name string) (string, error) {
+		if name == "nano" {
+			return "/usr/bin/nano", nil
+		}
+		return "", exec.ErrNotFound
+	}
+	none := func(string) (string, error) { return "", exec.ErrNotFound }
+
+	tests := []struct {
+		name     string
+		env      string
+		lookPath func(string) (string, error)
+		want     []string
+		wantErr  bool
+	}{
+		{name: "EDITOR", env: "nvim", lookPath: none, want: []string{"nvim"}},
+		{name: "EDITOR with arguments", env: "code --wait", lookPath: none, want: []string{"code", "--wait"}},
+		{name: "falls back to what's installed", lookPath: onlyNano, want: []string{"nano"}},
+		{name: "nothing installed", lookPath: none, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveEditor(tt.env, tt.lookPath)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveEditor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("resolveEditor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/datapointchris/sess/internal/session"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFuzzyConfirmThreshold is the similarity below which a fuzzy match is
+// confirmed with the user before switching to it
+const DefaultFuzzyConfirmThreshold = 0.7
+
+// AppConfig holds settings for sess itself, as opposed to session definitions
+// This maps to ~/.config/sess/config.yml
+type AppConfig struct {
+	// ScratchName is the name of the throwaway session used by "sess scratch"
+	ScratchName string `yaml:"scratch_name"`
+
+	// Hidden lists session names to keep out of the picker and list
+	// Useful for background sessions that aren't defined in any config
+	Hidden []string `yaml:"hidden"`
+
+	// EventSocket is a Unix socket that session events (created, switched,
+	// deleted) are written to as JSON lines; empty means no events are sent
+	EventSocket string `yaml:"event_socket"`
+
+	// ResolverCommand is run with a session name when the name isn't an
+	// active session, tmuxinator project, or default session; a directory
+	// printed on success becomes the new session's starting directory
+	ResolverCommand string `yaml:"resolver_command"`
+
+	// IconWidth is the display width session icons are padded to in lists
+	// 0 (the default) measures the widest icon; set it when your font draws
+	// the icons wider than the terminal expects
+	IconWidth int `yaml:"icon_width"`
+
+	// Theme sets the icon and color each session type is listed with, keyed
+	// by type (tmux, tmuxinator, default); anything left out keeps its default
+	Theme session.Theme `yaml:"theme"`
+
+	// FuzzyConfirmThreshold is how similar (0 to 1) a fuzzy match must be to
+	// what was typed for "sess go --fuzzy" to switch without asking
+	// 0 never asks, 1 always asks
+	FuzzyConfirmThreshold float64 `yaml:"fuzzy_confirm_threshold"`
+
+	// NormalizeNames creates new sessions under a slugified name ("My
+	// Notes" becomes "My-Notes", "site.com" becomes "site_com") instead of
+	// handing tmux a name it would misread, and shows the original in lists
+	NormalizeNames bool `yaml:"normalize_names"`
+
+	// AutoSwitchSingle makes a bare "sess" switch straight to the only
+	// available session instead of showing a picker with one entry
+	AutoSwitchSingle bool `yaml:"auto_switch_single"`
+
+	// Sort is the order sessions are listed in: name, custom, recent, active, or activity
+	// Empty picks one: custom once the picker's order is saved, otherwise
+	// recent once there's a history, otherwise name
+	Sort string `yaml:"sort"`
+}
+
+// DefaultAppConfig returns the settings used when config.yml doesn't set them
+func DefaultAppConfig() AppConfig {
+	return AppConfig{
+		ScratchName:           "scratch",
+		FuzzyConfirmThreshold: DefaultFuzzyConfirmThreshold,
+	}
+}
+
+// LoadAppConfig loads config.yml, falling back to defaults for anything missing
+// A missing file is not an error - most users never need one
+func (l *Loader) LoadAppConfig() (*AppConfig, error) {
+	cfg := DefaultAppConfig()
+
+	configPath := filepath.Join(l.configDir, "config.yml")
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return &cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+	}
+
+	// Unmarshal on top of the defaults so unset keys keep their default value
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	// An explicitly empty name would make scratch unusable
+	if cfg.ScratchName == "" {
+		cfg.ScratchName = DefaultAppConfig().ScratchName
+	}
+
+	if cfg.FuzzyConfirmThreshold < 0 || cfg.FuzzyConfirmThreshold > 1 {
+		return nil, fmt.Errorf("fuzzy_confirm_threshold must be between 0 and 1, got %v", cfg.FuzzyConfirmThreshold)
+	}
+
+	for sessionType := range cfg.Theme {
+		if !slices.Contains(session.SessionTypes, sessionType) {
+			return nil, fmt.Errorf("invalid theme in %s: unknown session type %q (want tmux, tmuxinator, or default)", configPath, sessionType)
+		}
+	}
+
+	if cfg.Sort != "" {
+		if _, err := session.ParseSortMode(cfg.Sort); err != nil {
+			return nil, fmt.Errorf("invalid sort in %s: %w", configPath, err)
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	cfg.EventSocket = expandHome(cfg.EventSocket, home)
+	cfg.ResolverCommand = expandHome(cfg.ResolverCommand, home)
+
+	return &cfg, nil
+}
@@ -0,0 +1,152 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config files are hand-maintained, so sess edits them through yaml.Node
+// instead of unmarshaling into structs and marshaling back. Structs drop
+// every comment and put keys in struct order; the node tree keeps comments
+// attached and keys where the user put them, so a write only changes the
+// part of the file it's meant to change.
+// (The one thing yaml.v3 can't keep is blank lines between entries.)
+
+// readDocument parses a YAML file into a node tree
+// A missing or empty file gives an empty document with a mapping at the root
+func readDocument(path string) (*yaml.Node, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	// Unmarshaling nothing leaves a zero node, not an empty document
+	if doc.Kind == 0 {
+		doc = yaml.Node{
+			Kind:    yaml.DocumentNode,
+			Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("%s: top level must be a mapping of keys", path)
+	}
+
+	return &doc, nil
+}
+
+// writeDocument encodes the node tree back to path, creating its directory if needed
+// The file is written to a temp file and renamed into place, so a failed
+// write never leaves a half-written config behind
+// An existing file keeps its permissions; a new one gets 0644
+func writeDocument(path string, doc *yaml.Node) error {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+
+	// The temp file starts out 0600, so the rename would otherwise change it
+	mode := fs.FileMode(0o644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name()) // No-op once the rename has happened
+
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// mappingValue returns the value node for key in a mapping node, or nil if it's not there
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	// A mapping's Content alternates key, value, key, value...
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// sequenceFor returns the sequence stored under key, adding an empty one at
+// the end of the mapping if the key is missing
+// A key that's present but empty ("defaults:" with nothing after it) is
+// turned into a sequence in place so its comments stay put
+func sequenceFor(mapping *yaml.Node, key string) (*yaml.Node, error) {
+	value := mappingValue(mapping, key)
+	if value == nil {
+		value = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+			value,
+		)
+		return value, nil
+	}
+
+	if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+		value.Kind = yaml.SequenceNode
+		value.Tag = "!!seq"
+		value.Value = ""
+	}
+
+	if value.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("%q must be a list", key)
+	}
+	return value, nil
+}
+
+// appendToSequence encodes item as a node and adds it to the end of seq
+func appendToSequence(seq *yaml.Node, item any) error {
+	var node yaml.Node
+	if err := node.Encode(item); err != nil {
+		return fmt.Errorf("failed to encode YAML: %w", err)
+	}
+
+	// "defaults: []" is flow style, which would put the new entry on one
+	// line; switch an empty list to the usual block style
+	if len(seq.Content) == 0 {
+		seq.Style = 0
+	}
+
+	seq.Content = append(seq.Content, &node)
+	return nil
+}
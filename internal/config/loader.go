@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"maps"
 	"os"
+	"os/user"
 	"path/filepath"
 	"strings"
 
@@ -43,48 +47,317 @@ func NewLoader() *Loader {
 	}
 }
 
+// NewLoaderWithDir creates a loader that reads its files from dir instead
+// of the user's config directory, for tests and tools that keep a config elsewhere
+func NewLoaderWithDir(dir string) *Loader {
+	return &Loader{configDir: dir}
+}
+
+// Dir returns the directory sess keeps its files in (usually ~/.config/sess)
+func (l *Loader) Dir() string {
+	return l.configDir
+}
+
+// PlatformOverride returns the platform named in the platform file
+// (~/.config/sess/platform), or "" if there's no such file
+// It lets a machine use a sessions file that auto-detection wouldn't pick,
+// like sessions-work.yml on a Linux box
+func (l *Loader) PlatformOverride() (string, error) {
+	path := filepath.Join(l.configDir, "platform")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read platform file %s: %w", path, err)
+	}
+
+	return strings.TrimSpace(string(data)), nil
+}
+
+// SessionsPath builds the path to the sessions config file for a platform
+// e.g., ~/.config/sess/sessions-macos.yml
+func (l *Loader) SessionsPath(platform string) string {
+	filename := fmt.Sprintf("sessions-%s.yml", platform)
+	return filepath.Join(l.configDir, filename)
+}
+
+// sessionsTemplate is written as a new sessions file, commented so the
+// format can be picked up without the README
+const sessionsTemplate = `# Default sessions for sess, shown in the picker even when they aren't running
+# Sessions shared by every machine can go in sessions.yml next to this file
+#
+# defaults:
+#   - name: dotfiles
+#     directory: ~/dotfiles
+#     description: Personal dotfiles
+#
+#   - name: api
+#     directory: $WORK/api       # ~ and environment variables are expanded
+#     commands:                  # typed into the first window
+#       - nvim
+#     windows:                   # more windows after the first
+#       - name: server
+#         commands:
+#           - make run
+#
+#   - name: webapp
+#     tmuxinator_project: webapp # start a tmuxinator project instead
+defaults: []
+`
+
+// EnsureSessionsFile creates the platform's sessions file from a commented
+// template if it doesn't exist yet
+// Returns the path, and whether it had to be created
+func (l *Loader) EnsureSessionsFile(platform string) (string, bool, error) {
+	configPath := l.SessionsPath(platform)
+
+	if err := os.MkdirAll(l.configDir, 0o755); err != nil {
+		return "", false, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	// O_EXCL so an existing file is never overwritten, even one created since the check
+	file, err := os.OpenFile(configPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return configPath, false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to create %s: %w", configPath, err)
+	}
+	if _, err := file.WriteString(sessionsTemplate); err != nil {
+		file.Close()
+		return "", false, fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", false, fmt.Errorf("failed to write %s: %w", configPath, err)
+	}
+
+	return configPath, true, nil
+}
+
+// GlobalSessionsPath builds the path to the sessions config file shared by every platform
+// e.g., ~/.config/sess/sessions.yml
+func (l *Loader) GlobalSessionsPath() string {
+	return filepath.Join(l.configDir, "sessions.yml")
+}
+
 // LoadDefaultSessions loads default sessions for the given platform
+// Sessions from the shared sessions.yml come first, and a session in the
+// platform's file replaces a shared one with the same name. Either file may
+// be missing, but not both
 func (l *Loader) LoadDefaultSessions(platform string) ([]session.SessionConfig, error) {
-	// Build the path to the sessions config file
-	// e.g., ~/.config/sess/sessions-macos.yml
-	filename := fmt.Sprintf("sessions-%s.yml", platform)
-	configPath := filepath.Join(l.configDir, filename)
+	sources, err := l.readSources(platform)
+	if err != nil {
+		return nil, err
+	}
+
+	home, _ := os.UserHomeDir()
+
+	// Projects are shared too, so a platform's session can use a shared layout
+	projects := make(map[string]session.Project)
+	for _, source := range sources {
+		sourceProjects, err := source.projects(home)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.path, err)
+		}
+		maps.Copy(projects, sourceProjects)
+	}
+
+	var merged []session.SessionConfig
+	position := make(map[string]int)
+	for _, source := range sources {
+		configs, err := source.defaults(projects, home)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", source.path, err)
+		}
+
+		for _, config := range configs {
+			if i, ok := position[config.Name]; ok {
+				// Overridden in place, so the shared file's order is kept
+				merged[i] = config
+				continue
+			}
+			position[config.Name] = len(merged)
+			merged = append(merged, config)
+		}
+	}
+
+	return merged, nil
+}
+
+// SourcePaths returns the sessions files a platform's sessions are read
+// from, in the order they're merged: the shared file, then the platform's
+func (l *Loader) SourcePaths(platform string) []string {
+	return []string{l.GlobalSessionsPath(), l.SessionsPath(platform)}
+}
+
+// readSources reads the shared and platform sessions files, in that order,
+// skipping whichever doesn't exist
+func (l *Loader) readSources(platform string) ([]*sessionsSource, error) {
+	var sources []*sessionsSource
+	for _, configPath := range l.SourcePaths(platform) {
+		source, err := readSessionsFile(configPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, source)
+	}
+
+	// Without either file, report the platform one, which is the one most people have
+	if len(sources) == 0 {
+		configPath := l.SessionsPath(platform)
+		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, fs.ErrNotExist)
+	}
+
+	return sources, nil
+}
+
+// sessionsSource is a parsed sessions file, along with where it came from
+type sessionsSource struct {
+	path string
+	file sessionsFile
+
+	// nodes are the YAML entries of file.Defaults, so errors can say where they are
+	nodes []*yaml.Node
+}
 
+// readSessionsFile reads and parses a sessions file
+// A missing file returns an error wrapping fs.ErrNotExist
+func readSessionsFile(configPath string) (*sessionsSource, error) {
 	// Read the file
 	// os.ReadFile() is the modern way to read an entire file into memory
 	data, err := os.ReadFile(configPath)
 	if err != nil {
-		// If the file doesn't exist or can't be read, return an error
 		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
 	}
 
 	// Parse the YAML
 	// In Go, we unmarshal (decode) YAML into a struct
-	// The YAML file uses "defaults:" as the top-level key
-	var config struct {
-		Defaults []session.SessionConfig `yaml:"defaults"`
+	file, nodes, err := parseSessions(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", configPath, err)
 	}
 
-	// yaml.Unmarshal() parses the YAML into our struct
-	if err := yaml.Unmarshal(data, &config); err != nil {
-		return nil, fmt.Errorf("failed to parse YAML: %w", err)
+	// Sessions are looked up by name, so every one needs a distinct one
+	if issues := checkDefaults(file.Defaults, nodes); len(issues) > 0 {
+		return nil, fmt.Errorf("%s: %s", configPath, issues[0])
 	}
 
-	// Expand ~ in directory paths to the actual home directory
-	home, _ := os.UserHomeDir()
-	for i := range config.Defaults {
-		// If directory starts with ~, replace it with home directory
-		if strings.HasPrefix(config.Defaults[i].Directory, "~") {
-			config.Defaults[i].Directory = strings.Replace(
-				config.Defaults[i].Directory,
-				"~",
-				home,
-				1, // Only replace the first occurrence
-			)
+	return &sessionsSource{path: configPath, file: file, nodes: nodes}, nil
+}
+
+// projects validates the file's projects and expands their directories
+func (s *sessionsSource) projects(home string) (map[string]session.Project, error) {
+	projects := make(map[string]session.Project, len(s.file.Projects))
+	for name, project := range s.file.Projects {
+		if err := project.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid project %q: %w", name, err)
+		}
+
+		project.Directory = expandDirectory(project.Directory, home)
+		project.Windows = expandWindows(project.Windows, home)
+		projects[name] = project
+	}
+	return projects, nil
+}
+
+// defaults validates the file's default sessions, expands their directories,
+// and attaches the project each one references
+func (s *sessionsSource) defaults(projects map[string]session.Project, home string) ([]session.SessionConfig, error) {
+	configs := s.file.Defaults
+	for i := range configs {
+		// Expand ~ and environment variables in directory paths
+		configs[i].Directory = expandDirectory(configs[i].Directory, home)
+
+		// Catch option typos now rather than when tmux rejects them
+		for key := range configs[i].Options {
+			if err := session.ValidateOptionName(key); err != nil {
+				return nil, entryError(s.nodes, i, fmt.Errorf("session %q: %w", configs[i].Name, err))
+			}
+		}
+
+		// An empty command would just press enter in the new session
+		for _, command := range configs[i].Commands {
+			if strings.TrimSpace(command) == "" {
+				return nil, entryError(s.nodes, i, fmt.Errorf("session %q has an empty command", configs[i].Name))
+			}
+		}
+
+		// Inline windows follow the same rules as a project's
+		if err := (session.Project{Windows: configs[i].Windows}).Validate(); err != nil {
+			return nil, entryError(s.nodes, i, fmt.Errorf("session %q: %w", configs[i].Name, err))
+		}
+		configs[i].Windows = expandWindows(configs[i].Windows, home)
+
+		// Attach the referenced project to each session that uses one
+		projectName := configs[i].Project
+		if projectName == "" {
+			continue // flat config, nothing to expand
+		}
+
+		project, ok := projects[projectName]
+		if !ok {
+			return nil, entryError(s.nodes, i, fmt.Errorf("session %q references unknown project %q", configs[i].Name, projectName))
+		}
+		configs[i].ResolvedProject = &project
+	}
+
+	return configs, nil
+}
+
+// expandHome replaces a leading ~ in path with the home directory
+func expandHome(path, home string) string {
+	if strings.HasPrefix(path, "~") {
+		return strings.Replace(path, "~", home, 1) // Only replace the first occurrence
+	}
+	return path
+}
+
+// expandDirectory expands a directory from the sessions file: a leading ~
+// or ~user, then $VAR and ${VAR} anywhere in it
+// Unset variables expand to nothing, like in a shell (validation warns about them)
+func expandDirectory(path, home string) string {
+	if rest, ok := strings.CutPrefix(path, "~"); ok && rest != "" && !strings.HasPrefix(rest, "/") {
+		// ~user/code is another user's home, left alone if there's no such user
+		name, tail, _ := strings.Cut(rest, "/")
+		if u, err := user.Lookup(name); err == nil {
+			path = filepath.Join(u.HomeDir, tail)
 		}
+	} else {
+		path = expandHome(path, home)
 	}
 
-	return config.Defaults, nil
+	return os.ExpandEnv(path)
+}
+
+// unsetVariables returns the environment variables path uses that aren't set
+func unsetVariables(path string) []string {
+	var unset []string
+	os.Expand(path, func(name string) string {
+		if _, ok := os.LookupEnv(name); !ok {
+			unset = append(unset, name)
+		}
+		return ""
+	})
+	return unset
+}
+
+// expandWindows returns a copy of windows with ~ and variables expanded in their directories
+// Copying keeps the slice shared with the parsed YAML unmodified
+func expandWindows(windows []session.WindowConfig, home string) []session.WindowConfig {
+	if windows == nil {
+		return nil
+	}
+
+	expanded := make([]session.WindowConfig, len(windows))
+	for i, window := range windows {
+		window.Directory = expandDirectory(window.Directory, home)
+		expanded[i] = window
+	}
+	return expanded
 }
 
 // GetSessionConfig retrieves a specific session configuration by name
@@ -110,5 +383,150 @@ func (l *Loader) GetSessionConfig(name, platform string) (*session.SessionConfig
 	return nil, fmt.Errorf("session %q not found in config", name)
 }
 
+// AddSessionConfig appends a default session to the platform's config file
+// The file (and config directory) are created if they don't exist yet
+// Comments and key order in the existing file are kept (see edit.go)
+// Returns the path that was written
+func (l *Loader) AddSessionConfig(platform string, config session.SessionConfig) (string, error) {
+	configPath := l.SessionsPath(platform)
+
+	// Work on the raw file rather than going through LoadDefaultSessions,
+	// so ~ in directories isn't expanded in what we write back
+	doc, err := readDocument(configPath)
+	if err != nil {
+		return "", err
+	}
+
+	var file struct {
+		Defaults []session.SessionConfig `yaml:"defaults"`
+	}
+	if err := doc.Decode(&file); err != nil {
+		return "", fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	defined, err := l.definedSessions(platform)
+	if err != nil {
+		return "", err
+	}
+	if defined[config.Name] {
+		// The platform's file wins over the shared one, so check it first
+		definedIn := l.GlobalSessionsPath()
+		for _, existing := range file.Defaults {
+			if existing.Name == config.Name {
+				definedIn = configPath
+			}
+		}
+		return "", fmt.Errorf("session %q already exists in %s", config.Name, definedIn)
+	}
+
+	defaults, err := sequenceFor(doc.Content[0], "defaults")
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", configPath, err)
+	}
+	if err := appendToSequence(defaults, config); err != nil {
+		return "", err
+	}
+
+	if err := writeDocument(configPath, doc); err != nil {
+		return "", err
+	}
+
+	return configPath, nil
+}
+
+// ImportSessions appends imported sessions to the platform's config file in one write
+// Sessions whose name is already a default session, in either sessions file,
+// are skipped, so running an import twice doesn't duplicate anything
+// Directories under the home directory are written with ~ so the file
+// works for the same user on another machine
+// Returns the path written and the names that were added
+func (l *Loader) ImportSessions(platform string, imports []session.SessionConfig) (string, []string, error) {
+	configPath := l.SessionsPath(platform)
+
+	doc, err := readDocument(configPath)
+	if err != nil {
+		return "", nil, err
+	}
+
+	existing, err := l.definedSessions(platform)
+	if err != nil {
+		return "", nil, err
+	}
+
+	home, _ := os.UserHomeDir()
+	var added []string
+	for _, config := range imports {
+		if existing[config.Name] {
+			continue
+		}
+
+		config.Directory = contractHome(config.Directory, home)
+		windows := make([]session.WindowConfig, len(config.Windows))
+		for i, window := range config.Windows {
+			window.Directory = contractHome(window.Directory, home)
+			windows[i] = window
+		}
+		config.Windows = windows
+
+		defaults, err := sequenceFor(doc.Content[0], "defaults")
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", configPath, err)
+		}
+		if err := appendToSequence(defaults, config); err != nil {
+			return "", nil, err
+		}
+
+		existing[config.Name] = true
+		added = append(added, config.Name)
+	}
+
+	// Nothing new means nothing to rewrite
+	if len(added) == 0 {
+		return configPath, nil, nil
+	}
+
+	if err := writeDocument(configPath, doc); err != nil {
+		return "", nil, err
+	}
+
+	return configPath, added, nil
+}
+
+// definedSessions returns the names of the platform's default sessions, from
+// the shared sessions.yml as well as the platform's own file
+// A name in either is taken: written to the platform's file again, it would
+// quietly replace the shared session
+func (l *Loader) definedSessions(platform string) (map[string]bool, error) {
+	configs, err := l.LoadDefaultSessions(platform)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Neither file exists yet, so nothing is defined
+		return map[string]bool{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		names[config.Name] = true
+	}
+	return names, nil
+}
+
+// contractHome replaces a leading home directory in path with ~
+// It's the reverse of expandHome
+func contractHome(path, home string) string {
+	if home == "" {
+		return path
+	}
+	if path == home {
+		return "~"
+	}
+	if rest, ok := strings.CutPrefix(path, home+string(filepath.Separator)); ok {
+		return "~/" + rest
+	}
+	return path
+}
+
 // Verify interface implementation at compile time
 var _ session.ConfigLoader = (*Loader)(nil)
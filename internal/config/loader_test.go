@@ -0,0 +1,785 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// writeConfig writes a sessions file for the given platform into dir
+func writeConfig(t *testing.T, dir, platform, content string) {
+	t.Helper()
+
+	path := filepath.Join(dir, "sessions-"+platform+".yml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+// TestSessionsPath tests that the sessions file follows XDG_CONFIG_HOME
+func TestSessionsPath(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+
+	t.Run("XDG_CONFIG_HOME", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "/xdg")
+		loader := NewLoader()
+		if got := loader.Dir(); got != "/xdg/sess" {
+			t.Errorf("Dir() = %q, want /xdg/sess", got)
+		}
+		if got := loader.SessionsPath("macos"); got != "/xdg/sess/sessions-macos.yml" {
+			t.Errorf("SessionsPath() = %q, want /xdg/sess/sessions-macos.yml", got)
+		}
+	})
+
+	t.Run("home", func(t *testing.T) {
+		t.Setenv("XDG_CONFIG_HOME", "")
+		if got := NewLoader().SessionsPath("wsl"); got != "/home/me/.config/sess/sessions-wsl.yml" {
+			t.Errorf("SessionsPath() = %q, want /home/me/.config/sess/sessions-wsl.yml", got)
+		}
+	})
+}
+
+// TestLoadDefaultSessionsProjects tests project reference expansion
+func TestLoadDefaultSessionsProjects(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", "/home/test")
+	writeConfig(t, dir, "linux", `
+projects:
+  webapp:
+    directory: ~/code/webapp
+    commands:
+      - nvim
+    windows:
+      - name: server
+        commands:
+          - npm run dev
+      - name: logs
+        directory: /var/log
+
+defaults:
+  - name: webapp
+    project: webapp
+  - name: webapp-review
+    directory: ~/review/webapp
+    project: webapp
+`)
+	loader := NewLoaderWithDir(dir)
+
+	sessions, err := loader.LoadDefaultSessions("linux")
+	if err != nil {
+		t.Fatalf("LoadDefaultSessions() returned error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	// Both sessions share the same project
+	for _, sess := range sessions {
+		project := sess.ResolvedProject
+		if project == nil {
+			t.Fatalf("session %q has no resolved project", sess.Name)
+		}
+		if project.Directory != "/home/test/code/webapp" {
+			t.Errorf("project directory = %q, want /home/test/code/webapp", project.Directory)
+		}
+		if len(project.Windows) != 2 || project.Windows[0].Name != "server" || project.Windows[1].Directory != "/var/log" {
+			t.Errorf("project windows = %+v, want server and logs", project.Windows)
+		}
+	}
+
+	// The session's own directory is kept so it can override the project's
+	if sessions[1].Directory != "/home/test/review/webapp" {
+		t.Errorf("session directory = %q, want /home/test/review/webapp", sessions[1].Directory)
+	}
+}
+
+// TestLoadDefaultSessionsFlat tests that configs without projects still load
+func TestLoadDefaultSessionsFlat(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", "/home/test")
+	writeConfig(t, dir, "macos", `
+defaults:
+  - name: dotfiles
+    directory: ~/dotfiles
+    description: Dotfiles development
+  - name: api
+    directory: ~/code/api
+    tmuxinator_project: api-dev
+`)
+	loader := NewLoaderWithDir(dir)
+
+	sessions, err := loader.LoadDefaultSessions("macos")
+	if err != nil {
+		t.Fatalf("LoadDefaultSessions() returned error: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("got %d sessions, want 2", len(sessions))
+	}
+
+	if sessions[0].Directory != "/home/test/dotfiles" || sessions[0].ResolvedProject != nil {
+		t.Errorf("dotfiles = %+v, want flat session in /home/test/dotfiles", sessions[0])
+	}
+	if sessions[1].TmuxinatorProject != "api-dev" {
+		t.Errorf("api tmuxinator project = %q, want api-dev", sessions[1].TmuxinatorProject)
+	}
+}
+
+// TestLoadDefaultSessionsProjectErrors tests invalid project references
+func TestLoadDefaultSessionsProjectErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{
+			name: "unknown project",
+			content: `
+defaults:
+  - name: api
+    project: missing
+`,
+		},
+		{
+			name: "window without name",
+			content: `
+projects:
+  api:
+    windows:
+      - directory: /tmp
+defaults:
+  - name: api
+    project: api
+`,
+		},
+		{
+			name: "inline window without name",
+			content: `
+defaults:
+  - name: api
+    directory: /tmp
+    windows:
+      - directory: /tmp
+`,
+		},
+		{
+			name: "empty session command",
+			content: `
+defaults:
+  - name: api
+    directory: /tmp
+    commands:
+      - nvim
+      - ""
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfig(t, dir, "linux", tt.content)
+			loader := NewLoaderWithDir(dir)
+
+			if _, err := loader.LoadDefaultSessions("linux"); err == nil {
+				t.Error("LoadDefaultSessions() expected error but got none")
+			}
+		})
+	}
+}
+
+// TestLoadDefaultSessionsGlobal tests merging the shared sessions.yml with the platform file
+func TestLoadDefaultSessionsGlobal(t *testing.T) {
+	tests := []struct {
+		name     string
+		global   string
+		platform string
+		want     []string // name=directory, in order
+	}{
+		{
+			name: "only global",
+			global: `
+defaults:
+  - name: dotfiles
+    directory: /shared/dotfiles
+`,
+			want: []string{"dotfiles=/shared/dotfiles"},
+		},
+		{
+			name: "only platform",
+			platform: `
+defaults:
+  - name: api
+    directory: /linux/api
+`,
+			want: []string{"api=/linux/api"},
+		},
+		{
+			name: "platform overrides global",
+			global: `
+defaults:
+  - name: dotfiles
+    directory: /shared/dotfiles
+  - name: notes
+    directory: /shared/notes
+`,
+			platform: `
+defaults:
+  - name: dotfiles
+    directory: /linux/dotfiles
+`,
+			want: []string{"dotfiles=/linux/dotfiles", "notes=/shared/notes"},
+		},
+		{
+			name: "disjoint names",
+			global: `
+defaults:
+  - name: notes
+    directory: /shared/notes
+`,
+			platform: `
+defaults:
+  - name: api
+    directory: /linux/api
+`,
+			want: []string{"notes=/shared/notes", "api=/linux/api"},
+		},
+		{
+			name: "platform session uses a shared project",
+			global: `
+projects:
+  web:
+    directory: /shared/web
+`,
+			platform: `
+defaults:
+  - name: web
+    directory: /linux/web
+    project: web
+`,
+			want: []string{"web=/linux/web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if tt.global != "" {
+				if err := os.WriteFile(filepath.Join(dir, "sessions.yml"), []byte(tt.global), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tt.platform != "" {
+				writeConfig(t, dir, "linux", tt.platform)
+			}
+			loader := NewLoaderWithDir(dir)
+
+			sessions, err := loader.LoadDefaultSessions("linux")
+			if err != nil {
+				t.Fatalf("LoadDefaultSessions() returned error: %v", err)
+			}
+
+			var got []string
+			for _, sess := range sessions {
+				got = append(got, sess.Name+"="+sess.Directory)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("sessions = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("neither file", func(t *testing.T) {
+		loader := NewLoaderWithDir(t.TempDir())
+		if _, err := loader.LoadDefaultSessions("linux"); !errors.Is(err, fs.ErrNotExist) {
+			t.Errorf("LoadDefaultSessions() error = %v, want fs.ErrNotExist", err)
+		}
+	})
+}
+
+// TestGetSessionConfig tests looking up one default session by name
+func TestGetSessionConfig(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("HOME", "/home/test")
+	writeConfig(t, dir, "linux", `
+defaults:
+  - name: dotfiles
+    directory: ~/dotfiles
+  - name: api
+    directory: /code/api
+    tmuxinator_project: api-dev
+`)
+	loader := NewLoaderWithDir(dir)
+
+	tests := []struct {
+		name     string
+		session  string
+		platform string
+		wantDir  string
+		wantErr  bool
+	}{
+		{name: "first session", session: "dotfiles", platform: "linux", wantDir: "/home/test/dotfiles"},
+		{name: "later session", session: "api", platform: "linux", wantDir: "/code/api"},
+		{name: "unknown name", session: "notes", platform: "linux", wantErr: true},
+		{name: "platform without a file", session: "dotfiles", platform: "macos", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := loader.GetSessionConfig(tt.session, tt.platform)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetSessionConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if config.Name != tt.session || config.Directory != tt.wantDir {
+				t.Errorf("GetSessionConfig() = %s in %q, want %s in %q", config.Name, config.Directory, tt.session, tt.wantDir)
+			}
+		})
+	}
+}
+
+// TestAddSessionConfig tests appending a session to the platform config file
+func TestAddSessionConfig(t *testing.T) {
+	t.Run("creates the file when missing", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "sess")
+		loader := NewLoaderWithDir(dir)
+
+		path, err := loader.AddSessionConfig("linux", session.SessionConfig{Name: "api", Directory: "~/code/api"})
+		if err != nil {
+			t.Fatalf("AddSessionConfig() returned error: %v", err)
+		}
+		if path != filepath.Join(dir, "sessions-linux.yml") {
+			t.Errorf("wrote %s, want sessions-linux.yml in %s", path, dir)
+		}
+
+		sessions, err := loader.LoadDefaultSessions("linux")
+		if err != nil {
+			t.Fatalf("LoadDefaultSessions() returned error: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].Name != "api" {
+			t.Errorf("sessions = %+v, want [api]", sessions)
+		}
+	})
+
+	t.Run("keeps existing entries and raw directories", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, dir, "linux", `
+projects:
+  web:
+    directory: ~/code/web
+defaults:
+  - name: dotfiles
+    directory: ~/dotfiles
+  - name: web
+    project: web
+`)
+		loader := NewLoaderWithDir(dir)
+
+		if _, err := loader.AddSessionConfig("linux", session.SessionConfig{Name: "api", Directory: "~/code/api"}); err != nil {
+			t.Fatalf("AddSessionConfig() returned error: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "sessions-linux.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(data)
+		for _, want := range []string{"name: dotfiles", "directory: ~/dotfiles", "project: web", "name: api", "directory: ~/code/api"} {
+			if !strings.Contains(content, want) {
+				t.Errorf("config file is missing %q:\n%s", want, content)
+			}
+		}
+	})
+
+	t.Run("keeps comments and key order", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, dir, "linux", `# My sessions - edit by hand
+projects:
+  web:
+    directory: ~/code/web # the frontend
+defaults:
+  # Always open
+  - name: dotfiles
+    directory: ~/dotfiles # trailing comment
+`)
+		loader := NewLoaderWithDir(dir)
+
+		if _, err := loader.AddSessionConfig("linux", session.SessionConfig{Name: "api", Directory: "~/code/api"}); err != nil {
+			t.Fatalf("AddSessionConfig() returned error: %v", err)
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, "sessions-linux.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(data)
+
+		for _, want := range []string{
+			"# My sessions - edit by hand",
+			"# the frontend",
+			"# Always open",
+			"# trailing comment",
+		} {
+			if !strings.Contains(content, want) {
+				t.Errorf("comment %q was lost:\n%s", want, content)
+			}
+		}
+
+		// projects came first in the file and should stay first, and the new
+		// entry goes after the existing one
+		order := []string{"projects:", "defaults:", "name: dotfiles", "name: api"}
+		last := -1
+		for _, want := range order {
+			index := strings.Index(content, want)
+			if index <= last {
+				t.Errorf("%q is out of order:\n%s", want, content)
+			}
+			last = index
+		}
+
+		sessions, err := loader.LoadDefaultSessions("linux")
+		if err != nil {
+			t.Fatalf("LoadDefaultSessions() returned error: %v", err)
+		}
+		if len(sessions) != 2 {
+			t.Errorf("got %d sessions, want 2", len(sessions))
+		}
+	})
+
+	t.Run("keeps the file's permissions", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, dir, "linux", "defaults:\n")
+		path := filepath.Join(dir, "sessions-linux.yml")
+		if err := os.Chmod(path, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		loader := NewLoaderWithDir(dir)
+
+		if _, err := loader.AddSessionConfig("linux", session.SessionConfig{Name: "api", Directory: "/code"}); err != nil {
+			t.Fatalf("AddSessionConfig() returned error: %v", err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0o600 {
+			t.Errorf("mode = %v, want -rw-------", info.Mode().Perm())
+		}
+	})
+
+	t.Run("fills in an empty defaults key", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, dir, "linux", "# nothing yet\ndefaults:\n")
+		loader := NewLoaderWithDir(dir)
+
+		if _, err := loader.AddSessionConfig("linux", session.SessionConfig{Name: "api", Directory: "/code"}); err != nil {
+			t.Fatalf("AddSessionConfig() returned error: %v", err)
+		}
+
+		sessions, err := loader.LoadDefaultSessions("linux")
+		if err != nil {
+			t.Fatalf("LoadDefaultSessions() returned error: %v", err)
+		}
+		if len(sessions) != 1 || sessions[0].Name != "api" {
+			t.Errorf("sessions = %+v, want [api]", sessions)
+		}
+	})
+
+	t.Run("rejects duplicate names", func(t *testing.T) {
+		dir := t.TempDir()
+		writeConfig(t, dir, "linux", "defaults:\n  - name: api\n    directory: /tmp\n")
+		loader := NewLoaderWithDir(dir)
+
+		if _, err := loader.AddSessionConfig("linux", session.SessionConfig{Name: "api", Directory: "/code"}); err == nil {
+			t.Error("AddSessionConfig() expected error for a duplicate name")
+		}
+	})
+
+	t.Run("rejects names from the shared file", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "sessions.yml"), []byte("defaults:\n  - name: api\n    directory: /tmp\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		loader := NewLoaderWithDir(dir)
+
+		_, err := loader.AddSessionConfig("linux", session.SessionConfig{Name: "api", Directory: "/code"})
+		if err == nil || !strings.Contains(err.Error(), "sessions.yml") {
+			t.Errorf("AddSessionConfig() error = %v, want one naming sessions.yml", err)
+		}
+		if _, err := os.Stat(filepath.Join(dir, "sessions-linux.yml")); err == nil {
+			t.Error("the platform file was written for a duplicate")
+		}
+	})
+}
+
+// TestImportSessions tests writing imported sessions and their layouts
+func TestImportSessions(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	dir := t.TempDir()
+	writeConfig(t, dir, "linux", `# My sessions
+defaults:
+  - name: dotfiles
+    directory: ~/dotfiles
+`)
+	loader := NewLoaderWithDir(dir)
+
+	imports := []session.SessionConfig{
+		{Name: "dotfiles", Directory: "/elsewhere"},
+		{Name: "api", Directory: filepath.Join(home, "code/api"), Windows: []session.WindowConfig{
+			{Name: "server"},
+			{Name: "logs", Directory: filepath.Join(home, "logs")},
+		}},
+		{Name: "notes", Directory: "/notes"},
+	}
+
+	_, added, err := loader.ImportSessions("linux", imports)
+	if err != nil {
+		t.Fatalf("ImportSessions() returned error: %v", err)
+	}
+	if strings.Join(added, ",") != "api,notes" {
+		t.Errorf("added = %v, want [api notes]", added)
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "sessions-linux.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := string(data)
+	for _, want := range []string{"# My sessions", "directory: ~/code/api", "name: server", "name: logs", "directory: ~/logs"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("config file is missing %q:\n%s", want, content)
+		}
+	}
+	if strings.Contains(content, "/elsewhere") {
+		t.Errorf("existing session dotfiles was imported again:\n%s", content)
+	}
+
+	// The written file loads back with the windows
+	sessions, err := loader.LoadDefaultSessions("linux")
+	if err != nil {
+		t.Fatalf("LoadDefaultSessions() returned error: %v", err)
+	}
+	if len(sessions) != 3 || len(sessions[1].Windows) != 2 || sessions[1].Windows[1].Directory != filepath.Join(home, "logs") {
+		t.Errorf("sessions = %+v, want dotfiles, api with 2 windows, notes", sessions)
+	}
+
+	// A second import has nothing left to add
+	_, added, err = loader.ImportSessions("linux", imports)
+	if err != nil {
+		t.Fatalf("second ImportSessions() returned error: %v", err)
+	}
+	if len(added) != 0 {
+		t.Errorf("second import added %v, want nothing", added)
+	}
+}
+
+// TestImportSessionsShared tests that sessions defined in the shared
+// sessions.yml aren't imported into the platform file, where they'd
+// replace the shared ones
+func TestImportSessionsShared(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "sessions.yml"), []byte("defaults:\n  - name: dotfiles\n    directory: /dotfiles\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	loader := NewLoaderWithDir(dir)
+
+	imports := []session.SessionConfig{
+		{Name: "dotfiles", Directory: "/elsewhere"},
+		{Name: "api", Directory: "/code/api"},
+	}
+	_, added, err := loader.ImportSessions("linux", imports)
+	if err != nil {
+		t.Fatalf("ImportSessions() returned error: %v", err)
+	}
+	if strings.Join(added, ",") != "api" {
+		t.Errorf("added = %v, want [api]", added)
+	}
+
+	config, err := loader.GetSessionConfig("dotfiles", "linux")
+	if err != nil {
+		t.Fatalf("GetSessionConfig() returned error: %v", err)
+	}
+	if config.Directory != "/dotfiles" {
+		t.Errorf("dotfiles directory = %q, want the shared /dotfiles", config.Directory)
+	}
+}
+
+// TestContractHome tests writing directories under home with ~
+func TestContractHome(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/home/me", want: "~"},
+		{path: "/home/me/code", want: "~/code"},
+		{path: "/home/meow", want: "/home/meow"},
+		{path: "/srv", want: "/srv"},
+		{path: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := contractHome(tt.path, "/home/me"); got != tt.want {
+			t.Errorf("contractHome(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestEnsureSessionsFile tests creating a sessions file from the template
+func TestEnsureSessionsFile(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sess")
+	loader := NewLoaderWithDir(dir)
+
+	path, created, err := loader.EnsureSessionsFile("linux")
+	if err != nil {
+		t.Fatalf("EnsureSessionsFile() returned error: %v", err)
+	}
+	if !created || path != filepath.Join(dir, "sessions-linux.yml") {
+		t.Fatalf("EnsureSessionsFile() = %q, %v, want a new sessions-linux.yml", path, created)
+	}
+
+	// The template is all comments, so it loads as no sessions
+	sessions, err := loader.LoadDefaultSessions("linux")
+	if err != nil {
+		t.Fatalf("LoadDefaultSessions() on the template returned error: %v", err)
+	}
+	if len(sessions) != 0 {
+		t.Errorf("template has %d sessions, want 0", len(sessions))
+	}
+
+	// An existing file is left alone
+	writeConfig(t, dir, "linux", "defaults:\n  - name: api\n")
+	if _, created, err := loader.EnsureSessionsFile("linux"); err != nil || created {
+		t.Fatalf("EnsureSessionsFile() on an existing file = %v, %v, want false, nil", created, err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "name: api") {
+		t.Errorf("existing file was overwritten: %q", data)
+	}
+}
+
+// TestExpandDirectory tests ~ and environment variable expansion in directories
+func TestExpandDirectory(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("WORK", "/srv/work")
+	t.Setenv("SUB", "code")
+	t.Setenv("SESS_UNSET", "") // restored after the test
+	os.Unsetenv("SESS_UNSET")
+
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "$HOME/notes", want: "/home/me/notes"},
+		{path: "${WORK}/repos/api", want: "/srv/work/repos/api"},
+		{path: "$SESS_UNSET/api", want: "/api"},
+		{path: "~/$SUB/dir", want: "/home/me/code/dir"},
+		{path: "~", want: "/home/me"},
+		{path: "~nosuchuser-sess/code", want: "~nosuchuser-sess/code"},
+		{path: "/plain/path", want: "/plain/path"},
+	}
+
+	for _, tt := range tests {
+		if got := expandDirectory(tt.path, "/home/me"); got != tt.want {
+			t.Errorf("expandDirectory(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+
+	// ~user needs a user that exists on the machine running the tests
+	if current, err := user.Current(); err == nil {
+		path := "~" + current.Username + "/code"
+		if got, want := expandDirectory(path, "/home/me"), filepath.Join(current.HomeDir, "code"); got != want {
+			t.Errorf("expandDirectory(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestLoadAppConfigSort tests reading and checking the sort setting
+func TestLoadAppConfigSort(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr bool
+	}{
+		{name: "unset", content: "scratch_name: tmp\n", want: ""},
+		{name: "recent", content: "sort: recent\n", want: "recent"},
+		{name: "unknown", content: "sort: newest\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			loader := NewLoaderWithDir(dir)
+
+			cfg, err := loader.LoadAppConfig()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadAppConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && cfg.Sort != tt.want {
+				t.Errorf("Sort = %q, want %q", cfg.Sort, tt.want)
+			}
+		})
+	}
+}
+
+// TestLoadAppConfigTheme tests reading a theme that changes some session types
+func TestLoadAppConfigTheme(t *testing.T) {
+	dir := t.TempDir()
+	content := `
+theme:
+  tmux:
+    icon: "▶"
+    color: "#a6e3a1"
+  default:
+    icon: "-"
+`
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := NewLoaderWithDir(dir).LoadAppConfig()
+	if err != nil {
+		t.Fatalf("LoadAppConfig() returned error: %v", err)
+	}
+
+	tests := []struct {
+		sessionType session.SessionType
+		icon        string
+		color       string
+	}{
+		{sessionType: session.SessionTypeTmux, icon: "▶", color: "#a6e3a1"},
+		{sessionType: session.SessionTypeTmuxinator, icon: "⚙", color: "11"},
+		{sessionType: session.SessionTypeDefault, icon: "-", color: "12"},
+	}
+	for _, tt := range tests {
+		if icon := cfg.Theme.Icon(tt.sessionType); icon != tt.icon {
+			t.Errorf("Icon(%s) = %q, want %q", tt.sessionType, icon, tt.icon)
+		}
+		if color := cfg.Theme.Color(tt.sessionType); color != tt.color {
+			t.Errorf("Color(%s) = %q, want %q", tt.sessionType, color, tt.color)
+		}
+	}
+}
+
+// TestLoadAppConfigThemeUnknownType tests that a theme for a type that doesn't exist is an error
+func TestLoadAppConfigThemeUnknownType(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.yml"), []byte("theme:\n  active:\n    icon: x\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewLoaderWithDir(dir).LoadAppConfig(); err == nil || !strings.Contains(err.Error(), `"active"`) {
+		t.Errorf("LoadAppConfig() error = %v, want one naming the unknown type", err)
+	}
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// orderFile is the file the picker's custom session order is kept in
+// It maps to ~/.config/sess/order.yml:
+//
+//	order:
+//	  - api
+//	  - dotfiles
+type orderFile struct {
+	Order []string `yaml:"order"`
+}
+
+// orderPath returns the path of order.yml
+func (l *Loader) orderPath() string {
+	return filepath.Join(l.configDir, "order.yml")
+}
+
+// LoadOrder returns the custom session order, or nil if none has been saved
+func (l *Loader) LoadOrder() ([]string, error) {
+	path := l.orderPath()
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read order file %s: %w", path, err)
+	}
+
+	var file orderFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse order file %s: %w", path, err)
+	}
+
+	return file.Order, nil
+}
+
+// SaveOrder replaces the custom session order
+// The file is written whole: it's sess's own file, not one users edit by hand
+func (l *Loader) SaveOrder(names []string) error {
+	data, err := yaml.Marshal(orderFile{Order: names})
+	if err != nil {
+		return fmt.Errorf("failed to encode order: %w", err)
+	}
+
+	if err := os.MkdirAll(l.configDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := os.WriteFile(l.orderPath(), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write order file: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestOrderRoundTrip tests saving and loading the custom order
+func TestOrderRoundTrip(t *testing.T) {
+	loader := NewLoaderWithDir(filepath.Join(t.TempDir(), "sess"))
+
+	order, err := loader.LoadOrder()
+	if err != nil || order != nil {
+		t.Fatalf("LoadOrder() with no file = %v, %v, want nil, nil", order, err)
+	}
+
+	if err := loader.SaveOrder([]string{"web", "api", "dotfiles"}); err != nil {
+		t.Fatalf("SaveOrder() returned error: %v", err)
+	}
+
+	order, err = loader.LoadOrder()
+	if err != nil {
+		t.Fatalf("LoadOrder() returned error: %v", err)
+	}
+	if got := strings.Join(order, ","); got != "web,api,dotfiles" {
+		t.Errorf("LoadOrder() = %s, want web,api,dotfiles", got)
+	}
+}
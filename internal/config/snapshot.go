@@ -0,0 +1,75 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datapointchris/sess/internal/session"
+	"gopkg.in/yaml.v3"
+)
+
+// SnapshotPath returns where the snapshot of a session is kept:
+// ~/.config/sess/snapshots/<name>.yml
+func (l *Loader) SnapshotPath(name string) string {
+	return filepath.Join(l.configDir, "snapshots", name+".yml")
+}
+
+// SaveSnapshot writes a session snapshot, replacing any earlier one of the
+// same name, and returns the path it was written to
+func (l *Loader) SaveSnapshot(snapshot *session.Snapshot) (string, error) {
+	if err := checkSnapshotName(snapshot.Name); err != nil {
+		return "", err
+	}
+
+	data, err := yaml.Marshal(snapshot)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	path := l.SnapshotPath(snapshot.Name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write snapshot: %w", err)
+	}
+
+	return path, nil
+}
+
+// LoadSnapshot reads the snapshot saved for a session
+func (l *Loader) LoadSnapshot(name string) (*session.Snapshot, error) {
+	if err := checkSnapshotName(name); err != nil {
+		return nil, err
+	}
+
+	path := l.SnapshotPath(name)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no snapshot of '%s' (save one with: sess save %s)", name, name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot %s: %w", path, err)
+	}
+
+	var snapshot session.Snapshot
+	if err := yaml.Unmarshal(data, &snapshot); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	// The file name is what it's looked up by, so it wins over a hand-edited name
+	snapshot.Name = name
+	return &snapshot, nil
+}
+
+// checkSnapshotName rejects names that would put the file outside the snapshot directory
+func checkSnapshotName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// TestSnapshotRoundTrip tests saving and loading a session snapshot
+func TestSnapshotRoundTrip(t *testing.T) {
+	loader := NewLoaderWithDir(filepath.Join(t.TempDir(), "sess"))
+
+	if _, err := loader.LoadSnapshot("api"); err == nil {
+		t.Error("LoadSnapshot() with no snapshot returned no error")
+	}
+
+	snapshot := &session.Snapshot{
+		Name: "api",
+		Windows: []session.SnapshotWindow{
+			{Name: "editor", Layout: "a1", Panes: []session.SnapshotPane{{Directory: "/code/api", Command: "nvim"}, {Directory: "/code/api"}}},
+		},
+	}
+	path, err := loader.SaveSnapshot(snapshot)
+	if err != nil {
+		t.Fatalf("SaveSnapshot() returned error: %v", err)
+	}
+	if path != loader.SnapshotPath("api") {
+		t.Errorf("SaveSnapshot() path = %s, want %s", path, loader.SnapshotPath("api"))
+	}
+
+	loaded, err := loader.LoadSnapshot("api")
+	if err != nil {
+		t.Fatalf("LoadSnapshot() returned error: %v", err)
+	}
+	if !reflect.DeepEqual(loaded, snapshot) {
+		t.Errorf("LoadSnapshot() = %+v, want %+v", loaded, snapshot)
+	}
+
+	if _, err := loader.SaveSnapshot(&session.Snapshot{Name: "../escape"}); err == nil {
+		t.Error("SaveSnapshot() with a path in the name returned no error")
+	}
+}
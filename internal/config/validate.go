@@ -0,0 +1,249 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"strings"
+
+	"github.com/datapointchris/sess/internal/session"
+	"gopkg.in/yaml.v3"
+)
+
+// Issue is a problem found in a sessions file
+type Issue struct {
+	// Line and Column locate the offending entry, 0 when it isn't tied to one
+	Line   int
+	Column int
+
+	Message string
+
+	// Warning marks problems that don't stop the file from loading
+	Warning bool
+}
+
+// String returns the message prefixed with where in the file it applies
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return i.Message
+	}
+	return fmt.Sprintf("line %d, column %d: %s", i.Line, i.Column, i.Message)
+}
+
+// Validation is the result of checking a sessions file
+type Validation struct {
+	Path     string
+	Sessions int
+	Issues   []Issue
+}
+
+// Errors counts the issues that stop the file from loading
+func (v *Validation) Errors() int {
+	count := 0
+	for _, issue := range v.Issues {
+		if !issue.Warning {
+			count++
+		}
+	}
+	return count
+}
+
+// sessionsFile is the layout of a sessions-<platform>.yml file: the
+// default sessions, plus an optional map of reusable layouts they can reference
+type sessionsFile struct {
+	Defaults []session.SessionConfig    `yaml:"defaults"`
+	Projects map[string]session.Project `yaml:"projects"`
+}
+
+// parseSessions decodes a sessions file along with the YAML node of each
+// default session, so problems can be reported with their line and column
+func parseSessions(data []byte) (sessionsFile, []*yaml.Node, error) {
+	var file sessionsFile
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return file, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+	// An empty file has no document at all
+	if len(root.Content) == 0 {
+		return file, nil, nil
+	}
+	if err := root.Decode(&file); err != nil {
+		return file, nil, fmt.Errorf("failed to parse YAML: %w", err)
+	}
+
+	return file, defaultNodes(root.Content[0]), nil
+}
+
+// defaultNodes returns the entries of the defaults sequence in a document's top-level mapping
+func defaultNodes(top *yaml.Node) []*yaml.Node {
+	if top.Kind != yaml.MappingNode {
+		return nil
+	}
+	if defaults := mappingValue(top, "defaults"); defaults != nil && defaults.Kind == yaml.SequenceNode {
+		return defaults.Content
+	}
+	return nil
+}
+
+// checkDefaults finds default sessions that can't be told apart: ones
+// without a name, and names used more than once
+// nodes are the sessions' YAML entries, in the same order
+func checkDefaults(defaults []session.SessionConfig, nodes []*yaml.Node) []Issue {
+	var issues []Issue
+	firstLine := make(map[string]int, len(defaults))
+
+	for i, config := range defaults {
+		issue := issueAt(nodes, i)
+		if config.Name == "" {
+			issue.Message = "session has no name"
+			issues = append(issues, issue)
+			continue
+		}
+
+		line, seen := firstLine[config.Name]
+		if !seen {
+			firstLine[config.Name] = issue.Line
+			continue
+		}
+		issue.Message = fmt.Sprintf("duplicate session name %q", config.Name)
+		if line != 0 {
+			issue.Message += fmt.Sprintf(" (first defined on line %d)", line)
+		}
+		issues = append(issues, issue)
+	}
+
+	return issues
+}
+
+// issueAt returns an Issue positioned at the i-th node, if there is one
+func issueAt(nodes []*yaml.Node, i int) Issue {
+	if i >= len(nodes) {
+		return Issue{}
+	}
+	return Issue{Line: nodes[i].Line, Column: nodes[i].Column}
+}
+
+// entryError prefixes err with the position of the i-th default session
+func entryError(nodes []*yaml.Node, i int, err error) error {
+	issue := issueAt(nodes, i)
+	if issue.Line == 0 {
+		return err
+	}
+	return fmt.Errorf("line %d, column %d: %w", issue.Line, issue.Column, err)
+}
+
+// ValidateSessions checks the platform's sessions file, and the shared
+// sessions.yml if there is one, without loading them
+// Everything that makes LoadDefaultSessions fail is an error; a directory
+// that doesn't exist is only a warning, since sess can create it when the
+// session is opened, and so is one using an unset environment variable
+func (l *Loader) ValidateSessions(platform string) ([]*Validation, error) {
+	var validations []*Validation
+	var sources []*sessionsSource
+
+	for _, configPath := range l.SourcePaths(platform) {
+		data, err := os.ReadFile(configPath)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", configPath, err)
+		}
+
+		validation := &Validation{Path: configPath}
+		validations = append(validations, validation)
+
+		file, nodes, err := parseSessions(data)
+		if err != nil {
+			// The YAML error already says which line is broken
+			validation.Issues = append(validation.Issues, Issue{Message: err.Error()})
+			continue
+		}
+		validation.Sessions = len(file.Defaults)
+		validation.Issues = checkDefaults(file.Defaults, nodes)
+		validation.Issues = append(validation.Issues, nameWarnings(file.Defaults, nodes)...)
+		validation.Issues = append(validation.Issues, directoryWarnings(file.Defaults, nodes)...)
+
+		sources = append(sources, &sessionsSource{path: configPath, file: file, nodes: nodes})
+	}
+
+	if len(validations) == 0 {
+		configPath := l.SessionsPath(platform)
+		return nil, fmt.Errorf("failed to read config file %s: %w", configPath, fs.ErrNotExist)
+	}
+
+	// The remaining checks stop at the first problem, so only run them
+	// once there's nothing else to report
+	for _, validation := range validations {
+		if validation.Errors() > 0 {
+			return validations, nil
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	projects := make(map[string]session.Project)
+	for i, source := range sources {
+		sourceProjects, err := source.projects(home)
+		if err != nil {
+			validations[i].Issues = append(validations[i].Issues, Issue{Message: err.Error()})
+			return validations, nil
+		}
+		maps.Copy(projects, sourceProjects)
+	}
+	for i, source := range sources {
+		if _, err := source.defaults(projects, home); err != nil {
+			validations[i].Issues = append(validations[i].Issues, Issue{Message: err.Error()})
+			return validations, nil
+		}
+	}
+
+	return validations, nil
+}
+
+// nameWarnings warns about default sessions whose name tmux won't take as
+// is, since the session then runs under another name
+func nameWarnings(defaults []session.SessionConfig, nodes []*yaml.Node) []Issue {
+	var issues []Issue
+	for i, config := range defaults {
+		if !strings.ContainsAny(config.Name, ".:") {
+			continue
+		}
+		sanitized, _ := session.SanitizeName(config.Name)
+		issue := issueAt(nodes, i)
+		issue.Warning = true
+		issue.Message = fmt.Sprintf("session %q: tmux doesn't allow '.' or ':' in names, so it runs as %q", config.Name, sanitized)
+		issues = append(issues, issue)
+	}
+	return issues
+}
+
+// directoryWarnings warns about default sessions whose directory uses an
+// unset variable or doesn't exist
+func directoryWarnings(defaults []session.SessionConfig, nodes []*yaml.Node) []Issue {
+	var issues []Issue
+	home, _ := os.UserHomeDir()
+	for i, config := range defaults {
+		if config.Directory == "" {
+			continue
+		}
+		issue := issueAt(nodes, i)
+		issue.Warning = true
+
+		// The expanded path would be wrong anyway, so there's no point checking it
+		if unset := unsetVariables(config.Directory); len(unset) > 0 {
+			issue.Message = fmt.Sprintf("session %q: directory %s uses $%s, which isn't set", config.Name, config.Directory, strings.Join(unset, ", $"))
+			issues = append(issues, issue)
+			continue
+		}
+
+		directory := expandDirectory(config.Directory, home)
+		if _, err := os.Stat(directory); errors.Is(err, fs.ErrNotExist) {
+			issue.Message = fmt.Sprintf("session %q: directory %s doesn't exist", config.Name, directory)
+			issues = append(issues, issue)
+		}
+	}
+	return issues
+}
@@ -0,0 +1,144 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestValidateSessions tests the checks behind "sess config validate"
+func TestValidateSessions(t *testing.T) {
+	existing := t.TempDir()
+
+	tests := []struct {
+		name         string
+		content      string
+		wantSessions int
+		wantIssues   []string
+		wantErrors   int
+	}{
+		{
+			name: "well-formed",
+			content: `
+defaults:
+  - name: dotfiles
+    directory: ` + existing + `
+  - name: notes
+`,
+			wantSessions: 2,
+		},
+		{
+			name: "duplicate name",
+			content: `
+defaults:
+  - name: api
+    directory: ` + existing + `
+  - name: web
+  - name: api
+`,
+			wantSessions: 3,
+			wantIssues:   []string{`line 6, column 5: duplicate session name "api" (first defined on line 3)`},
+			wantErrors:   1,
+		},
+		{
+			name: "empty name",
+			content: `
+defaults:
+  - name: api
+  - directory: ` + existing + `
+`,
+			wantSessions: 2,
+			wantIssues:   []string{"line 4, column 5: session has no name"},
+			wantErrors:   1,
+		},
+		{
+			name: "missing directory is only a warning",
+			content: `
+defaults:
+  - name: api
+    directory: /nonexistent/api
+`,
+			wantSessions: 1,
+			wantIssues:   []string{`line 3, column 5: session "api": directory /nonexistent/api doesn't exist`},
+		},
+		{
+			name: "unset variable is only a warning",
+			content: `
+defaults:
+  - name: api
+    directory: $SESS_TEST_UNSET/api
+`,
+			wantSessions: 1,
+			wantIssues:   []string{`line 3, column 5: session "api": directory $SESS_TEST_UNSET/api uses $SESS_TEST_UNSET, which isn't set`},
+		},
+		{
+			name: "dot in the name is only a warning",
+			content: `
+defaults:
+  - name: web.app
+`,
+			wantSessions: 1,
+			wantIssues:   []string{`line 3, column 5: session "web.app": tmux doesn't allow '.' or ':' in names, so it runs as "web_app"`},
+		},
+		{
+			name: "broken YAML",
+			content: `
+defaults:
+  - name: api
+   directory: /tmp
+`,
+			wantIssues: []string{"yaml: line"},
+			wantErrors: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfig(t, dir, "linux", tt.content)
+			loader := NewLoaderWithDir(dir)
+
+			validations, err := loader.ValidateSessions("linux")
+			if err != nil {
+				t.Fatalf("ValidateSessions() returned error: %v", err)
+			}
+			if len(validations) != 1 {
+				t.Fatalf("got %d validations, want 1 for the platform file", len(validations))
+			}
+			validation := validations[0]
+
+			if validation.Sessions != tt.wantSessions {
+				t.Errorf("Sessions = %d, want %d", validation.Sessions, tt.wantSessions)
+			}
+			if validation.Errors() != tt.wantErrors {
+				t.Errorf("Errors() = %d, want %d (issues: %v)", validation.Errors(), tt.wantErrors, validation.Issues)
+			}
+			if len(validation.Issues) != len(tt.wantIssues) {
+				t.Fatalf("issues = %v, want %d", validation.Issues, len(tt.wantIssues))
+			}
+			for i, want := range tt.wantIssues {
+				if got := validation.Issues[i].String(); !strings.Contains(got, want) {
+					t.Errorf("issue %d = %q, want it to contain %q", i, got, want)
+				}
+			}
+		})
+	}
+}
+
+// TestLoadDefaultSessionsDuplicate tests that loading fails on a repeated name, saying where
+func TestLoadDefaultSessionsDuplicate(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "linux", `
+defaults:
+  - name: api
+  - name: api
+`)
+	loader := NewLoaderWithDir(dir)
+
+	_, err := loader.LoadDefaultSessions("linux")
+	if err == nil {
+		t.Fatal("LoadDefaultSessions() expected error but got none")
+	}
+	if !strings.Contains(err.Error(), "line 4, column 5") {
+		t.Errorf("error %q doesn't say where the duplicate is", err)
+	}
+}
@@ -0,0 +1,253 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/datapointchris/sess/internal/runner"
+)
+
+// DiagnosticResult is the outcome of one check
+type DiagnosticResult struct {
+	// Name says what was checked, e.g. "Config directory"
+	Name string
+
+	// OK is whether the check passed
+	OK bool
+
+	// Detail is what was found, or for a failure, how to fix it
+	Detail string
+
+	// Fix remedies a failure, for problems sess can fix itself (nil otherwise)
+	// It's only set on failed results
+	Fix func() error
+
+	// Optional marks a check sess works without, like gum being installed
+	// Its failure is reported but doesn't count towards Failed
+	Optional bool
+}
+
+// Check runs one diagnostic
+// Checks are small functions so each one can be tested on its own
+type Check func() DiagnosticResult
+
+// Run runs the checks in order and returns their results
+func Run(checks []Check) []DiagnosticResult {
+	results := make([]DiagnosticResult, len(checks))
+	for i, check := range checks {
+		results[i] = check()
+	}
+	return results
+}
+
+// FixOutcome is what happened when a result's Fix ran
+type FixOutcome struct {
+	Name string
+	Err  error
+}
+
+// ApplyFixes runs the Fix of every failed result that has one, in order,
+// and returns what happened for each fix that ran
+func ApplyFixes(results []DiagnosticResult) []FixOutcome {
+	var outcomes []FixOutcome
+	for _, result := range results {
+		if result.OK || result.Fix == nil {
+			continue
+		}
+		outcomes = append(outcomes, FixOutcome{Name: result.Name, Err: result.Fix()})
+	}
+	return outcomes
+}
+
+// Failed reports whether any result that isn't optional failed
+func Failed(results []DiagnosticResult) bool {
+	for _, result := range results {
+		if !result.OK && !result.Optional {
+			return true
+		}
+	}
+	return false
+}
+
+// starterSessions is written as a new sessions file, with an example to copy
+const starterSessions = `# Default sessions for sess, shown in the picker even when they aren't running
+# defaults:
+#   - name: dotfiles
+#     directory: ~/dotfiles
+#     description: Personal dotfiles
+defaults: []
+`
+
+// DirCheck checks that a directory exists, and can create it if it doesn't
+func DirCheck(name, dir string) Check {
+	return func() DiagnosticResult {
+		result := DiagnosticResult{Name: name}
+
+		info, err := os.Stat(dir)
+		switch {
+		case err == nil && info.IsDir():
+			result.OK = true
+			result.Detail = dir
+		case err == nil:
+			result.Detail = fmt.Sprintf("%s is a file, not a directory", dir)
+		case errors.Is(err, fs.ErrNotExist):
+			result.Detail = fmt.Sprintf("%s doesn't exist (create it with mkdir -p)", dir)
+			result.Fix = func() error {
+				return os.MkdirAll(dir, 0o755)
+			}
+		default:
+			result.Detail = err.Error()
+		}
+
+		return result
+	}
+}
+
+// SessionsFileCheck checks that there's a sessions file, the platform's own
+// (path) or the shared one (sharedPath), and can create a starter platform
+// file (and its directory) if neither exists
+func SessionsFileCheck(path, sharedPath string) Check {
+	return func() DiagnosticResult {
+		result := DiagnosticResult{Name: "Sessions file"}
+
+		_, err := os.Stat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			if _, sharedErr := os.Stat(sharedPath); sharedErr == nil {
+				result.OK = true
+				result.Detail = sharedPath
+				return result
+			}
+		}
+		switch {
+		case err == nil:
+			result.OK = true
+			result.Detail = path
+		case errors.Is(err, fs.ErrNotExist):
+			result.Detail = fmt.Sprintf("%s doesn't exist (add sessions with 'sess config add')", path)
+			result.Fix = func() error {
+				if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+					return err
+				}
+				// O_EXCL so a file that appeared since the check is never overwritten
+				file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+				if err != nil {
+					return err
+				}
+				if _, err := file.WriteString(starterSessions); err != nil {
+					file.Close()
+					return err
+				}
+				return file.Close()
+			}
+		default:
+			result.Detail = err.Error()
+		}
+
+		return result
+	}
+}
+
+// TmuxCheck checks that the tmux binary can be found and runs, and reports its version
+func TmuxCheck(r runner.Runner, binary string) Check {
+	return func() DiagnosticResult {
+		result := DiagnosticResult{Name: "tmux"}
+
+		path, err := r.LookPath(binary)
+		if err != nil {
+			result.Detail = fmt.Sprintf("%s isn't on PATH (install tmux, e.g. brew install tmux or apt install tmux, or point SESS_TMUX_BIN at it)", binary)
+			return result
+		}
+
+		output, err := r.Output(binary, "-V")
+		if err != nil {
+			result.Detail = fmt.Sprintf("%s doesn't run: %v", path, err)
+			return result
+		}
+
+		result.OK = true
+		result.Detail = fmt.Sprintf("%s (%s)", strings.TrimSpace(string(output)), path)
+		return result
+	}
+}
+
+// InsideTmuxCheck reports whether sess is running inside tmux, given $TMUX
+// Either way is fine, it only changes whether sess switches or attaches
+func InsideTmuxCheck(tmuxEnv string) Check {
+	return func() DiagnosticResult {
+		result := DiagnosticResult{Name: "Inside tmux", OK: true}
+		if tmuxEnv != "" {
+			result.Detail = "yes, sessions are switched to"
+		} else {
+			result.Detail = "no, sessions are attached to"
+		}
+		return result
+	}
+}
+
+// ToolCheck checks that an optional program is installed under any of names
+// hint says what it's for and how to install it, for when it isn't
+func ToolCheck(tool string, lookPath func(string) (string, error), hint string, names ...string) Check {
+	return func() DiagnosticResult {
+		result := DiagnosticResult{Name: tool, Optional: true}
+
+		for _, name := range names {
+			if path, err := lookPath(name); err == nil {
+				result.OK = true
+				result.Detail = path
+				return result
+			}
+		}
+
+		result.Detail = fmt.Sprintf("%s isn't on PATH (%s)", strings.Join(names, " or "), hint)
+		return result
+	}
+}
+
+// SessionsValidCheck checks that the sessions files load, by running
+// validate and counting what it found
+// A missing file passes: SessionsFileCheck reports that
+func SessionsValidCheck(validate func() (errs, warnings int, err error)) Check {
+	return func() DiagnosticResult {
+		result := DiagnosticResult{Name: "Sessions config"}
+
+		errs, warnings, err := validate()
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			result.OK = true
+			result.Detail = "no sessions file to check"
+		case err != nil:
+			result.Detail = err.Error()
+		case errs > 0:
+			result.Detail = fmt.Sprintf("%d error(s), %d warning(s) (see 'sess config validate')", errs, warnings)
+		case warnings > 0:
+			result.OK = true
+			result.Detail = fmt.Sprintf("valid, with %d warning(s) (see 'sess config validate')", warnings)
+		default:
+			result.OK = true
+			result.Detail = "valid"
+		}
+
+		return result
+	}
+}
+
+// TmuxConfigCheck checks that "sess reload" has a tmux config to source,
+// by running resolve to find it
+// tmux runs fine without one, so this is optional
+func TmuxConfigCheck(resolve func() (string, error)) Check {
+	return func() DiagnosticResult {
+		result := DiagnosticResult{Name: "Tmux config", Optional: true}
+		path, err := resolve()
+		if err != nil {
+			result.Detail = fmt.Sprintf("%v ('sess reload' needs one; set SESS_TMUX_CONF to use another file)", err)
+			return result
+		}
+		result.OK = true
+		result.Detail = path
+		return result
+	}
+}
@@ -0,0 +1,234 @@
+package doctor
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestDirCheckFix tests that a missing directory fails with a fix that creates it
+func TestDirCheckFix(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "config", "sess")
+	check := DirCheck("Config directory", dir)
+
+	result := check()
+	if result.OK || result.Fix == nil {
+		t.Fatalf("check() = %+v, want a failure with a fix", result)
+	}
+
+	outcomes := ApplyFixes([]DiagnosticResult{result})
+	if len(outcomes) != 1 || outcomes[0].Err != nil {
+		t.Fatalf("ApplyFixes() = %+v, want one successful fix", outcomes)
+	}
+
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("%s wasn't created: %v", dir, err)
+	}
+	if result := check(); !result.OK || result.Fix != nil {
+		t.Errorf("check() after the fix = %+v, want OK without a fix", result)
+	}
+}
+
+// TestDirCheckFile tests that a file where the directory should be can't be fixed automatically
+func TestDirCheckFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sess")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := DirCheck("Config directory", path)()
+	if result.OK || result.Fix != nil {
+		t.Errorf("check() = %+v, want a failure without a fix", result)
+	}
+}
+
+// TestSessionsFileCheckFix tests creating a starter sessions file
+func TestSessionsFileCheckFix(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "sess")
+	path := filepath.Join(dir, "sessions-linux.yml")
+	check := SessionsFileCheck(path, filepath.Join(dir, "sessions.yml"))
+
+	result := check()
+	if result.OK || result.Fix == nil {
+		t.Fatalf("check() = %+v, want a failure with a fix", result)
+	}
+	if err := result.Fix(); err != nil {
+		t.Fatalf("Fix() returned error: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "defaults: []") {
+		t.Errorf("starter file = %q, %v", data, err)
+	}
+
+	// Running the fix again must not clobber the file
+	if err := result.Fix(); err == nil {
+		t.Error("Fix() on an existing file expected error but got none")
+	}
+}
+
+// TestSessionsFileCheckShared tests that the shared sessions.yml is enough
+// without a file for the platform
+func TestSessionsFileCheckShared(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "sessions.yml")
+	if err := os.WriteFile(shared, []byte("defaults: []\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	result := SessionsFileCheck(filepath.Join(dir, "sessions-linux.yml"), shared)()
+	if !result.OK || result.Detail != shared {
+		t.Errorf("check() = %+v, want OK with the shared file", result)
+	}
+}
+
+// TestApplyFixes tests that only failed results with a fix are fixed
+func TestApplyFixes(t *testing.T) {
+	var ran []string
+	fix := func(name string, err error) func() error {
+		return func() error {
+			ran = append(ran, name)
+			return err
+		}
+	}
+
+	results := []DiagnosticResult{
+		{Name: "passing", OK: true},
+		{Name: "manual", OK: false},
+		{Name: "fixable", OK: false, Fix: fix("fixable", nil)},
+		{Name: "broken fix", OK: false, Fix: fix("broken fix", errors.New("permission denied"))},
+	}
+
+	outcomes := ApplyFixes(results)
+
+	if got := strings.Join(ran, ","); got != "fixable,broken fix" {
+		t.Errorf("ran fixes %s, want fixable,broken fix", got)
+	}
+	if len(outcomes) != 2 || outcomes[0].Err != nil || outcomes[1].Err == nil {
+		t.Errorf("ApplyFixes() = %+v", outcomes)
+	}
+	if !Failed(results) {
+		t.Error("Failed() = false, want true")
+	}
+}
+
+// fakeRunner answers LookPath from installed and Output from output
+type fakeRunner struct {
+	installed map[string]bool
+	output    map[string]string
+	errs      map[string]error
+}
+
+func (f *fakeRunner) Run(name string, args ...string) error {
+	return nil
+}
+
+func (f *fakeRunner) Output(name string, args ...string) ([]byte, error) {
+	key := strings.Join(append([]string{name}, args...), " ")
+	return []byte(f.output[key]), f.errs[key]
+}
+
+func (f *fakeRunner) Interactive(name string, args ...string) error {
+	return nil
+}
+
+func (f *fakeRunner) InteractiveEnv(env []string, name string, args ...string) error {
+	return nil
+}
+
+func (f *fakeRunner) LookPath(name string) (string, error) {
+	if f.installed[name] {
+		return "/usr/bin/" + name, nil
+	}
+	return "", errors.New("not found")
+}
+
+// TestTmuxCheck tests finding tmux and reporting its version
+func TestTmuxCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		runner     *fakeRunner
+		wantOK     bool
+		wantDetail string
+	}{
+		{
+			name:       "installed",
+			runner:     &fakeRunner{installed: map[string]bool{"tmux": true}, output: map[string]string{"tmux -V": "tmux 3.4\n"}},
+			wantOK:     true,
+			wantDetail: "tmux 3.4 (/usr/bin/tmux)",
+		},
+		{
+			name:       "missing",
+			runner:     &fakeRunner{},
+			wantDetail: "isn't on PATH",
+		},
+		{
+			name:       "broken",
+			runner:     &fakeRunner{installed: map[string]bool{"tmux": true}, errs: map[string]error{"tmux -V": errors.New("exit status 127")}},
+			wantDetail: "doesn't run",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := TmuxCheck(tt.runner, "tmux")()
+			if result.OK != tt.wantOK || result.Optional {
+				t.Errorf("OK = %v, Optional = %v, want OK = %v and required", result.OK, result.Optional, tt.wantOK)
+			}
+			if !strings.Contains(result.Detail, tt.wantDetail) {
+				t.Errorf("Detail = %q, want it to contain %q", result.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
+
+// TestToolCheck tests that an optional tool is found under any of its names,
+// and that its absence doesn't fail the doctor
+func TestToolCheck(t *testing.T) {
+	r := &fakeRunner{installed: map[string]bool{"mux": true}}
+
+	result := ToolCheck("tmuxinator", r.LookPath, "gem install tmuxinator", "tmuxinator", "mux")()
+	if !result.OK || result.Detail != "/usr/bin/mux" {
+		t.Errorf("ToolCheck(tmuxinator) = %+v, want mux found", result)
+	}
+
+	result = ToolCheck("gum", r.LookPath, "brew install gum", "gum")()
+	if result.OK || !strings.Contains(result.Detail, "brew install gum") {
+		t.Errorf("ToolCheck(gum) = %+v, want a failure with the hint", result)
+	}
+	if Failed([]DiagnosticResult{result}) {
+		t.Error("Failed() = true for an optional check, want false")
+	}
+}
+
+// TestSessionsValidCheck tests how validation results are reported
+func TestSessionsValidCheck(t *testing.T) {
+	tests := []struct {
+		name     string
+		errs     int
+		warnings int
+		err      error
+		wantOK   bool
+	}{
+		{name: "valid", wantOK: true},
+		{name: "warnings only", warnings: 2, wantOK: true},
+		{name: "errors", errs: 1, warnings: 1},
+		{name: "missing file", err: fmt.Errorf("failed to read config file: %w", fs.ErrNotExist), wantOK: true},
+		{name: "unreadable", err: errors.New("permission denied")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := SessionsValidCheck(func() (int, int, error) {
+				return tt.errs, tt.warnings, tt.err
+			})()
+			if result.OK != tt.wantOK {
+				t.Errorf("OK = %v, want %v (detail: %s)", result.OK, tt.wantOK, result.Detail)
+			}
+		})
+	}
+}
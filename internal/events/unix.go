@@ -0,0 +1,46 @@
+package events
+
+import (
+	"encoding/json"
+	"net"
+	"time"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// dialTimeout bounds how long an event can hold up a session operation
+const dialTimeout = 200 * time.Millisecond
+
+// UnixSocketSink sends each event as one line of JSON to a Unix domain socket
+// Something else (a desktop widget, a status bar script) listens on the socket;
+// sess only connects, writes, and hangs up
+type UnixSocketSink struct {
+	path string
+}
+
+// NewUnixSocketSink creates a sink that writes to the socket at path
+func NewUnixSocketSink(path string) *UnixSocketSink {
+	return &UnixSocketSink{path: path}
+}
+
+// Emit writes the event to the socket
+// Every failure is ignored: nobody listening is the normal case, and a broken
+// listener must not stop the user from switching sessions
+func (s *UnixSocketSink) Emit(event session.Event) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	conn, err := net.DialTimeout("unix", s.path, dialTimeout)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(dialTimeout))
+	_, _ = conn.Write(append(payload, '\n'))
+}
+
+// Verify interface implementation at compile time
+var _ session.EventSink = (*UnixSocketSink)(nil)
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// TestUnixSocketSink tests that a listener receives the event as a JSON line
+func TestUnixSocketSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.sock")
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		line, _ := bufio.NewReader(conn).ReadString('\n')
+		received <- line
+	}()
+
+	when := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	NewUnixSocketSink(path).Emit(session.Event{Type: session.EventCreated, Session: "api", Time: when})
+
+	select {
+	case line := <-received:
+		var got map[string]string
+		if err := json.Unmarshal([]byte(line), &got); err != nil {
+			t.Fatalf("payload %q is not JSON: %v", line, err)
+		}
+		want := map[string]string{"type": "created", "session": "api", "time": "2025-01-02T03:04:05Z"}
+		for key, value := range want {
+			if got[key] != value {
+				t.Errorf("%s = %q, want %q", key, got[key], value)
+			}
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("listener received nothing")
+	}
+}
+
+// TestUnixSocketSinkNoListener tests that a missing socket is silently ignored
+func TestUnixSocketSinkNoListener(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing.sock")
+
+	// Nothing to assert beyond "doesn't panic or block"
+	NewUnixSocketSink(path).Emit(session.Event{Type: session.EventDeleted, Session: "api", Time: time.Now()})
+}
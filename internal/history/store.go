@@ -0,0 +1,142 @@
+package history
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// DefaultCapacity is how many entries the history keeps before dropping the oldest
+const DefaultCapacity = 100
+
+// Store keeps the sessions the user opened, oldest first, in a JSON file
+// It behaves like a ring buffer: once it's full, recording a new entry drops
+// the oldest one, so the file never grows without bound
+type Store struct {
+	path     string
+	capacity int
+
+	// mu makes each read-modify-write of the file atomic within the process,
+	// so concurrent Records don't drop each other's entries
+	mu sync.Mutex
+
+	// now returns the current time (replaced in tests)
+	now func() time.Time
+}
+
+// NewStore creates a history store backed by the file at path
+// The file is created on the first Record
+func NewStore(path string) *Store {
+	return &Store{path: path, capacity: DefaultCapacity, now: time.Now}
+}
+
+// Record appends an entry for name, dropping the oldest entries if the history is full
+func (s *Store) Record(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return err
+	}
+
+	entries = append(entries, session.HistoryEntry{Name: name, Time: s.now()})
+	if len(entries) > s.capacity {
+		entries = entries[len(entries)-s.capacity:]
+	}
+
+	return s.write(entries)
+}
+
+// Entries returns the history, oldest first
+// A missing file is an empty history, and so is a corrupt one: the history
+// is a convenience, and a bad file shouldn't stop sess from working
+func (s *Store) Entries() ([]session.HistoryEntry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.entries()
+}
+
+// entries reads the history file; the caller holds mu
+func (s *Store) entries() ([]session.HistoryEntry, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []session.HistoryEntry{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history %s: %w", s.path, err)
+	}
+
+	var entries []session.HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return []session.HistoryEntry{}, nil
+	}
+
+	return entries, nil
+}
+
+// Prune removes every entry whose name keep rejects and returns how many were removed
+// Surviving entries stay in their original order
+func (s *Store) Prune(keep func(name string) bool) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := s.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	// Filter in place - kept shares the backing array with entries
+	kept := entries[:0]
+	for _, entry := range entries {
+		if keep(entry.Name) {
+			kept = append(kept, entry)
+		}
+	}
+
+	removed := len(entries) - len(kept)
+	if removed == 0 {
+		return 0, nil
+	}
+
+	return removed, s.write(kept)
+}
+
+// Clear removes the whole history
+func (s *Store) Clear() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to clear history: %w", err)
+	}
+	return nil
+}
+
+// write replaces the history file with entries
+func (s *Store) write(entries []session.HistoryEntry) error {
+	data, err := json.Marshal(entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode history: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write history %s: %w", s.path, err)
+	}
+
+	return nil
+}
+
+// Verify interface implementation at compile time
+var _ session.HistoryStore = (*Store)(nil)
@@ -0,0 +1,149 @@
+package history
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestStore creates a store in a temp dir with a clock that ticks a minute per entry
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	store := NewStore(filepath.Join(t.TempDir(), "history.json"))
+	clock := time.Date(2025, 1, 1, 9, 0, 0, 0, time.UTC)
+	store.now = func() time.Time {
+		clock = clock.Add(time.Minute)
+		return clock
+	}
+	return store
+}
+
+// names returns the entry names, oldest first, joined for easy comparison
+func names(t *testing.T, store *Store) string {
+	t.Helper()
+
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatalf("Entries() returned error: %v", err)
+	}
+
+	var list []string
+	for _, entry := range entries {
+		list = append(list, entry.Name)
+	}
+	return strings.Join(list, ",")
+}
+
+// TestRecord tests recording entries and dropping the oldest when full
+func TestRecord(t *testing.T) {
+	store := newTestStore(t)
+	store.capacity = 3
+
+	for _, name := range []string{"api", "web", "api", "infra"} {
+		if err := store.Record(name); err != nil {
+			t.Fatalf("Record(%q) returned error: %v", name, err)
+		}
+	}
+
+	if got := names(t, store); got != "web,api,infra" {
+		t.Errorf("history = %s, want web,api,infra", got)
+	}
+}
+
+// TestRecordConcurrent tests that concurrent Records don't drop each other's entries
+func TestRecordConcurrent(t *testing.T) {
+	store := newTestStore(t)
+
+	const records = 50
+	var wg sync.WaitGroup
+	for range records {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := store.Record("api"); err != nil {
+				t.Errorf("Record() returned error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	entries, err := store.Entries()
+	if err != nil {
+		t.Fatalf("Entries() returned error: %v", err)
+	}
+	if len(entries) != records {
+		t.Errorf("history has %d entries, want %d", len(entries), records)
+	}
+}
+
+// TestPrune tests removing entries for sessions that no longer exist
+func TestPrune(t *testing.T) {
+	store := newTestStore(t)
+	for _, name := range []string{"api", "gone", "web", "old", "api", "gone"} {
+		if err := store.Record(name); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, _ := store.Entries()
+
+	existing := map[string]bool{"api": true, "web": true}
+	removed, err := store.Prune(func(name string) bool { return existing[name] })
+	if err != nil {
+		t.Fatalf("Prune() returned error: %v", err)
+	}
+
+	if removed != 3 {
+		t.Errorf("Prune() removed %d, want 3", removed)
+	}
+	if got := names(t, store); got != "api,web,api" {
+		t.Errorf("history = %s, want api,web,api (original order)", got)
+	}
+
+	// Survivors keep their original timestamps
+	after, _ := store.Entries()
+	if !after[0].Time.Equal(before[0].Time) || !after[2].Time.Equal(before[4].Time) {
+		t.Errorf("timestamps changed: before %v, after %v", before, after)
+	}
+}
+
+// TestClear tests wiping the history, including when there's nothing to wipe
+func TestClear(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear() on an empty history returned error: %v", err)
+	}
+
+	if err := store.Record("api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Clear(); err != nil {
+		t.Fatalf("Clear() returned error: %v", err)
+	}
+	if got := names(t, store); got != "" {
+		t.Errorf("history = %s, want empty", got)
+	}
+}
+
+// TestCorruptHistory tests that a damaged file is treated as an empty history
+func TestCorruptHistory(t *testing.T) {
+	store := newTestStore(t)
+	if err := os.WriteFile(store.path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := names(t, store); got != "" {
+		t.Errorf("history = %s, want empty", got)
+	}
+
+	// Recording starts a fresh history
+	if err := store.Record("api"); err != nil {
+		t.Fatalf("Record() returned error: %v", err)
+	}
+	if got := names(t, store); got != "api" {
+		t.Errorf("history = %s, want api", got)
+	}
+}
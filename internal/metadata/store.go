@@ -0,0 +1,106 @@
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// Store keeps session metadata in a JSON file, as an object of session names
+// to objects of keys to values:
+//
+//	{"api": {"note": "deploy on fridays", "tags": "work"}}
+type Store struct {
+	path string
+
+	// mu makes each read-modify-write of the file atomic within the process,
+	// so concurrent Sets don't drop each other's keys
+	mu sync.Mutex
+}
+
+// NewStore creates a metadata store backed by the file at path
+// The file is created on the first Set
+func NewStore(path string) *Store {
+	return &Store{path: path}
+}
+
+// Load returns every session's metadata, keyed by session name
+// A missing file means no metadata. Unlike the history, a corrupt file is an
+// error: it holds things the user wrote, and the next Set must not replace
+// it with an empty object
+func (s *Store) Load() (map[string]map[string]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.load()
+}
+
+// load reads the metadata file; the caller holds mu
+func (s *Store) load() (map[string]map[string]string, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return map[string]map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata %s: %w", s.path, err)
+	}
+
+	metadata := map[string]map[string]string{}
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse metadata %s: %w", s.path, err)
+	}
+
+	return metadata, nil
+}
+
+// Set stores value under a session's key
+// An empty value removes the key, and a session left with no keys is removed too
+func (s *Store) Set(name, key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	metadata, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	if value == "" {
+		delete(metadata[name], key)
+		if len(metadata[name]) == 0 {
+			delete(metadata, name)
+		}
+	} else {
+		if metadata[name] == nil {
+			metadata[name] = map[string]string{}
+		}
+		metadata[name][key] = value
+	}
+
+	return s.write(metadata)
+}
+
+// write replaces the metadata file
+func (s *Store) write(metadata map[string]map[string]string) error {
+	data, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create metadata directory: %w", err)
+	}
+	if err := os.WriteFile(s.path, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write metadata %s: %w", s.path, err)
+	}
+
+	return nil
+}
+
+// Verify interface implementation at compile time
+var _ session.MetadataStore = (*Store)(nil)
@@ -0,0 +1,72 @@
+package metadata
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// TestLoadMissing tests that a missing file is empty metadata
+func TestLoadMissing(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "metadata.json"))
+
+	metadata, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+	if len(metadata) != 0 {
+		t.Errorf("Load() = %v, want empty", metadata)
+	}
+}
+
+// TestSetAndLoad tests storing, overwriting, and removing values
+func TestSetAndLoad(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "nested", "metadata.json"))
+
+	steps := []struct{ name, key, value string }{
+		{"api", "note", "deploy on fridays"},
+		{"api", "color", "red"},
+		{"web", "lock", "true"},
+		{"api", "color", "blue"}, // overwrite
+		{"web", "lock", ""},      // remove the only key, and with it the session
+	}
+	for _, step := range steps {
+		if err := store.Set(step.name, step.key, step.value); err != nil {
+			t.Fatalf("Set(%q, %q, %q) returned error: %v", step.name, step.key, step.value, err)
+		}
+	}
+
+	metadata, err := store.Load()
+	if err != nil {
+		t.Fatalf("Load() returned error: %v", err)
+	}
+
+	want := map[string]map[string]string{
+		"api": {"note": "deploy on fridays", "color": "blue"},
+	}
+	if !reflect.DeepEqual(metadata, want) {
+		t.Errorf("Load() = %v, want %v", metadata, want)
+	}
+}
+
+// TestCorruptFile tests that a corrupt file is reported and left alone
+func TestCorruptFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	store := NewStore(path)
+
+	if _, err := store.Load(); err == nil {
+		t.Error("Load() expected error but got none")
+	}
+	if err := store.Set("api", "note", "hi"); err == nil {
+		t.Error("Set() expected error but got none")
+	}
+
+	data, _ := os.ReadFile(path)
+	if string(data) != "{not json" {
+		t.Errorf("file = %q, want it untouched", data)
+	}
+}
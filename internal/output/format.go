@@ -0,0 +1,164 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/datapointchris/sess/internal/session"
+	"github.com/mattn/go-runewidth"
+)
+
+// Icons decides how the icon column of a human-readable list is drawn
+type Icons struct {
+	// Theme picks each session type's icon (nil for the default icons)
+	Theme session.Theme
+
+	// Width is the display width icons are padded to (see ColumnWidth)
+	Width int
+
+	// ASCII draws plain ASCII markers (session.ASCIIIcon) instead of the
+	// theme's icons, and no colors
+	ASCII bool
+}
+
+// Icon returns the icon for a session type, or its ASCII marker
+func (i Icons) Icon(sessionType session.SessionType) string {
+	if i.ASCII {
+		return session.ASCIIIcon(sessionType)
+	}
+	return i.Theme.Icon(sessionType)
+}
+
+// ForWriter returns the icons to draw in a list written to w
+// Anything but a terminal gets ASCII markers: a pipe or file may end up
+// somewhere without the font for the icons
+func (i Icons) ForWriter(w io.Writer) Icons {
+	if !isTerminal(w) {
+		i.ASCII = true
+	}
+	return i
+}
+
+// isTerminal reports whether w is an interactive terminal rather than a pipe, file, or buffer
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// ColumnWidth returns the display width icons are padded to
+// A width <= 0 means auto: the widest icon as measured by runewidth. The
+// icons don't all have the same width everywhere - in East Asian locales ●
+// and ○ take two cells while ⚙ takes one - so without padding the names
+// after them don't line up
+func (i Icons) ColumnWidth() int {
+	if i.Width > 0 {
+		return i.Width
+	}
+
+	widest := 0
+	for _, sessionType := range session.SessionTypes {
+		widest = max(widest, runewidth.StringWidth(i.Icon(sessionType)))
+	}
+	return widest
+}
+
+// Pad returns the icon for a session type, padded with spaces to the column width
+func (i Icons) Pad(sessionType session.SessionType) string {
+	return runewidth.FillRight(i.Icon(sessionType), i.ColumnWidth())
+}
+
+// FormatSessionLine renders a session as its padded icon followed by its details
+// Every human-readable session list (list, the picker) goes through here so
+// they stay aligned the same way
+func FormatSessionLine(sess session.Session, icons Icons) string {
+	line := icons.Pad(sess.Type) + " " + sess.DisplayInfo()
+	if sess.Socket != "" {
+		line += " [" + sess.Socket + "]"
+	}
+	return line
+}
+
+// WriteList writes sessions in the human-readable format, one line per session
+// This format is for people and may change; scripts should use porcelain or JSON
+func WriteList(w io.Writer, sessions []session.Session, icons Icons) error {
+	if len(sessions) == 0 {
+		_, err := fmt.Fprintln(w, "No sessions found")
+		return err
+	}
+
+	for _, sess := range sessions {
+		if _, err := fmt.Fprintln(w, FormatSessionLine(sess, icons)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// summaryLabels are how FormatSummary names each session type
+var summaryLabels = map[session.SessionType]string{
+	session.SessionTypeTmux:       "active",
+	session.SessionTypeTmuxinator: "tmuxinator",
+	session.SessionTypeDefault:    "default",
+}
+
+// FormatSummary renders session counts (see session.CountByType) as
+// "3 active, 2 tmuxinator, 5 default (10 total)"
+func FormatSummary(counts map[session.SessionType]int) string {
+	parts := make([]string, 0, len(session.SessionTypes))
+	total := 0
+	for _, sessionType := range session.SessionTypes {
+		parts = append(parts, fmt.Sprintf("%d %s", counts[sessionType], summaryLabels[sessionType]))
+		total += counts[sessionType]
+	}
+	return fmt.Sprintf("%s (%d total)", strings.Join(parts, ", "), total)
+}
+
+// WriteJSON writes sessions as a JSON array on a single line
+// Keeping it to one line means repeated calls form a stream of JSON lines
+func WriteJSON(w io.Writer, sessions []session.Session) error {
+	// A nil slice would encode as null, but an empty list is still a list
+	if sessions == nil {
+		sessions = []session.Session{}
+	}
+
+	return json.NewEncoder(w).Encode(sessions)
+}
+
+// WritePorcelain writes sessions in the stable, machine-readable format
+// Each line is TYPE, NAME, WINDOWS, and DIR separated by tabs, e.g.
+//
+//	tmux	api	3	/home/me/code/api
+//
+// Scripts depend on this format, so the fields and their order must never
+// change. New information belongs in a new format, not an extra column.
+func WritePorcelain(w io.Writer, sessions []session.Session) error {
+	for _, sess := range sessions {
+		_, err := fmt.Fprintf(w, "%s\t%s\t%d\t%s\n",
+			sess.Type,
+			porcelainField(sess.Name),
+			sess.WindowCount,
+			porcelainField(sess.Directory),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// porcelainReplacer flattens the characters the porcelain format uses as separators
+var porcelainReplacer = strings.NewReplacer("\t", " ", "\n", " ")
+
+// porcelainField keeps a value from breaking the line and field structure
+func porcelainField(value string) string {
+	return porcelainReplacer.Replace(value)
+}
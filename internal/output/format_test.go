@@ -0,0 +1,216 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datapointchris/sess/internal/session"
+	"github.com/mattn/go-runewidth"
+)
+
+// TestWritePorcelain pins the exact porcelain format for each session type
+// If this test fails, a script somewhere just broke - don't update the expected
+// output without a very good reason
+func TestWritePorcelain(t *testing.T) {
+	tests := []struct {
+		name    string
+		session session.Session
+		want    string
+	}{
+		{
+			name:    "active tmux session",
+			session: session.Session{Name: "api", Type: session.SessionTypeTmux, WindowCount: 3, Directory: "/code/api"},
+			want:    "tmux\tapi\t3\t/code/api\n",
+		},
+		{
+			name:    "tmuxinator project",
+			session: session.Session{Name: "infra", Type: session.SessionTypeTmuxinator},
+			want:    "tmuxinator\tinfra\t0\t\n",
+		},
+		{
+			name:    "default session",
+			session: session.Session{Name: "dotfiles", Type: session.SessionTypeDefault, Directory: "/home/me/dotfiles"},
+			want:    "default\tdotfiles\t0\t/home/me/dotfiles\n",
+		},
+		{
+			name:    "separators in values are flattened",
+			session: session.Session{Name: "odd", Type: session.SessionTypeDefault, Directory: "/tmp/a\tb\nc"},
+			want:    "default\todd\t0\t/tmp/a b c\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf strings.Builder
+			if err := WritePorcelain(&buf, []session.Session{tt.session}); err != nil {
+				t.Fatalf("WritePorcelain() returned error: %v", err)
+			}
+
+			if buf.String() != tt.want {
+				t.Errorf("WritePorcelain() = %q, want %q", buf.String(), tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteJSONEmpty tests that no sessions encode as an empty array, not null
+func TestWriteJSONEmpty(t *testing.T) {
+	var b strings.Builder
+	if err := WriteJSON(&b, nil); err != nil {
+		t.Fatalf("WriteJSON() returned error: %v", err)
+	}
+
+	if b.String() != "[]\n" {
+		t.Errorf("WriteJSON() = %q, want %q", b.String(), "[]\n")
+	}
+}
+
+// TestWriteJSONFields tests that the type is written as its name and the
+// creation time as RFC3339, which is what scripts parsing the output expect
+func TestWriteJSONFields(t *testing.T) {
+	created := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	sessions := []session.Session{
+		{Name: "api", Type: session.SessionTypeTmux, WindowCount: 2, IsActive: true, CreatedAt: created},
+	}
+
+	var b strings.Builder
+	if err := WriteJSON(&b, sessions); err != nil {
+		t.Fatalf("WriteJSON() returned error: %v", err)
+	}
+
+	var decoded []map[string]any
+	if err := json.Unmarshal([]byte(b.String()), &decoded); err != nil {
+		t.Fatalf("WriteJSON() wrote invalid JSON %q: %v", b.String(), err)
+	}
+	if len(decoded) != 1 {
+		t.Fatalf("WriteJSON() wrote %d sessions, want 1", len(decoded))
+	}
+
+	if decoded[0]["type"] != "tmux" {
+		t.Errorf("type = %v, want %q", decoded[0]["type"], "tmux")
+	}
+	if want := created.Format(time.RFC3339); decoded[0]["created_at"] != want {
+		t.Errorf("created_at = %v, want %q", decoded[0]["created_at"], want)
+	}
+}
+
+// TestFormatSessionLineAlignment tests that the icon column has the same
+// display width for every session type, so the names line up
+func TestFormatSessionLineAlignment(t *testing.T) {
+	sessions := []session.Session{
+		{Name: "api", Type: session.SessionTypeTmux, WindowCount: 2},
+		{Name: "infra", Type: session.SessionTypeTmuxinator},
+		{Name: "dotfiles", Type: session.SessionTypeDefault},
+	}
+
+	tests := []struct {
+		name      string
+		eastAsian bool // ● and ○ are two cells wide, ⚙ is one
+		iconWidth int
+		wantWidth int
+	}{
+		{name: "auto", eastAsian: false, iconWidth: 0, wantWidth: 1},
+		{name: "auto in an East Asian locale", eastAsian: true, iconWidth: 0, wantWidth: 2},
+		{name: "configured", eastAsian: false, iconWidth: 3, wantWidth: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			saved := runewidth.DefaultCondition.EastAsianWidth
+			runewidth.DefaultCondition.EastAsianWidth = tt.eastAsian
+			defer func() { runewidth.DefaultCondition.EastAsianWidth = saved }()
+
+			for _, sess := range sessions {
+				line := FormatSessionLine(sess, Icons{Width: tt.iconWidth})
+
+				// Everything before the name is the icon column plus one space
+				prefix, _, found := strings.Cut(line, sess.Name)
+				if !found {
+					t.Fatalf("line %q doesn't contain the name", line)
+				}
+				if width := runewidth.StringWidth(prefix); width != tt.wantWidth+1 {
+					t.Errorf("%s: icon column of %q is %d cells wide, want %d", sess.Type, line, width-1, tt.wantWidth)
+				}
+			}
+		})
+	}
+}
+
+// TestFormatSessionLineTheme tests that a theme's icons are used, and that
+// the column widens to fit the widest of them
+func TestFormatSessionLineTheme(t *testing.T) {
+	icons := Icons{Theme: session.Theme{
+		session.SessionTypeTmux:    {Icon: "[on]"},
+		session.SessionTypeDefault: {Icon: "--"},
+	}}
+
+	tests := []struct {
+		sess session.Session
+		want string
+	}{
+		{sess: session.Session{Name: "api", Type: session.SessionTypeTmux}, want: "[on] api"},
+		{sess: session.Session{Name: "infra", Type: session.SessionTypeTmuxinator}, want: "⚙    infra"},
+		{sess: session.Session{Name: "notes", Type: session.SessionTypeDefault}, want: "--   notes"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSessionLine(tt.sess, icons); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("FormatSessionLine(%s) = %q, want it to start with %q", tt.sess.Name, got, tt.want)
+		}
+	}
+}
+
+// TestIconsForWriter tests that output which isn't going to a terminal gets
+// ASCII markers instead of icons
+func TestIconsForWriter(t *testing.T) {
+	icons := Icons{Theme: session.Theme{session.SessionTypeTmux: {Icon: "[on]"}}}.ForWriter(&bytes.Buffer{})
+	if !icons.ASCII {
+		t.Fatal("ForWriter(buffer) should switch to ASCII markers")
+	}
+
+	tests := []struct {
+		sess session.Session
+		want string
+	}{
+		{sess: session.Session{Name: "api", Type: session.SessionTypeTmux}, want: "* api"},
+		{sess: session.Session{Name: "infra", Type: session.SessionTypeTmuxinator}, want: "+ infra"},
+		{sess: session.Session{Name: "notes", Type: session.SessionTypeDefault}, want: "- notes"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatSessionLine(tt.sess, icons); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("FormatSessionLine(%s) = %q, want it to start with %q", tt.sess.Name, got, tt.want)
+		}
+	}
+}
+
+// TestFormatSummary tests the count line of "sess list --summary"
+func TestFormatSummary(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[session.SessionType]int
+		want   string
+	}{
+		{
+			name:   "every type",
+			counts: map[session.SessionType]int{session.SessionTypeTmux: 3, session.SessionTypeTmuxinator: 2, session.SessionTypeDefault: 5},
+			want:   "3 active, 2 tmuxinator, 5 default (10 total)",
+		},
+		{
+			name:   "missing types count as none",
+			counts: map[session.SessionType]int{session.SessionTypeTmux: 1},
+			want:   "1 active, 0 tmuxinator, 0 default (1 total)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FormatSummary(tt.counts); got != tt.want {
+				t.Errorf("FormatSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
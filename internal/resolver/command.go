@@ -0,0 +1,59 @@
+package resolver
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+
+	"github.com/datapointchris/sess/internal/runner"
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// CommandResolver asks an external command where a session should start
+// The command is run with the session name as its last argument. Exiting 0
+// and printing a directory means "start it there"; a non-zero exit or no
+// output means the name isn't one it knows.
+type CommandResolver struct {
+	runner  runner.Runner
+	command []string
+}
+
+// NewCommandResolver creates a resolver for a command line like "~/bin/find-project"
+// or "zoxide query"; the command is split on whitespace, no shell is involved
+func NewCommandResolver(r runner.Runner, command string) *CommandResolver {
+	return &CommandResolver{
+		runner:  r,
+		command: strings.Fields(command),
+	}
+}
+
+// ResolveDirectory runs the command and returns the directory it printed
+func (c *CommandResolver) ResolveDirectory(name string) (string, bool, error) {
+	if len(c.command) == 0 {
+		return "", false, nil
+	}
+
+	args := append(c.command[1:len(c.command):len(c.command)], name)
+	output, err := c.runner.Output(c.command[0], args...)
+	if err != nil {
+		// A non-zero exit is the command's way of saying "not mine"
+		// Anything else (not found, timed out) is a broken setup worth reporting
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	// Only the first line counts, so chatty scripts still work
+	directory, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	directory = strings.TrimSpace(directory)
+	if directory == "" {
+		return "", false, nil
+	}
+
+	return directory, true, nil
+}
+
+// Verify interface implementation at compile time
+var _ session.DirectoryResolver = (*CommandResolver)(nil)
@@ -0,0 +1,114 @@
+package resolver
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeRunner returns canned output and records the command it was asked to run
+type fakeRunner struct {
+	output string
+	err    error
+	calls  [][]string
+}
+
+func (f *fakeRunner) Run(name string, args ...string) error { return nil }
+
+func (f *fakeRunner) Output(name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, append([]string{name}, args...))
+	return []byte(f.output), f.err
+}
+
+func (f *fakeRunner) Interactive(name string, args ...string) error { return nil }
+
+func (f *fakeRunner) InteractiveEnv(env []string, name string, args ...string) error { return nil }
+
+func (f *fakeRunner) LookPath(name string) (string, error) { return name, nil }
+
+// TestResolveDirectory tests the found and not-found answers from the resolver command
+func TestResolveDirectory(t *testing.T) {
+	tests := []struct {
+		name      string
+		command   string
+		output    string
+		err       error
+		wantCall  string
+		wantDir   string
+		wantFound bool
+		wantError bool
+	}{
+		{
+			name:      "found",
+			command:   "find-project",
+			output:    "/code/api\n",
+			wantCall:  "find-project api",
+			wantDir:   "/code/api",
+			wantFound: true,
+		},
+		{
+			name:      "command with arguments, first line wins",
+			command:   "zoxide query",
+			output:    "/code/api\n/code/api-old\n",
+			wantCall:  "zoxide query api",
+			wantDir:   "/code/api",
+			wantFound: true,
+		},
+		{
+			name:     "non-zero exit means not found",
+			command:  "find-project",
+			err:      &exec.ExitError{},
+			wantCall: "find-project api",
+		},
+		{
+			name:     "no output means not found",
+			command:  "find-project",
+			output:   "  \n",
+			wantCall: "find-project api",
+		},
+		{
+			name:      "command that can't run is an error",
+			command:   "find-project",
+			err:       errors.New("executable file not found in $PATH"),
+			wantCall:  "find-project api",
+			wantError: true,
+		},
+		{
+			name:    "no command configured",
+			command: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{output: tt.output, err: tt.err}
+			resolver := NewCommandResolver(runner, tt.command)
+
+			dir, found, err := resolver.ResolveDirectory("api")
+			if tt.wantError {
+				if err == nil {
+					t.Error("ResolveDirectory() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveDirectory() unexpected error: %v", err)
+			}
+
+			if dir != tt.wantDir || found != tt.wantFound {
+				t.Errorf("ResolveDirectory() = (%q, %v), want (%q, %v)", dir, found, tt.wantDir, tt.wantFound)
+			}
+
+			if tt.wantCall == "" {
+				if len(runner.calls) != 0 {
+					t.Errorf("ran %v, want nothing", runner.calls)
+				}
+				return
+			}
+			if len(runner.calls) != 1 || strings.Join(runner.calls[0], " ") != tt.wantCall {
+				t.Errorf("ran %v, want %q", runner.calls, tt.wantCall)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// DryRun is a Runner that prints each command instead of executing it
+// Commands print nothing, and the ones that ask whether something exists
+// (tmux has-session) fail, so sess carries on as if tmux had no sessions
+// yet and shows what it would create. Every other command succeeds.
+// LookPath still searches PATH, since finding a program runs nothing.
+type DryRun struct {
+	w io.Writer
+}
+
+// NewDryRun creates a runner that writes the commands it's given to w
+func NewDryRun(w io.Writer) *DryRun {
+	return &DryRun{w: w}
+}
+
+// errNotRunning is what a query fails with in a dry run
+var errNotRunning = errors.New("dry run: nothing is running")
+
+// queries are the tmux commands that only check whether something exists
+var queries = map[string]bool{"has-session": true}
+
+// Run prints the command, failing it if it's a query
+func (d *DryRun) Run(name string, args ...string) error {
+	if err := d.print(name, args); err != nil {
+		return err
+	}
+	if isQuery(args) {
+		return errNotRunning
+	}
+	return nil
+}
+
+// isQuery reports whether args are a tmux query, after any server flags
+func isQuery(args []string) bool {
+	for i := 0; i < len(args); i++ {
+		switch args[i] {
+		case "-L", "-S", "-f":
+			// These take a value
+			i++
+			continue
+		case "-u":
+			continue
+		}
+		return queries[args[i]]
+	}
+	return false
+}
+
+// Output prints the command and returns no output
+func (d *DryRun) Output(name string, args ...string) ([]byte, error) {
+	return nil, d.print(name, args)
+}
+
+// Interactive prints the command
+func (d *DryRun) Interactive(name string, args ...string) error {
+	return d.print(name, args)
+}
+
+// InteractiveEnv prints the command
+func (d *DryRun) InteractiveEnv(env []string, name string, args ...string) error {
+	return d.print(name, args)
+}
+
+// LookPath searches for an executable in PATH
+func (d *DryRun) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// print writes the command line, quoted so it can be pasted into a shell
+func (d *DryRun) print(name string, args []string) error {
+	_, err := fmt.Fprintln(d.w, commandLine(name, args))
+	return err
+}
+
+// Quote returns word as a shell would need it typed
+// Words made only of safe characters are left alone; anything else is
+// wrapped in single quotes
+func Quote(word string) string {
+	if word == "" {
+		return "''"
+	}
+	safe := strings.IndexFunc(word, func(r rune) bool {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return false
+		}
+		return !strings.ContainsRune("-_./:=@%+,#{}", r)
+	}) == -1
+	if safe {
+		return word
+	}
+	return "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+}
+
+// Verify interface implementation at compile time
+var _ Runner = (*DryRun)(nil)
@@ -0,0 +1,83 @@
+package runner
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Logging is a Runner that logs every command it passes on to another Runner
+// Commands and their outcome are logged at debug level, which is what
+// "sess -vv" shows
+type Logging struct {
+	next   Runner
+	logger *slog.Logger
+}
+
+// NewLogging creates a runner that runs commands through next, logging each one to logger
+func NewLogging(next Runner, logger *slog.Logger) *Logging {
+	return &Logging{next: next, logger: logger}
+}
+
+// Run executes a command and logs how it went
+func (l *Logging) Run(name string, args ...string) error {
+	start := time.Now()
+	err := l.next.Run(name, args...)
+	l.log(name, args, start, err)
+	return err
+}
+
+// Output executes a command, logging how it went and how much it printed
+func (l *Logging) Output(name string, args ...string) ([]byte, error) {
+	start := time.Now()
+	output, err := l.next.Output(name, args...)
+	l.log(name, args, start, err, "bytes", len(output))
+	return output, err
+}
+
+// Interactive executes a command attached to the terminal and logs how it went
+// It's logged before it starts as well, since it runs for as long as the user wants
+func (l *Logging) Interactive(name string, args ...string) error {
+	l.logger.Debug("starting interactive command", "command", commandLine(name, args))
+	start := time.Now()
+	err := l.next.Interactive(name, args...)
+	l.log(name, args, start, err)
+	return err
+}
+
+// InteractiveEnv is Interactive with its own environment
+func (l *Logging) InteractiveEnv(env []string, name string, args ...string) error {
+	l.logger.Debug("starting interactive command", "command", commandLine(name, args))
+	start := time.Now()
+	err := l.next.InteractiveEnv(env, name, args...)
+	l.log(name, args, start, err)
+	return err
+}
+
+// LookPath searches for an executable in PATH and logs whether it was found
+func (l *Logging) LookPath(name string) (string, error) {
+	path, err := l.next.LookPath(name)
+	l.logger.Debug("looked up executable", "name", name, "path", path, "error", err)
+	return path, err
+}
+
+// log records a finished command
+func (l *Logging) log(name string, args []string, start time.Time, err error, attrs ...any) {
+	attrs = append([]any{"command", commandLine(name, args), "duration", time.Since(start)}, attrs...)
+	if err != nil {
+		attrs = append(attrs, "error", err)
+	}
+	l.logger.Debug("ran command", attrs...)
+}
+
+// commandLine returns the command quoted the way a shell would need it
+func commandLine(name string, args []string) string {
+	words := make([]string, 0, len(args)+1)
+	for _, word := range append([]string{name}, args...) {
+		words = append(words, Quote(word))
+	}
+	return strings.Join(words, " ")
+}
+
+// Verify interface implementation at compile time
+var _ Runner = (*Logging)(nil)
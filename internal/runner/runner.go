@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long a non-interactive command may run before it's killed
+const DefaultTimeout = 10 * time.Second
+
+// Runner executes external commands on behalf of the clients
+// The clients never call os/exec directly, so tests can swap in a fake
+// runner and inspect the exact arguments without tmux being installed
+type Runner interface {
+	// Run executes a command and waits for it to finish
+	Run(name string, args ...string) error
+
+	// Output executes a command and returns what it printed to stdout
+	Output(name string, args ...string) ([]byte, error)
+
+	// Interactive executes a command with the terminal's stdin/stdout/stderr
+	// connected, for commands the user interacts with (like attaching to tmux)
+	Interactive(name string, args ...string) error
+
+	// InteractiveEnv is Interactive with env as the command's whole
+	// environment, for commands that must not see some of sess's
+	InteractiveEnv(env []string, name string, args ...string) error
+
+	// LookPath searches for an executable in PATH
+	LookPath(name string) (string, error)
+}
+
+// Exec is the real Runner backed by os/exec
+type Exec struct {
+	// timeout bounds Run and Output so a hung tmux server can't hang sess
+	// Interactive commands are never timed out - they last as long as the user wants
+	timeout time.Duration
+}
+
+// New creates a runner that executes real commands with the default timeout
+func New() *Exec {
+	return NewWithTimeout(DefaultTimeout)
+}
+
+// NewWithTimeout creates a runner whose non-interactive commands are killed after timeout
+// A timeout of 0 disables it
+func NewWithTimeout(timeout time.Duration) *Exec {
+	return &Exec{timeout: timeout}
+}
+
+// CommandError is a failed command together with what it printed to stderr
+// tmux explains its failures on stderr ("no current client", "can't find
+// session"), which says a lot more than the bare exit status
+type CommandError struct {
+	Name   string
+	Stderr string
+	Err    error
+}
+
+// Error returns the command's own explanation of the failure
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Name, e.Stderr)
+}
+
+// Unwrap returns the underlying error, so errors.As still finds *exec.ExitError
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// Run executes a command and waits for it to finish
+func (e *Exec) Run(name string, args ...string) error {
+	ctx, cancel := e.context()
+	defer cancel()
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	return e.timeoutError(ctx, name, commandError(name, stderr.Bytes(), err))
+}
+
+// Output executes a command and returns its stdout
+func (e *Exec) Output(name string, args ...string) ([]byte, error) {
+	ctx, cancel := e.context()
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, name, args...).Output()
+
+	// Output already collected stderr into the ExitError for us
+	var stderr []byte
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		stderr = exitErr.Stderr
+	}
+
+	return output, e.timeoutError(ctx, name, commandError(name, stderr, err))
+}
+
+// Interactive executes a command attached to the current terminal
+func (e *Exec) Interactive(name string, args ...string) error {
+	return e.InteractiveEnv(nil, name, args...)
+}
+
+// InteractiveEnv executes a command attached to the current terminal with
+// env as its environment; a nil env is sess's own
+func (e *Exec) InteractiveEnv(env []string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Env = env
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// LookPath searches for an executable in PATH
+func (e *Exec) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// commandError attaches stderr to a failed command's error
+// Errors without any stderr are returned unchanged
+func commandError(name string, stderr []byte, err error) error {
+	message := strings.TrimSpace(string(stderr))
+	if err == nil || message == "" {
+		return err
+	}
+	return &CommandError{Name: name, Stderr: message, Err: err}
+}
+
+// context returns the context a non-interactive command runs under
+func (e *Exec) context() (context.Context, context.CancelFunc) {
+	if e.timeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), e.timeout)
+}
+
+// timeoutError replaces the unhelpful "signal: killed" with a message saying why
+func (e *Exec) timeoutError(ctx context.Context, name string, err error) error {
+	if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%s timed out after %s (raise it with --timeout)", name, e.timeout)
+	}
+	return err
+}
+
+// Verify interface implementation at compile time
+var _ Runner = (*Exec)(nil)
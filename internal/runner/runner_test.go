@@ -0,0 +1,107 @@
+package runner
+
+import (
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestStderrInErrors tests that a failing command's stderr ends up in its error
+func TestStderrInErrors(t *testing.T) {
+	script := "echo 'no current client' >&2; exit 1"
+	r := New()
+
+	runErr := r.Run("sh", "-c", script)
+	_, outputErr := r.Output("sh", "-c", script)
+
+	for name, err := range map[string]error{"Run": runErr, "Output": outputErr} {
+		if err == nil || err.Error() != "sh: no current client" {
+			t.Errorf("%s() error = %v, want %q", name, err, "sh: no current client")
+		}
+
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			t.Errorf("%s() error doesn't unwrap to *exec.ExitError", name)
+		}
+	}
+
+	// Without stderr there's nothing to add
+	if err := r.Run("sh", "-c", "exit 1"); err == nil || err.Error() != "exit status 1" {
+		t.Errorf("Run() error = %v, want the plain exit status", err)
+	}
+}
+
+// TestTimeout tests that a command running past the timeout is killed with a helpful error
+func TestTimeout(t *testing.T) {
+	err := NewWithTimeout(50*time.Millisecond).Run("sleep", "5")
+	if err == nil || err.Error() != "sleep timed out after 50ms (raise it with --timeout)" {
+		t.Errorf("Run() error = %v, want a timeout error", err)
+	}
+}
+
+// TestDryRun tests that a dry run prints each command without running it
+func TestDryRun(t *testing.T) {
+	marker := filepath.Join(t.TempDir(), "ran")
+	var out bytes.Buffer
+	r := NewDryRun(&out)
+
+	if err := r.Run("touch", marker); err != nil {
+		t.Fatalf("Run() unexpected error: %v", err)
+	}
+	output, err := r.Output("tmux", "list-sessions", "-F", "#{session_name}")
+	if err != nil || len(output) != 0 {
+		t.Fatalf("Output() = %q, %v, want no output and no error", output, err)
+	}
+	if err := r.Interactive("tmux", "new-session", "-s", "my notes", "-c", "it's here"); err != nil {
+		t.Fatalf("Interactive() unexpected error: %v", err)
+	}
+
+	if err := r.Run("tmux", "-L", "work", "has-session", "-t", "api"); err == nil {
+		t.Error("Run(has-session) succeeded, want it to fail so nothing looks like it's running")
+	}
+
+	if _, err := os.Stat(marker); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("touch ran during a dry run (stat error: %v)", err)
+	}
+
+	want := "touch " + marker + "\n" +
+		"tmux list-sessions -F #{session_name}\n" +
+		`tmux new-session -s 'my notes' -c 'it'\''s here'` + "\n" +
+		"tmux -L work has-session -t api\n"
+	if out.String() != want {
+		t.Errorf("printed:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
+
+// TestLogging tests that each command is logged at debug level with its outcome
+func TestLogging(t *testing.T) {
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	r := NewLogging(New(), logger)
+
+	output, err := r.Output("echo", "hello world")
+	if err != nil || string(output) != "hello world\n" {
+		t.Fatalf("Output() = %q, %v, want the command's output", output, err)
+	}
+	if err := r.Run("sh", "-c", "exit 3"); err == nil {
+		t.Fatal("Run() expected the command's error")
+	}
+
+	for _, want := range []string{
+		`command="echo 'hello world'"`,
+		"bytes=12",
+		`command="sh -c 'exit 3'"`,
+		`error="exit status 3"`,
+	} {
+		if !strings.Contains(logs.String(), want) {
+			t.Errorf("logs don't contain %s:\n%s", want, logs.String())
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package session
+
+import "strings"
+
+// maxFuzzyDistance is how many single-character edits a typo may be away from a name
+const maxFuzzyDistance = 2
+
+// FuzzyMatches returns the names close to query: within a couple of typos,
+// or starting with what was typed
+func FuzzyMatches(query string, names []string) []string {
+	var matches []string
+	for _, name := range names {
+		if name == query {
+			continue // not a fuzzy match, the caller would have found it already
+		}
+		if strings.HasPrefix(name, query) || levenshtein(query, name) <= maxFuzzyDistance {
+			matches = append(matches, name)
+		}
+	}
+	return matches
+}
+
+// Similarity scores how alike two names are, from 0 (nothing in common) to 1 (identical)
+// It's the edit distance scaled by the longer name, so "dotfile" and
+// "dotfiles" are very similar while "prod" and "production-db" are not,
+// even though both are fuzzy matches
+func Similarity(a, b string) float64 {
+	longest := max(len([]rune(a)), len([]rune(b)))
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(longest)
+}
+
+// levenshtein returns the number of single-character insertions, deletions,
+// and substitutions needed to turn a into b
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	// Only the previous row of the classic matrix is needed
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,54 @@
+package session
+
+import "fmt"
+
+// BuildImport turns an active session's windows into a default session config
+// The first window's directory becomes the session directory, and any
+// further windows become the config's windows
+// The first window's name is kept unless it's just what's running in it,
+// which is how tmux names windows nobody renamed
+func BuildImport(name string, windows []Window) SessionConfig {
+	config := SessionConfig{Name: name}
+	if len(windows) == 0 {
+		return config
+	}
+
+	directory := windows[0].Directory
+	config.Directory = directory
+	if windows[0].Name != windows[0].Command {
+		config.WindowName = windows[0].Name
+	}
+
+	// The first window comes with the session, so only the rest are listed
+	for _, window := range windows[1:] {
+		windowConfig := WindowConfig{Name: window.Name}
+		// Windows in the session directory already default to it
+		if window.Directory != directory {
+			windowConfig.Directory = window.Directory
+		}
+		config.Windows = append(config.Windows, windowConfig)
+	}
+
+	return config
+}
+
+// ImportRunning captures every active tmux session as config
+// Deciding which ones are already in the config file is left to the writer,
+// which reads the file as it is when writing
+func (m *Manager) ImportRunning() ([]SessionConfig, error) {
+	sessions, err := m.tmuxClient.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	imports := make([]SessionConfig, 0, len(sessions))
+	for _, sess := range sessions {
+		windows, err := m.tmuxClient.ListWindows(sess.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list windows of %s: %w", sess.Name, err)
+		}
+		imports = append(imports, BuildImport(sess.Name, windows))
+	}
+
+	return imports, nil
+}
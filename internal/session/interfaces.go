@@ -19,24 +19,101 @@ type TmuxClient interface {
 	// The Session parameter contains the configuration
 	CreateSession(session Session) error
 
+	// CreateDetachedSession creates a new tmux session without switching or attaching to it
+	// Used when the session needs more setup (extra windows) before the user sees it
+	CreateDetachedSession(session Session) error
+
 	// SwitchToSession switches to an existing session
 	// fromTmux indicates if we're already inside tmux (affects the command used)
 	SwitchToSession(name string, fromTmux bool) error
 
 	// AttachToSession attaches to a session (used when not already in tmux)
-	AttachToSession(name string) error
+	// detachOthers detaches any other clients attached to the session (tmux attach -d)
+	AttachToSession(name string, detachOthers bool) error
+
+	// AttachedClients returns how many clients are attached to a session
+	AttachedClients(name string) (int, error)
 
 	// IsInsideTmux checks if we're currently running inside a tmux session
 	IsInsideTmux() bool
 
+	// CurrentSession returns the name of the session sess is running in, or
+	// an error outside tmux
+	CurrentSession() (string, error)
+
+	// ShowEnvironment returns a session's tmux environment (show-environment -t)
+	// Variables the session marks as removed are left out
+	ShowEnvironment(session string) (map[string]string, error)
+
 	// SwitchToLastSession switches to the previously active session
 	SwitchToLastSession() error
 
+	// DetachSession detaches the current client, leaving its session running
+	DetachSession() error
+
 	// DeleteSession deletes a tmux session
 	DeleteSession(name string) error
 
+	// RenameSession renames an active tmux session
+	RenameSession(oldName, newName string) error
+
+	// KillServer ends the tmux server, and with it every session
+	KillServer() error
+
+	// ServerRunning reports whether a tmux server is up, even one with no sessions
+	ServerRunning() bool
+
+	// ListWindows returns the windows of a session in index order
+	ListWindows(session string) ([]Window, error)
+
+	// NewWindow adds a window to the end of a session, starting in the given directory
+	// The session's current window stays selected
+	NewWindow(session, name, directory string) error
+
+	// SendKeys types a command into the target pane and presses Enter
+	SendKeys(target, command string) error
+
+	// ListPanes returns the targets (session:window.pane) of every pane in a session
+	ListPanes(session string) ([]string, error)
+
+	// ListPaneDetails returns every pane in a session, in window and pane order
+	ListPaneDetails(session string) ([]Pane, error)
+
+	// SplitWindow adds a pane to the target window, starting in the given
+	// directory; the new pane becomes the window's active pane
+	SplitWindow(target, directory string) error
+
+	// SelectLayout arranges the target window's panes by a window_layout string
+	SelectLayout(target, layout string) error
+
+	// RenameWindow renames the target window
+	RenameWindow(target, name string) error
+
+	// MoveWindow moves a session's window to the end of another session
+	MoveWindow(srcSession string, windowIndex int, dstSession string) error
+
+	// CapturePane returns the text in a pane, plus up to scrollback lines of
+	// its history (0 captures just what's on screen)
+	CapturePane(target string, scrollback int) (string, error)
+
+	// SetOption sets a tmux option on a session (tmux set-option -t)
+	SetOption(session, key, value string) error
+
+	// GetOption reads a session's tmux option, falling back to the global
+	// value ("" for a user option that was never set)
+	GetOption(session, key string) (string, error)
+
+	// SetSyncPanes turns synchronize-panes on or off for a window ("" for the current window)
+	SetSyncPanes(window string, on bool) error
+
+	// GetSyncPanes reports whether synchronize-panes is on for a window ("" for the current window)
+	GetSyncPanes(window string) (bool, error)
+
 	// ReloadConfig reloads tmux configuration in all sessions
 	ReloadConfig() error
+
+	// ReloadConfigFor reloads tmux configuration in a single session
+	ReloadConfigFor(name string) error
 }
 
 // TmuxinatorClient defines operations for interacting with tmuxinator
@@ -49,10 +126,24 @@ type TmuxinatorClient interface {
 
 	// StartProject starts a tmuxinator project
 	// fromTmux indicates if we're already inside tmux
-	StartProject(name string, fromTmux bool) error
+	// It returns the name of the project's session, which the project file
+	// can set to something other than the project's name
+	StartProject(name string, fromTmux bool) (string, error)
+
+	// StartProjectDetached starts a tmuxinator project without switching or
+	// attaching to its session, and returns the session's name
+	StartProjectDetached(name string) (string, error)
+
+	// StopProject stops a tmuxinator project, running its on_project_stop
+	// hooks before killing the session
+	StopProject(name string) error
 
 	// IsInstalled checks if tmuxinator is available on the system
 	IsInstalled() bool
+
+	// Invalidate forgets any cached project list, so the next listing
+	// picks up projects added or removed since
+	Invalidate()
 }
 
 // ConfigLoader defines operations for loading session configurations
@@ -65,6 +156,53 @@ type ConfigLoader interface {
 	GetSessionConfig(name, platform string) (*SessionConfig, error)
 }
 
+// EventSink receives notifications when sessions are created, switched to, or deleted
+// Emit has no error return on purpose: a listener that's gone away must never
+// make the session operation itself fail
+type EventSink interface {
+	// Emit delivers an event (best-effort)
+	Emit(event Event)
+}
+
+// Confirmer asks the user a yes/no question
+// The real one prompts on the terminal; tests answer with a fixed value
+type Confirmer interface {
+	// Confirm returns true if the user answered yes
+	Confirm(question string) (bool, error)
+}
+
+// DirectoryResolver finds a starting directory for a name that isn't known anywhere else
+// It's the last thing CreateOrSwitch tries before creating a plain session,
+// so user scripts can plug in their own project discovery
+type DirectoryResolver interface {
+	// ResolveDirectory returns the directory for name, and false if it has none
+	ResolveDirectory(name string) (string, bool, error)
+}
+
+// ZoxideClient looks up frequently used directories with zoxide
+type ZoxideClient interface {
+	// IsInstalled checks if zoxide is available on the system
+	IsInstalled() bool
+
+	// Query returns the best matching directory for the query keywords
+	Query(query string) (string, error)
+}
+
+// HistoryStore remembers which sessions were opened, oldest first
+type HistoryStore interface {
+	// Record adds an entry for a session that was just opened
+	Record(name string) error
+
+	// Entries returns the history, oldest first
+	Entries() ([]HistoryEntry, error)
+
+	// Prune drops entries whose name keep rejects, returning how many were dropped
+	Prune(keep func(name string) bool) (int, error)
+
+	// Clear removes the whole history
+	Clear() error
+}
+
 // Note on interfaces in Go:
 // 1. You don't explicitly say "this type implements this interface"
 // 2. If a type has all the methods in an interface, it automatically implements it
@@ -78,3 +216,14 @@ type ConfigLoader interface {
 //   }
 //   ... implement other methods ...
 //   // Now MockTmuxClient automatically implements TmuxClient!
+
+// MetadataStore keeps key=value details about sessions, keyed by session name
+// It's the one store behind Session.Metadata, so every feature that attaches
+// something to a session is loaded and saved the same way
+type MetadataStore interface {
+	// Load returns every session's metadata, keyed by session name
+	Load() (map[string]map[string]string, error)
+
+	// Set stores a value under a session's key; an empty value removes the key
+	Set(name, key, value string) error
+}
@@ -1,19 +1,114 @@
 package session
 
 import (
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+// ErrSessionNotFound is returned when a name isn't an active session, a
+// tmuxinator project, or a default session
+var ErrSessionNotFound = errors.New("session not found")
+
+// ErrAmbiguousName is returned when fuzzy matching finds several sessions
+// close to a name and can't tell which one was meant
+var ErrAmbiguousName = errors.New("name matches several sessions")
+
+// ErrServerNotRunning is returned when there's no tmux server at all, as
+// opposed to a server with no sessions
+var ErrServerNotRunning = errors.New("tmux is not running")
+
 // Manager orchestrates session operations using injected dependencies
 // This is the dependency injection pattern - instead of creating its own
 // tmux client, config loader, etc., the Manager receives them
 // This makes testing easy - we can inject mocks instead of real implementations
+//
+// A Manager is safe to use from several goroutines at once (a long-running
+// process can share one between requests). It holds no state of its own
+// besides its settings, which a mutex guards; everything else lives in the
+// injected clients and stores, and those are safe for concurrent use too
+// (the tmuxinator project list is loaded once, and the history and metadata
+// stores lock around each file update). Operations aren't transactions
+// though: two goroutines creating the same session can both see it missing,
+// just as two sess processes can.
 type Manager struct {
 	tmuxClient       TmuxClient
 	tmuxinatorClient TmuxinatorClient
 	configLoader     ConfigLoader
 	platform         string
+
+	// mu guards opts: setters take it for writing, and operations read
+	// the settings through settings(), which copies them under a read lock
+	mu   sync.RWMutex
+	opts options
+}
+
+// options are the optional settings and collaborators set after NewManager
+type options struct {
+	// takeover detaches other clients when attaching from outside tmux
+	takeover bool
+
+	// events receives created/switched/deleted notifications (no-op by default)
+	events EventSink
+
+	// missingDir decides what to do when a default session's directory is missing
+	// confirmer answers the question when the policy is to ask (nil when not interactive)
+	missingDir MissingDirPolicy
+	confirmer  Confirmer
+
+	// resolver is asked for a directory when a name isn't found anywhere (optional)
+	resolver DirectoryResolver
+
+	// zoxide finds directories for "sess z" (optional)
+	zoxide ZoxideClient
+
+	// history records every session the user opens (optional)
+	history HistoryStore
+
+	// fuzzy lets GoToSession and CreateOrSwitch fall back to a close match
+	// for a name that doesn't exist
+	// Matches less similar than fuzzyConfirmBelow are confirmed first
+	fuzzy             bool
+	fuzzyConfirmBelow float64
+
+	// sortMode is the order ListAll returns sessions in, and order is the
+	// saved name order SortByCustom follows (SortByRecent reads the history)
+	sortMode SortMode
+	order    []string
+
+	// metadata fills in Session.Metadata when listing (optional)
+	metadata MetadataStore
+
+	// normalizeNames creates plain sessions under a slugified name (spaces,
+	// dots, and colons replaced) and keeps what was typed as the display name
+	normalizeNames bool
+
+	// skipHooks keeps the on_attach commands of default sessions from running
+	skipHooks bool
+
+	// detached creates new sessions in the background, without switching
+	// or attaching to them, and leaves running ones alone
+	detached bool
+
+	// dryRun leaves everything outside tmux alone: no history, metadata,
+	// or events are written, and missing directories aren't created
+	dryRun bool
+
+	// logger records what sess did and why, for -v (discards everything by default)
+	logger *slog.Logger
+
+	// notices receives messages the user should see, like a name being
+	// changed to one tmux accepts (discarded by default)
+	notices io.Writer
 }
 
 // NewManager creates a new session manager with the given dependencies
@@ -28,6 +123,250 @@ func NewManager(
 		tmuxinatorClient: tmuxinatorClient,
 		configLoader:     configLoader,
 		platform:         platform,
+		opts: options{
+			events:            noopSink{},
+			fuzzyConfirmBelow: 1, // until told otherwise, every fuzzy match is confirmed
+			logger:            slog.New(slog.DiscardHandler),
+			notices:           io.Discard,
+		},
+	}
+}
+
+// noopSink is the default EventSink, it discards every event
+type noopSink struct{}
+
+// Emit does nothing
+func (noopSink) Emit(Event) {}
+
+// configure changes the settings under the write lock
+func (m *Manager) configure(change func(o *options)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	change(&m.opts)
+}
+
+// settings returns a copy of the current settings
+// Operations that use a setting more than once (check it's set, then call
+// it) read it from one copy, so a concurrent setter can't change it midway
+func (m *Manager) settings() options {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.opts
+}
+
+// SetEventSink sets where session events are sent
+// Passing nil restores the default of discarding them
+func (m *Manager) SetEventSink(sink EventSink) {
+	if sink == nil {
+		sink = noopSink{}
+	}
+	m.configure(func(o *options) { o.events = sink })
+}
+
+// SetLogger sets where the manager logs to
+// Passing nil restores the default of discarding everything
+func (m *Manager) SetLogger(logger *slog.Logger) {
+	if logger == nil {
+		logger = slog.New(slog.DiscardHandler)
+	}
+	m.configure(func(o *options) { o.logger = logger })
+}
+
+// SetNotices sets where messages for the user are written
+// Passing nil restores the default of discarding them
+func (m *Manager) SetNotices(w io.Writer) {
+	if w == nil {
+		w = io.Discard
+	}
+	m.configure(func(o *options) { o.notices = w })
+}
+
+// sanitize returns name as tmux accepts it (see SanitizeName), telling the
+// user when that's a different name
+func (m *Manager) sanitize(name string) (string, error) {
+	sanitized, changed := SanitizeName(name)
+	if sanitized == "" {
+		return "", fmt.Errorf("session name can't be empty")
+	}
+	if changed {
+		fmt.Fprintf(m.settings().notices, "tmux doesn't allow the name '%s', using '%s'\n", name, sanitized)
+	}
+	return sanitized, nil
+}
+
+// SetMissingDirPolicy sets what happens when a default session's directory doesn't exist
+func (m *Manager) SetMissingDirPolicy(policy MissingDirPolicy) {
+	m.configure(func(o *options) { o.missingDir = policy })
+}
+
+// SetConfirmer sets how the manager asks the user yes/no questions
+// Leave it unset when there's no one to ask (e.g. not running in a terminal)
+func (m *Manager) SetConfirmer(confirmer Confirmer) {
+	m.configure(func(o *options) { o.confirmer = confirmer })
+}
+
+// SetResolver sets the fallback used to find a directory for unknown names
+func (m *Manager) SetResolver(resolver DirectoryResolver) {
+	m.configure(func(o *options) { o.resolver = resolver })
+}
+
+// SetZoxide sets the zoxide client used by Zoxide
+func (m *Manager) SetZoxide(zoxide ZoxideClient) {
+	m.configure(func(o *options) { o.zoxide = zoxide })
+}
+
+// SetHistory sets where opened sessions are recorded
+func (m *Manager) SetHistory(history HistoryStore) {
+	m.configure(func(o *options) { o.history = history })
+}
+
+// SetSort sets the order ListAll returns sessions in
+// order is the saved name order for SortByCustom, and ignored otherwise
+func (m *Manager) SetSort(mode SortMode, order []string) {
+	// Copy the order so the caller can't change it under a running ListAll
+	order = append([]string(nil), order...)
+	m.configure(func(o *options) {
+		o.sortMode = mode
+		o.order = order
+	})
+}
+
+// SetMetadataStore sets the store that session metadata is loaded from and saved to
+func (m *Manager) SetMetadataStore(store MetadataStore) {
+	m.configure(func(o *options) { o.metadata = store })
+}
+
+// SetSessionMetadata stores a metadata value for a session; an empty value removes it
+func (m *Manager) SetSessionMetadata(name, key, value string) error {
+	opts := m.settings()
+	if opts.metadata == nil {
+		return fmt.Errorf("no metadata store configured")
+	}
+	if opts.dryRun {
+		return nil
+	}
+	return opts.metadata.Set(name, key, value)
+}
+
+// SetNormalizeNames turns name normalization on or off for new sessions
+func (m *Manager) SetNormalizeNames(on bool) {
+	m.configure(func(o *options) { o.normalizeNames = on })
+}
+
+// SetDryRun stops the manager from changing anything itself, for --dry-run
+// The tmux commands are up to the clients' runner; this covers the history,
+// metadata, events, and directories the manager would otherwise write
+func (m *Manager) SetDryRun(on bool) {
+	m.configure(func(o *options) { o.dryRun = on })
+}
+
+// normalize returns the name a new session is created under, and the name
+// to display for it when that differs from what was asked for
+func (m *Manager) normalize(name string) (string, string) {
+	if !m.settings().normalizeNames {
+		return name, ""
+	}
+	slug := slugify(name)
+	if slug == name || slug == "" {
+		return name, ""
+	}
+	return slug, name
+}
+
+// SetFuzzy turns fuzzy matching on or off for GoToSession and CreateOrSwitch
+func (m *Manager) SetFuzzy(on bool) {
+	m.configure(func(o *options) { o.fuzzy = on })
+}
+
+// SetFuzzyConfirmThreshold sets the similarity (0 to 1) below which a fuzzy
+// match needs confirming; 0 never asks and 1 always does
+func (m *Manager) SetFuzzyConfirmThreshold(threshold float64) {
+	m.configure(func(o *options) { o.fuzzyConfirmBelow = threshold })
+}
+
+// emit sends an event for the named session to the event sink
+func (m *Manager) emit(eventType EventType, name string) {
+	if m.settings().dryRun {
+		return
+	}
+	m.settings().events.Emit(Event{Type: eventType, Session: name, Time: time.Now()})
+}
+
+// opened is called after the user lands in a session, by creating it or switching to it
+// Like events, the history is best-effort: failing to write it doesn't fail the switch
+func (m *Manager) opened(eventType EventType, name string) {
+	m.emit(eventType, name)
+
+	opts := m.settings()
+	if opts.history != nil && !opts.dryRun {
+		if err := opts.history.Record(name); err != nil {
+			opts.logger.Warn("failed to record session in history", "session", name, "error", err)
+		}
+	}
+}
+
+// SetTakeover controls what happens when attaching (from outside tmux) to a
+// session that another client already has open: with takeover on, the other
+// clients are detached (tmux attach -d)
+func (m *Manager) SetTakeover(on bool) {
+	m.configure(func(o *options) { o.takeover = on })
+}
+
+// SetDetached makes CreateOrSwitch and NewSession create sessions in the
+// background instead of switching to them, even from outside tmux
+func (m *Manager) SetDetached(on bool) {
+	m.configure(func(o *options) { o.detached = on })
+}
+
+// createPlain creates a plain session and switches to it, or only creates it when detached
+func (m *Manager) createPlain(sess Session, detached bool) error {
+	if detached {
+		return m.tmuxClient.CreateDetachedSession(sess)
+	}
+	return m.tmuxClient.CreateSession(sess)
+}
+
+// startProject starts a tmuxinator project and switches to it, or only starts it when detached
+// It returns the name of the session the project runs in
+func (m *Manager) startProject(name string, detached bool) (string, error) {
+	if detached {
+		return m.tmuxinatorClient.StartProjectDetached(name)
+	}
+	return m.tmuxinatorClient.StartProject(name, m.tmuxClient.IsInsideTmux())
+}
+
+// created is opened for a session CreateOrSwitch or NewSession just made
+// A detached session wasn't opened by anyone, so it stays out of the history
+func (m *Manager) created(name string) {
+	if m.settings().detached {
+		m.emit(EventCreated, name)
+		return
+	}
+	m.opened(EventCreated, name)
+}
+
+// SetSkipHooks turns a default session's on_attach command off (or back on)
+func (m *Manager) SetSkipHooks(on bool) {
+	m.configure(func(o *options) { o.skipHooks = on })
+}
+
+// runOnAttach types a running default session's on_attach command into its active pane
+// Like the history, it's best-effort: a failed hook doesn't fail the switch
+func (m *Manager) runOnAttach(name string) {
+	opts := m.settings()
+	if opts.skipHooks {
+		return
+	}
+	config, err := m.configLoader.GetSessionConfig(name, m.platform)
+	if err != nil || config.OnAttach == "" {
+		return
+	}
+
+	opts.logger.Info("running on_attach hook", "session", name, "command", config.OnAttach)
+	// "name:" is the session's current window, and tmux sends to its active
+	// pane; "=" keeps tmux from taking another session whose name starts with name
+	if err := m.tmuxClient.SendKeys("="+name+":", config.OnAttach); err != nil {
+		opts.logger.Warn("failed to run on_attach hook", "session", name, "error", err)
 	}
 }
 
@@ -36,19 +375,57 @@ func NewManager(
 // - Active tmux sessions
 // - Tmuxinator projects (not already running)
 // - Default sessions from config (not already running)
-func (m *Manager) ListAll() ([]Session, error) {
+//
+// A source that can't be read doesn't fail the list: the others are still
+// returned, along with a warning for each one that failed. A missing config
+// file isn't a warning, since most users start without one
+func (m *Manager) ListAll() ([]Session, []ListWarning, error) {
+	// Each source runs a command (or reads a file), so ask all three at once
+	// Results land in their own variables and are merged in a fixed order
+	// below, so the precedence doesn't depend on which one finishes first
+	var (
+		wg             sync.WaitGroup
+		tmuxSessions   []Session
+		tmuxErr        error
+		projects       []string
+		projectsErr    error
+		defaultConfigs []SessionConfig
+		configErr      error
+	)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		tmuxSessions, tmuxErr = m.tmuxClient.ListSessions()
+	}()
+	go func() {
+		defer wg.Done()
+		// Only if tmuxinator is installed
+		if m.tmuxinatorClient.IsInstalled() {
+			projects, projectsErr = m.tmuxinatorClient.ListProjects()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		defaultConfigs, configErr = m.configLoader.LoadDefaultSessions(m.platform)
+	}()
+	wg.Wait()
+
+	logger := m.settings().logger
+	logger.Debug("listed sessions", "source", "tmux", "count", len(tmuxSessions), "error", tmuxErr)
+	logger.Debug("listed sessions", "source", "tmuxinator", "count", len(projects), "error", projectsErr)
+	logger.Debug("listed sessions", "source", "config", "count", len(defaultConfigs), "error", configErr)
+
 	// Start with a slice to hold all sessions
 	sessions := []Session{}
+	var warnings []ListWarning
 
-	// 1. Get active tmux sessions
-	tmuxSessions, err := m.tmuxClient.ListSessions()
-	if err != nil {
-		// If we can't list tmux sessions, that's not fatal
-		// Just log it and continue (we'll add logging later)
-		// For now, we'll just ignore the error
-	} else {
-		sessions = append(sessions, tmuxSessions...)
+	// 1. Active tmux sessions
+	// If we can't list tmux sessions, that's not fatal - warn and carry on
+	if tmuxErr != nil {
+		warnings = append(warnings, ListWarning{Source: "tmux", Err: tmuxErr})
 	}
+	sessions = append(sessions, tmuxSessions...)
 
 	// Build a map of session names we've already added
 	// This prevents duplicates
@@ -58,28 +435,31 @@ func (m *Manager) ListAll() ([]Session, error) {
 		existingNames[sess.Name] = true
 	}
 
-	// 2. Get tmuxinator projects (only if tmuxinator is installed)
-	if m.tmuxinatorClient.IsInstalled() {
-		projects, err := m.tmuxinatorClient.ListProjects()
-		if err == nil {
-			for _, projectName := range projects {
-				// Only add if not already running as a tmux session
-				if !existingNames[projectName] {
-					sessions = append(sessions, Session{
-						Name:     projectName,
-						Type:     SessionTypeTmuxinator,
-						IsActive: false,
-					})
-					existingNames[projectName] = true
-				}
-			}
+	// 2. Tmuxinator projects
+	if projectsErr != nil {
+		warnings = append(warnings, ListWarning{Source: "tmuxinator", Err: projectsErr})
+	}
+	for _, projectName := range projects {
+		// Only add if not already running as a tmux session
+		if !existingNames[projectName] {
+			sessions = append(sessions, Session{
+				Name:     projectName,
+				Type:     SessionTypeTmuxinator,
+				IsActive: false,
+			})
+			existingNames[projectName] = true
 		}
 	}
 
-	// 3. Get default sessions from config
-	defaultSessions, err := m.configLoader.LoadDefaultSessions(m.platform)
-	if err == nil {
-		for _, config := range defaultSessions {
+	// 3. Default sessions from config
+	if configErr != nil && !errors.Is(configErr, fs.ErrNotExist) {
+		warnings = append(warnings, ListWarning{Source: "config", Err: configErr})
+	}
+	if configErr == nil {
+		// A running session keeps the tags of the config it was started from
+		configTags(sessions, defaultConfigs)
+
+		for _, config := range defaultConfigs {
 			// Only add if not already in the list
 			if !existingNames[config.Name] {
 				sessions = append(sessions, Session{
@@ -87,6 +467,7 @@ func (m *Manager) ListAll() ([]Session, error) {
 					Type:        SessionTypeDefault,
 					Description: config.Description,
 					Directory:   config.Directory,
+					Tags:        config.Tags,
 					IsActive:    false,
 				})
 				existingNames[config.Name] = true
@@ -94,156 +475,1543 @@ func (m *Manager) ListAll() ([]Session, error) {
 		}
 	}
 
-	// Sort sessions by name for consistent ordering
-	// sort.Slice() sorts a slice using a custom comparison function
-	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[i].Name < sessions[j].Name
-	})
+	// 4. Attach metadata, loaded once for the whole list
+	if err := m.enrich(sessions); err != nil {
+		warnings = append(warnings, ListWarning{Source: "metadata", Err: err})
+	}
+
+	// Sort sessions for consistent ordering (by name unless another sort is set)
+	opts := m.settings()
+	var lastOpened map[string]time.Time
+	if opts.sortMode == SortByRecent {
+		var err error
+		if lastOpened, err = m.lastOpened(); err != nil {
+			warnings = append(warnings, ListWarning{Source: "history", Err: err})
+		}
+	}
+	sortSessions(sessions, opts.sortMode, opts.order, lastOpened)
 
-	return sessions, nil
+	return sessions, warnings, nil
 }
 
-// CreateOrSwitch creates a new session or switches to an existing one
-// This is the main operation when a user selects a session
-func (m *Manager) CreateOrSwitch(name string) error {
-	// First, check if it's already an active tmux session
-	exists, err := m.tmuxClient.SessionExists(name)
-	if err != nil {
-		return fmt.Errorf("failed to check if session exists: %w", err)
+// configTags gives each session the tags of the config entry with its name
+func configTags(sessions []Session, configs []SessionConfig) {
+	for _, config := range configs {
+		for i := range sessions {
+			if sessions[i].Name == config.Name {
+				sessions[i].Tags = config.Tags
+			}
+		}
 	}
+}
 
-	if exists {
-		// Session exists, just switch to it
-		inTmux := m.tmuxClient.IsInsideTmux()
-		return m.tmuxClient.SwitchToSession(name, inTmux)
+// AttachTags gives sessions that weren't listed by ListAll (those from
+// every tmux server, say) the tags and metadata ListAll would have: their
+// config entry's tags and whatever the metadata store has for them
+// Like ListAll, a source that can't be read is a warning, not an error
+func (m *Manager) AttachTags(sessions []Session) []ListWarning {
+	var warnings []ListWarning
+
+	configs, err := m.configLoader.LoadDefaultSessions(m.platform)
+	switch {
+	case err == nil:
+		configTags(sessions, configs)
+	case !errors.Is(err, fs.ErrNotExist):
+		warnings = append(warnings, ListWarning{Source: "config", Err: err})
 	}
 
-	// Not an active session, check if it's a tmuxinator project
-	if m.tmuxinatorClient.IsInstalled() {
-		isProject, err := m.tmuxinatorClient.ProjectExists(name)
-		if err == nil && isProject {
-			// It's a tmuxinator project, start it
-			inTmux := m.tmuxClient.IsInsideTmux()
-			return m.tmuxinatorClient.StartProject(name, inTmux)
-		}
+	if err := m.enrich(sessions); err != nil {
+		warnings = append(warnings, ListWarning{Source: "metadata", Err: err})
+	}
+	return warnings
+}
+
+// lastOpened returns when each session in the history was last opened
+// Without a history store, nothing has been opened as far as sess knows
+func (m *Manager) lastOpened() (map[string]time.Time, error) {
+	history := m.settings().history
+	if history == nil {
+		return nil, nil
 	}
 
-	// Check if it's a default session from config
-	config, err := m.configLoader.GetSessionConfig(name, m.platform)
-	if err == nil {
-		// It's a default session, create it based on config
-		return m.createDefaultSession(config)
+	entries, err := history.Entries()
+	if err != nil {
+		return nil, err
 	}
 
-	// Not found in any source, create a new basic tmux session
-	return m.tmuxClient.CreateSession(Session{
-		Name: name,
-		Type: SessionTypeTmux,
-	})
+	// Entries are oldest first, so later ones overwrite earlier ones
+	last := make(map[string]time.Time, len(entries))
+	for _, entry := range entries {
+		last[entry.Name] = entry.Time
+	}
+	return last, nil
 }
 
-// createDefaultSession creates a session from a YAML config
-func (m *Manager) createDefaultSession(config *SessionConfig) error {
-	// If the config specifies a tmuxinator project, use that
-	if config.TmuxinatorProject != "" && m.tmuxinatorClient.IsInstalled() {
-		inTmux := m.tmuxClient.IsInsideTmux()
-		return m.tmuxinatorClient.StartProject(config.TmuxinatorProject, inTmux)
+// enrich fills in each session's Metadata from the metadata store
+// Like the other sources, a store that can't be read just means no metadata,
+// and the error is returned for ListAll to warn about
+func (m *Manager) enrich(sessions []Session) error {
+	store := m.settings().metadata
+	if store == nil {
+		return nil
 	}
 
-	// Otherwise, create a simple session with the specified directory
-	return m.tmuxClient.CreateSession(Session{
-		Name:      config.Name,
-		Type:      SessionTypeTmux,
-		Directory: config.Directory,
-	})
-}
+	metadata, err := store.Load()
+	if err != nil {
+		return err
+	}
+
+	for i := range sessions {
+		if values, ok := metadata[sessions[i].Name]; ok {
+			sessions[i].Metadata = values
+			sessions[i].Tags = mergeTags(sessions[i].Tags, values[MetaTags])
+		}
+	}
 
-// SwitchToLast switches to the previously active session
-func (m *Manager) SwitchToLast() error {
-	return m.tmuxClient.SwitchToLastSession()
+	return nil
 }
 
-// SessionExists checks if a session exists in any source (tmux, tmuxinator, or default config)
-func (m *Manager) SessionExists(name string) (bool, error) {
-	// Check if it's an active tmux session
-	exists, err := m.tmuxClient.SessionExists(name)
+// ListFiltered returns ListAll's sessions minus the hidden ones, and its warnings
+// Sessions are hidden either by name (opts.Hidden) or by hidden: true in their config
+// With opts.Tag, only the sessions with that tag are returned
+func (m *Manager) ListFiltered(opts ListOptions) ([]Session, []ListWarning, error) {
+	sessions, warnings, err := m.ListAll()
 	if err != nil {
-		return false, err
+		return sessions, warnings, err
 	}
-	if exists {
-		return true, nil
+	if opts.Tag != "" {
+		sessions = FilterByTag(sessions, opts.Tag)
+	}
+	sessions, err = FilterByName(sessions, opts.Pattern, opts.Match)
+	if err != nil {
+		return nil, warnings, err
+	}
+	if opts.IncludeHidden {
+		return sessions, warnings, nil
 	}
 
-	// Check if it's a tmuxinator project
-	if m.tmuxinatorClient.IsInstalled() {
-		isProject, err := m.tmuxinatorClient.ProjectExists(name)
-		if err == nil && isProject {
-			return true, nil
-		}
+	hidden := make(map[string]bool)
+	for _, name := range opts.Hidden {
+		hidden[name] = true
 	}
 
-	// Check if it's a default session from config
-	_, err = m.configLoader.GetSessionConfig(name, m.platform)
+	// A config marked hidden hides the session whether or not it's running
+	configs, err := m.configLoader.LoadDefaultSessions(m.platform)
 	if err == nil {
-		return true, nil
+		for _, config := range configs {
+			if config.Hidden {
+				hidden[config.Name] = true
+			}
+		}
+	}
+
+	visible := make([]Session, 0, len(sessions))
+	for _, sess := range sessions {
+		if !hidden[sess.Name] {
+			visible = append(visible, sess)
+		}
 	}
 
-	return false, nil
+	return visible, warnings, nil
 }
 
-// GoToSession opens a session if it exists, returns error if it doesn't
-// This is different from CreateOrSwitch which creates a new session if not found
-func (m *Manager) GoToSession(name string) error {
-	exists, err := m.SessionExists(name)
+// CreateOrSwitch creates a new session or switches to an existing one
+// This is the main operation when a user selects a session
+// With fuzzy matching on, a name no source knows that's close to exactly one
+// session switches to that session instead of creating an empty one
+func (m *Manager) CreateOrSwitch(name string) error {
+	// A running session can't have '.' or ':' in its name, so look for it
+	// under the name tmux would have given it. The other sources know
+	// sessions by the name as typed, which only changes (and says so) when
+	// a plain session is created. Normalizing names already takes care of
+	// what tmux won't accept.
+	running := name
+	if !m.settings().normalizeNames {
+		sanitized, _ := SanitizeName(name)
+		if sanitized == "" {
+			return fmt.Errorf("session name can't be empty")
+		}
+		name = strings.TrimSpace(name)
+		running = sanitized
+	}
+
+	// First, check if it's already an active tmux session
+	exists, err := m.tmuxClient.SessionExists(running)
 	if err != nil {
-		return err
+		return fmt.Errorf("failed to check if session exists: %w", err)
 	}
-	if !exists {
-		return fmt.Errorf("session '%s' not found", name)
+	if exists {
+		name = running
 	}
 
-	return m.CreateOrSwitch(name)
-}
-
-// DeleteSession deletes an active tmux session
-func (m *Manager) DeleteSession(name string) error {
-	return m.tmuxClient.DeleteSession(name)
-}
+	// A session created from a messy name is running under its normalized one
+	if slug, display := m.normalize(name); !exists && display != "" {
+		exists, err = m.tmuxClient.SessionExists(slug)
+		if err != nil {
+			return fmt.Errorf("failed to check if session exists: %w", err)
+		}
+		if exists {
+			name = slug
+		}
+	}
 
-// GetSessionInfo returns detailed information about a session
-// This is useful for displaying additional context in the UI
-func (m *Manager) GetSessionInfo(name string) (string, error) {
-	// Check if it's an active session
-	exists, err := m.tmuxClient.SessionExists(name)
-	if err != nil {
-		return "", err
+	if exists && m.settings().detached {
+		// It's already running in the background, which is all that was asked
+		return nil
 	}
 
 	if exists {
-		// Get the active sessions and find this one
-		sessions, err := m.tmuxClient.ListSessions()
-		if err != nil {
-			return "", err
+		// Session exists, just switch to it
+		// Attaching from outside tmux only returns once the user detaches,
+		// so there the hook has to go in first
+		inTmux := m.tmuxClient.IsInsideTmux()
+		if !inTmux {
+			m.runOnAttach(name)
+		}
+		if err := m.switchToExisting(name); err != nil {
+			return err
 		}
+		if inTmux {
+			m.runOnAttach(name)
+		}
+		m.opened(EventSwitched, name)
+		return nil
+	}
 
-		for _, sess := range sessions {
-			if sess.Name == name {
-				return sess.DisplayInfo(), nil
-			}
+	if m.settings().fuzzy {
+		match, err := m.fuzzyFallback(name)
+		if err != nil {
+			return err
+		}
+		if match != "" {
+			return m.CreateOrSwitch(match)
 		}
 	}
 
+	created, err := m.create(name)
+	if err != nil {
+		return err
+	}
+	m.created(created)
+	return nil
+}
+
+// create starts a session that isn't running yet, from whichever source knows about it
+// It returns the name the session was created under, which normalization may have changed
+func (m *Manager) create(name string) (string, error) {
+	logger := m.settings().logger
+
 	// Check if it's a tmuxinator project
 	if m.tmuxinatorClient.IsInstalled() {
 		isProject, err := m.tmuxinatorClient.ProjectExists(name)
+		if err != nil {
+			logger.Warn("failed to list tmuxinator projects", "error", err)
+		}
 		if err == nil && isProject {
-			return "tmuxinator project", nil
+			// It's a tmuxinator project, start it
+			logger.Info("starting tmuxinator project", "session", name)
+			return m.startProject(name, m.settings().detached)
 		}
 	}
 
-	// Check if it's a default session
+	// Check if it's a default session from config
 	config, err := m.configLoader.GetSessionConfig(name, m.platform)
 	if err == nil {
+		// It's a default session, create it based on config
+		// A name tmux won't take is created under the one it would use
+		// (config validate warns about those)
+		sessionName, _ := SanitizeName(name)
+		config.Name = sessionName
+		logger.Info("creating default session", "session", sessionName, "directory", config.Directory)
+		return sessionName, m.createDefaultSession(config, m.settings().detached)
+	}
+	// Usually just "not in the config", but a broken file looks the same from here
+	logger.Info("not a default session", "session", name, "error", err)
+
+	// Not found in any source, give the resolver a chance to place it
+	directory := ""
+	if resolver := m.settings().resolver; resolver != nil {
+		resolved, found, err := resolver.ResolveDirectory(name)
+		if err != nil {
+			return "", fmt.Errorf("resolver failed for %q: %w", name, err)
+		}
+		if found {
+			directory = resolved
+		}
+	}
+
+	// Create a new basic tmux session
+	if !m.settings().normalizeNames {
+		sanitized, err := m.sanitize(name)
+		if err != nil {
+			return "", err
+		}
+		name = sanitized
+	}
+	slug, display := m.normalize(name)
+	logger.Info("creating session", "session", slug, "directory", directory)
+	return slug, m.createPlain(Session{
+		Name:        slug,
+		DisplayName: display,
+		Type:        SessionTypeTmux,
+		Directory:   directory,
+	}, m.settings().detached)
+}
+
+// switchToExisting switches to (or attaches to) an active session
+func (m *Manager) switchToExisting(name string) error {
+	inTmux := m.tmuxClient.IsInsideTmux()
+	if inTmux {
+		return m.tmuxClient.SwitchToSession(name, true)
+	}
+
+	if m.settings().takeover {
+		return m.tmuxClient.AttachToSession(name, true)
+	}
+
+	err := m.tmuxClient.SwitchToSession(name, false)
+	if err == nil {
+		return nil
+	}
+
+	// If another client has the session open, point the user at --takeover
+	if clients, countErr := m.tmuxClient.AttachedClients(name); countErr == nil && clients > 0 {
+		return fmt.Errorf("failed to attach to session '%s', it is open in another client (use --takeover to detach it): %w", name, err)
+	}
+
+	return err
+}
+
+// BringUpDefaults starts every default session that isn't running yet, in
+// the background, with its windows, options, and commands
+// A session that fails to start doesn't stop the rest; the failures are
+// returned together after trying them all
+func (m *Manager) BringUpDefaults(opts UpOptions) (UpResult, error) {
+	var result UpResult
+
+	configs, err := m.configLoader.LoadDefaultSessions(m.platform)
+	if err != nil {
+		return result, err
+	}
+
+	known := make(map[string]bool, len(configs))
+	for _, config := range configs {
+		known[config.Name] = true
+	}
+	for _, name := range opts.Only {
+		if !known[name] {
+			return result, fmt.Errorf("no default session named '%s'", name)
+		}
+	}
+
+	var failures []error
+	for i := range configs {
+		config := &configs[i]
+		if len(opts.Only) > 0 && !slices.Contains(opts.Only, config.Name) {
+			continue
+		}
+		if opts.Tag != "" && !config.HasTag(opts.Tag) {
+			continue
+		}
+
+		running, err := m.tmuxClient.SessionExists(config.Name)
+		if err != nil {
+			return result, fmt.Errorf("failed to check if session exists: %w", err)
+		}
+		if running {
+			result.Skipped = append(result.Skipped, config.Name)
+			continue
+		}
+
+		if err := m.createDefaultSession(config, true); err != nil {
+			failures = append(failures, fmt.Errorf("%s: %w", config.Name, err))
+			continue
+		}
+		m.emit(EventCreated, config.Name)
+		result.Created = append(result.Created, config.Name)
+	}
+
+	return result, errors.Join(failures...)
+}
+
+// createDefaultSession creates a session from a YAML config and switches to
+// it, or only creates it when detached
+func (m *Manager) createDefaultSession(config *SessionConfig, detached bool) error {
+	// If the config specifies a tmuxinator project, use that
+	if config.TmuxinatorProject != "" && m.tmuxinatorClient.IsInstalled() {
+		_, err := m.startProject(config.TmuxinatorProject, detached)
+		return err
+	}
+
+	// The session's own directory wins over the project's
+	directory := config.Directory
+	if directory == "" && config.ResolvedProject != nil {
+		directory = config.ResolvedProject.Directory
+	}
+	if err := m.ensureDirectory(directory); err != nil {
+		return err
+	}
+
+	// A project layout, session options, startup commands, or windows need
+	// setting up before the user sees the session
+	if config.ResolvedProject != nil || len(config.Options) > 0 || len(config.Commands) > 0 ||
+		config.WindowName != "" || len(config.Windows) > 0 {
+		return m.buildSession(config, detached)
+	}
+
+	// Otherwise, create a simple session with the specified directory
+	return m.createPlain(Session{
+		Name:      config.Name,
+		Type:      SessionTypeTmux,
+		Directory: config.Directory,
+	}, detached)
+}
+
+// ensureDirectory makes sure a session's starting directory exists before
+// tmux gets it, following the missing directory policy
+// tmux silently falls back to the home directory otherwise, which is easy to miss
+func (m *Manager) ensureDirectory(dir string) error {
+	opts := m.settings()
+	if dir == "" || opts.missingDir == MissingDirIgnore {
+		return nil
+	}
+
+	info, err := os.Stat(dir)
+	if err == nil {
+		if !info.IsDir() {
+			return fmt.Errorf("%s is not a directory", dir)
+		}
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	create := false
+	switch opts.missingDir {
+	case MissingDirCreate:
+		create = true
+	case MissingDirAsk:
+		if opts.confirmer == nil {
+			return fmt.Errorf("directory %s doesn't exist (use --create-missing-dir to create it)", dir)
+		}
+		create, err = opts.confirmer.Confirm(fmt.Sprintf("Directory %s doesn't exist, create it?", dir))
+		if err != nil {
+			return err
+		}
+	}
+
+	if !create {
+		return fmt.Errorf("directory %s doesn't exist, not starting the session", dir)
+	}
+
+	if opts.dryRun {
+		fmt.Fprintf(opts.notices, "mkdir -p %s\n", dir)
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+	return nil
+}
+
+// buildSession creates a session detached, applies its options and project
+// layout, then switches to it (unless sessions are created detached) - so
+// everything is in place before the user sees the session
+func (m *Manager) buildSession(config *SessionConfig, detached bool) error {
+	name := config.Name
+	project := config.ResolvedProject
+	if project == nil {
+		project = &Project{}
+	}
+
+	// The session's own directory wins over the project's
+	directory := config.Directory
+	if directory == "" {
+		directory = project.Directory
+	}
+
+	if err := m.tmuxClient.CreateDetachedSession(Session{
+		Name:      name,
+		Type:      SessionTypeTmux,
+		Directory: directory,
+	}); err != nil {
+		return err
+	}
+	if config.WindowName != "" {
+		// The new session's only window is its first, whatever base-index is
+		if err := m.tmuxClient.RenameWindow("="+name+":", config.WindowName); err != nil {
+			return err
+		}
+	}
+
+	if err := m.applyOptions(name, config.Options); err != nil {
+		return err
+	}
+
+	// The session target resolves to its current window, which is still the first one
+	// The project's commands set up the layout, then the session's own run
+	commands := append(append([]string{}, project.Commands...), config.Commands...)
+	for _, command := range commands {
+		if err := m.tmuxClient.SendKeys(name, command); err != nil {
+			return fmt.Errorf("failed to run %q: %w", command, err)
+		}
+	}
+
+	windows := append(append([]WindowConfig{}, project.Windows...), config.Windows...)
+	for _, window := range windows {
+		windowDir := window.Directory
+		if windowDir == "" {
+			windowDir = directory
+		}
+
+		if err := m.tmuxClient.NewWindow(name, window.Name, windowDir); err != nil {
+			return err
+		}
+
+		// {end} is tmux's name for the last window - the one just created
+		for _, command := range window.Commands {
+			if err := m.tmuxClient.SendKeys(name+":{end}", command); err != nil {
+				return fmt.Errorf("failed to run %q in window %s: %w", command, window.Name, err)
+			}
+		}
+	}
+
+	if detached {
+		return nil
+	}
+	inTmux := m.tmuxClient.IsInsideTmux()
+	return m.tmuxClient.SwitchToSession(name, inTmux)
+}
+
+// applyOptions sets tmux options on a session
+// Keys are applied in sorted order so the result doesn't depend on map iteration
+func (m *Manager) applyOptions(name string, options map[string]string) error {
+	keys := make([]string, 0, len(options))
+	for key := range options {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := m.tmuxClient.SetOption(name, key, options[key]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SwitchToLast switches to the session opened before the current one, or
+// with back > 1, to one further back
+// The sessions come from sess's history, so unlike tmux's own last session
+// it remembers more than one and works outside tmux. Inside tmux, a history
+// with nothing to go back to falls back to tmux's switch-client -l.
+func (m *Manager) SwitchToLast(back int) error {
+	if back < 1 {
+		return fmt.Errorf("can't go back %d sessions", back)
+	}
+
+	previous, err := m.previousRunning()
+	if err != nil {
+		return err
+	}
+
+	if back > len(previous) {
+		switch {
+		case len(previous) == 0 && back == 1 && m.tmuxClient.IsInsideTmux():
+			return m.tmuxClient.SwitchToLastSession()
+		case len(previous) == 0:
+			return fmt.Errorf("no running session in the history to go back to")
+		default:
+			return fmt.Errorf("can't go back %d sessions, the history only has %d", back, len(previous))
+		}
+	}
+
+	name := previous[back-1]
+	if err := m.switchToExisting(name); err != nil {
+		return err
+	}
+	m.opened(EventSwitched, name)
+	return nil
+}
+
+// Attach attaches this terminal to a running session with attach-session,
+// never switch-client, so it works even while the session is open elsewhere
+// With detachOthers, the session's other clients are detached
+func (m *Manager) Attach(name string, detachOthers bool) error {
+	// tmux refuses to attach inside one of its own clients
+	if m.tmuxClient.IsInsideTmux() {
+		return fmt.Errorf("already inside tmux, use 'sess %s' to switch to it", name)
+	}
+
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s is not running", ErrSessionNotFound, name)
+	}
+
+	if err := m.tmuxClient.AttachToSession(name, detachOthers); err != nil {
+		return err
+	}
+	m.opened(EventSwitched, name)
+	return nil
+}
+
+// Detach detaches the current client from its session, leaving the session running
+// Only a client inside tmux can be detached, so outside it this says so
+// instead of letting tmux fail with "no current client"
+func (m *Manager) Detach() error {
+	if !m.tmuxClient.IsInsideTmux() {
+		return fmt.Errorf("not inside tmux, so there's no client to detach")
+	}
+	return m.tmuxClient.DetachSession()
+}
+
+// previousRunning returns the running sessions in the history, most recent
+// first and without repeats, leaving out the one this client is in
+// Entries for sessions that don't exist anywhere anymore are pruned on the
+// way, so they don't pile up in front of the ones that do
+func (m *Manager) previousRunning() ([]string, error) {
+	entries, err := m.History()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := m.tmuxClient.ListSessions()
+	if err != nil {
+		return nil, err
+	}
+	running := make(map[string]bool, len(sessions))
+	for _, sess := range sessions {
+		running[sess.Name] = true
+	}
+
+	// Outside tmux no session is current, so the most recent one counts
+	current := ""
+	if m.tmuxClient.IsInsideTmux() {
+		current, _ = m.tmuxClient.CurrentSession()
+	}
+
+	var previous []string
+	gone := make(map[string]bool)
+	for _, name := range previousUnique(entries, 0) {
+		switch {
+		case name == current:
+		case running[name]:
+			previous = append(previous, name)
+		default:
+			// A stopped default session or tmuxinator project is still worth remembering
+			if exists, err := m.SessionExists(name); err == nil && !exists {
+				gone[name] = true
+			}
+		}
+	}
+
+	if len(gone) > 0 && !m.settings().dryRun {
+		if _, err := m.settings().history.Prune(func(name string) bool { return !gone[name] }); err != nil {
+			m.settings().logger.Warn("failed to prune the history", "error", err)
+		}
+	}
+
+	return previous, nil
+}
+
+// SessionExists checks if a session exists in any source (tmux, tmuxinator, or default config)
+func (m *Manager) SessionExists(name string) (bool, error) {
+	_, exists, err := m.ResolveSession(name)
+	return exists, err
+}
+
+// ResolveSession reports which source a name belongs to, in the order sess
+// opens them: an active tmux session, then a tmuxinator project, then a
+// default session from config
+// A name in several sources gets the first one's type
+func (m *Manager) ResolveSession(name string) (SessionType, bool, error) {
+	// Check if it's an active tmux session
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return "", false, err
+	}
+	if exists {
+		return SessionTypeTmux, true, nil
+	}
+
+	// Check if it's a tmuxinator project
+	if m.tmuxinatorClient.IsInstalled() {
+		isProject, err := m.tmuxinatorClient.ProjectExists(name)
+		if err == nil && isProject {
+			return SessionTypeTmuxinator, true, nil
+		}
+	}
+
+	// Check if it's a default session from config
+	_, err = m.configLoader.GetSessionConfig(name, m.platform)
+	if err == nil {
+		return SessionTypeDefault, true, nil
+	}
+
+	return "", false, nil
+}
+
+// CreateForDirectory switches to the named session, creating it rooted at dir if it isn't running
+// Unlike CreateOrSwitch, the directory is already known, so the other sources aren't consulted
+func (m *Manager) CreateForDirectory(name, dir string) error {
+	name, display := m.normalize(name)
+
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+
+	if exists {
+		if err := m.switchToExisting(name); err != nil {
+			return err
+		}
+		m.opened(EventSwitched, name)
+		return nil
+	}
+
+	if err := m.tmuxClient.CreateSession(Session{Name: name, DisplayName: display, Type: SessionTypeTmux, Directory: dir}); err != nil {
+		return err
+	}
+	m.opened(EventCreated, name)
+	return nil
+}
+
+// zoxideInstallHint says where to get zoxide when "sess z" runs without it
+const zoxideInstallHint = "zoxide isn't installed (see https://github.com/ajeetdsouza/zoxide), opening '%s' as a session name\n"
+
+// Zoxide opens a session for the directory zoxide matches for query
+// The session is named after the directory. Without zoxide installed, the
+// query is treated as a plain session name instead, after a notice saying
+// where to get zoxide
+func (m *Manager) Zoxide(query string) error {
+	opts := m.settings()
+	zoxide := opts.zoxide
+	if zoxide == nil || !zoxide.IsInstalled() {
+		fmt.Fprintf(opts.notices, zoxideInstallHint, query)
+		return m.CreateOrSwitch(query)
+	}
+
+	dir, err := zoxide.Query(query)
+	if err != nil {
+		return err
+	}
+
+	return m.OpenDirectory(dir)
+}
+
+// OpenDirectory switches to the session named after dir, creating it rooted
+// there if it isn't running
+// The name is the directory's basename (see SessionNameForDirectory), except
+// that the home directory is "home" and the filesystem root is "root", since
+// a username or "/" would make a confusing session name
+func (m *Manager) OpenDirectory(dir string) error {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", dir, err)
+	}
+
+	name := SessionNameForDirectory(dir)
+	if home, err := os.UserHomeDir(); err == nil && dir == filepath.Clean(home) {
+		name = "home"
+	} else if dir == filepath.Dir(dir) {
+		name = "root"
+	}
+	if name == "" {
+		return fmt.Errorf("can't name a session after %s", dir)
+	}
+
+	return m.CreateForDirectory(name, dir)
+}
+
+// GoToSession opens a session if it exists, returns error if it doesn't
+// This is different from CreateOrSwitch which creates a new session if not found
+func (m *Manager) GoToSession(name string) error {
+	exists, err := m.SessionExists(name)
+	if err != nil {
+		return err
+	}
+	if !exists && m.settings().fuzzy {
+		match, err := m.fuzzyMatch(name)
+		// Several matches end up in the picker, where the user can choose
+		if err != nil && !errors.Is(err, ErrAmbiguousName) {
+			return err
+		}
+		if match != "" {
+			return m.CreateOrSwitch(match)
+		}
+	}
+	if !exists {
+		return fmt.Errorf("%w: %s", ErrSessionNotFound, name)
+	}
+
+	return m.CreateOrSwitch(name)
+}
+
+// fuzzyFallback is fuzzyMatch for a name CreateOrSwitch is about to create
+// A tmuxinator project or default session isn't a typo, so it's created as is
+func (m *Manager) fuzzyFallback(name string) (string, error) {
+	known, err := m.SessionExists(name)
+	if err != nil || known {
+		return "", err
+	}
+	return m.fuzzyMatch(name)
+}
+
+// fuzzyMatch returns the one known session name close to name, or "" if
+// there is none (or the user turned down the suggestion)
+// Several close names return ErrAmbiguousName listing them
+func (m *Manager) fuzzyMatch(name string) (string, error) {
+	// A source that can't be read has no names to match, so carry on without it
+	sessions, warnings, err := m.ListAll()
+	if err != nil {
+		return "", err
+	}
+	for _, warning := range warnings {
+		m.settings().logger.Warn("fuzzy matching without a session source", "source", warning.Source, "error", warning.Err)
+	}
+
+	names := make([]string, len(sessions))
+	for i, sess := range sessions {
+		names[i] = sess.Name
+	}
+
+	matches := FuzzyMatches(name, names)
+	if len(matches) == 0 {
+		return "", nil
+	}
+	if len(matches) > 1 {
+		return "", fmt.Errorf("%w: '%s' could be %s", ErrAmbiguousName, name, strings.Join(matches, ", "))
+	}
+	match := matches[0]
+
+	// Close enough that it's clearly a typo, go straight there
+	if !m.needsFuzzyConfirm(name, match) {
+		return match, nil
+	}
+
+	// Switching somewhere quite different from what was typed (say "prod"
+	// to "production-db") shouldn't happen by surprise
+	confirmer := m.settings().confirmer
+	if confirmer == nil {
+		return "", nil
+	}
+	ok, err := confirmer.Confirm(fmt.Sprintf("Did you mean '%s'?", match))
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", nil
+	}
+	return match, nil
+}
+
+// needsFuzzyConfirm decides whether a fuzzy match is too different from what was typed to switch silently
+func (m *Manager) needsFuzzyConfirm(typed, match string) bool {
+	return Similarity(typed, match) < m.settings().fuzzyConfirmBelow
+}
+
+// DeleteSession deletes an active tmux session
+func (m *Manager) DeleteSession(name string) error {
+	if err := m.tmuxClient.DeleteSession(name); err != nil {
+		return err
+	}
+	m.emit(EventDeleted, name)
+	return nil
+}
+
+// RefreshProjects makes the next listing ask tmuxinator for its projects
+// again instead of reusing the ones already listed, for watches
+func (m *Manager) RefreshProjects() {
+	m.tmuxinatorClient.Invalidate()
+}
+
+// Windows returns an active session's windows in index order
+func (m *Manager) Windows(name string) ([]Window, error) {
+	return m.tmuxClient.ListWindows(name)
+}
+
+// MoveWindow moves a window from one running session to the end of another,
+// and reports whether that ended the source session
+// tmux closes a session when its last window leaves, so moving that one
+// is allowed but reported
+func (m *Manager) MoveWindow(srcSession string, windowIndex int, dstSession string) (bool, error) {
+	if srcSession == dstSession {
+		return false, fmt.Errorf("window is already in session '%s'", srcSession)
+	}
+	for _, name := range []string{srcSession, dstSession} {
+		exists, err := m.tmuxClient.SessionExists(name)
+		if err != nil {
+			return false, fmt.Errorf("failed to check if session exists: %w", err)
+		}
+		if !exists {
+			return false, fmt.Errorf("session '%s' is not running", name)
+		}
+	}
+
+	windows, err := m.tmuxClient.ListWindows(srcSession)
+	if err != nil {
+		return false, err
+	}
+	found := false
+	for _, window := range windows {
+		if window.Index == windowIndex {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return false, fmt.Errorf("session '%s' has no window %d", srcSession, windowIndex)
+	}
+
+	if err := m.tmuxClient.MoveWindow(srcSession, windowIndex, dstSession); err != nil {
+		return false, err
+	}
+
+	if len(windows) > 1 {
+		return false, nil
+	}
+	m.emit(EventDeleted, srcSession)
+	return true, nil
+}
+
+// RenameSession renames an active session
+// tmux refuses a name that's taken, but checking first gives a clearer error
+func (m *Manager) RenameSession(oldName, newName string) error {
+	if newName == "" {
+		return fmt.Errorf("new session name can't be empty")
+	}
+	if newName == oldName {
+		return fmt.Errorf("session is already named '%s'", oldName)
+	}
+
+	exists, err := m.tmuxClient.SessionExists(oldName)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("session '%s' is not running", oldName)
+	}
+
+	taken, err := m.tmuxClient.SessionExists(newName)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if taken {
+		return fmt.Errorf("a session named '%s' already exists", newName)
+	}
+
+	return m.tmuxClient.RenameSession(oldName, newName)
+}
+
+// KillAll kills every active session and returns the names killed
+// The session sess runs in is skipped unless opts.IncludeCurrent is set, and
+// the user is asked first unless opts.Force is set; declining kills nothing
+func (m *Manager) KillAll(opts KillAllOptions) ([]string, error) {
+	sessions, err := m.tmuxClient.ListSessions()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sessions: %w", err)
+	}
+
+	// Only a session we're inside of can be pulled out from under us
+	current := ""
+	if !opts.IncludeCurrent && m.tmuxClient.IsInsideTmux() {
+		current, err = m.tmuxClient.CurrentSession()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	targets := make([]string, 0, len(sessions))
+	for _, sess := range sessions {
+		if sess.Name != current {
+			targets = append(targets, sess.Name)
+		}
+	}
+	if len(targets) == 0 {
+		return nil, nil
+	}
+
+	// kill-server can't spare one session
+	if opts.Server && current != "" {
+		return nil, fmt.Errorf("killing the server would end the current session '%s' too (add --include-current)", current)
+	}
+
+	if !opts.Force {
+		confirmer := m.settings().confirmer
+		if confirmer == nil {
+			return nil, fmt.Errorf("not killing %d sessions without confirmation (use --force)", len(targets))
+		}
+		ok, err := confirmer.Confirm(fmt.Sprintf("Kill %d sessions (%s)?", len(targets), strings.Join(targets, ", ")))
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+	}
+
+	if opts.Server {
+		if err := m.tmuxClient.KillServer(); err != nil {
+			return nil, err
+		}
+		for _, name := range targets {
+			m.emit(EventDeleted, name)
+		}
+		return targets, nil
+	}
+
+	killed := make([]string, 0, len(targets))
+	for _, name := range targets {
+		if err := m.DeleteSession(name); err != nil {
+			return killed, err
+		}
+		killed = append(killed, name)
+	}
+	return killed, nil
+}
+
+// Teardown ends a running session, asking first unless opts.Force is set
+// A session started from a tmuxinator project may have stop hooks that
+// only tmuxinator stop runs, so for those the user is also asked whether
+// to stop the project instead (opts.StopProject does so without asking)
+func (m *Manager) Teardown(name string, opts TeardownOptions) (TeardownResult, error) {
+	running, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return TeardownKept, fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if !running {
+		// Only running sessions can be deleted, so say what the name is instead
+		switch typ, _, _ := m.ResolveSession(name); typ {
+		case SessionTypeTmuxinator:
+			return TeardownKept, fmt.Errorf("'%s' is a tmuxinator project that isn't running", name)
+		case SessionTypeDefault:
+			return TeardownKept, fmt.Errorf("'%s' is a default session that isn't running", name)
+		}
+		return TeardownKept, fmt.Errorf("session '%s' does not exist", name)
+	}
+
+	confirmer := m.settings().confirmer
+	if !opts.Force {
+		if confirmer == nil {
+			return TeardownKept, fmt.Errorf("not deleting session '%s' without confirmation (use --force)", name)
+		}
+		// The window count is only there to help recognize the session
+		question := fmt.Sprintf("Delete session '%s'?", name)
+		if windows, err := m.tmuxClient.ListWindows(name); err == nil {
+			count := fmt.Sprintf("%d windows", len(windows))
+			if len(windows) == 1 {
+				count = "1 window"
+			}
+			question = fmt.Sprintf("Delete session '%s' (%s)?", name, count)
+		}
+		ok, err := confirmer.Confirm(question)
+		if err != nil {
+			return TeardownKept, err
+		}
+		if !ok {
+			return TeardownKept, nil
+		}
+	}
+
+	stopProject := opts.StopProject
+	isProject := false
+	if m.tmuxinatorClient.IsInstalled() {
+		exists, err := m.tmuxinatorClient.ProjectExists(name)
+		isProject = err == nil && exists
+	}
+	if isProject && !stopProject && !opts.Force {
+		ok, err := confirmer.Confirm(fmt.Sprintf("'%s' is a tmuxinator project, run its stop hooks with tmuxinator stop?", name))
+		if err != nil {
+			return TeardownKept, err
+		}
+		stopProject = ok
+	}
+
+	if !isProject || !stopProject {
+		if err := m.DeleteSession(name); err != nil {
+			return TeardownKept, err
+		}
+		return TeardownDeleted, nil
+	}
+
+	if err := m.tmuxinatorClient.StopProject(name); err != nil {
+		return TeardownKept, err
+	}
+	m.emit(EventDeleted, name)
+	return TeardownStopped, nil
+}
+
+// StopProject stops a running tmuxinator project with tmuxinator stop, which
+// runs its on_project_stop hooks before killing the session
+// tmuxinator stop fails with a bare tmux error for anything it can't stop,
+// so the name is checked first
+func (m *Manager) StopProject(name string) error {
+	if !m.tmuxinatorClient.IsInstalled() {
+		return fmt.Errorf("tmuxinator is not installed")
+	}
+
+	isProject, err := m.tmuxinatorClient.ProjectExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check for tmuxinator project: %w", err)
+	}
+	if !isProject {
+		switch typ, _, _ := m.ResolveSession(name); typ {
+		case SessionTypeTmux:
+			return fmt.Errorf("'%s' is a tmux session, not a tmuxinator project (use 'sess delete')", name)
+		case SessionTypeDefault:
+			return fmt.Errorf("'%s' is a default session, not a tmuxinator project", name)
+		}
+		return fmt.Errorf("'%s' is not a tmuxinator project", name)
+	}
+
+	running, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+
+	// A project file can name its session something other than the project, so a
+	// missing session only explains a failure rather than preventing the stop
+	if err := m.tmuxinatorClient.StopProject(name); err != nil {
+		if !running {
+			return fmt.Errorf("tmuxinator project '%s' isn't running", name)
+		}
+		return err
+	}
+	m.emit(EventDeleted, name)
+	return nil
+}
+
+// RestartSession kills a session and recreates it fresh
+// Sessions backed by a default config are rebuilt from that config, while
+// ad-hoc active sessions are rebuilt from the window layout captured before the kill
+func (m *Manager) RestartSession(name string) error {
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+
+	// Config-backed sessions know how to build themselves
+	config, err := m.configLoader.GetSessionConfig(name, m.platform)
+	if err == nil {
+		old := ""
+		if exists {
+			if old, err = m.retire(name); err != nil {
+				return err
+			}
+		}
+		return m.replace(name, old, func() error { return m.createDefaultSession(config, false) })
+	}
+
+	if !exists {
+		return fmt.Errorf("session '%s' not found", name)
+	}
+
+	// Capture the layout before the session goes away
+	windows, err := m.tmuxClient.ListWindows(name)
+	if err != nil {
+		return err
+	}
+
+	old, err := m.retire(name)
+	if err != nil {
+		return err
+	}
+	return m.replace(name, old, func() error { return m.recreateLayout(name, windows) })
+}
+
+// retire gets a running session out of the way of the one replacing it
+// Usually that's killing it, but killing the session sess is running in
+// would kill sess before the new one exists. That one is renamed instead,
+// and its new name returned for replace to kill once the client has moved on.
+func (m *Manager) retire(name string) (string, error) {
+	if m.tmuxClient.IsInsideTmux() {
+		if current, err := m.tmuxClient.CurrentSession(); err == nil && current == name {
+			old := name + "-restarting"
+			if err := m.tmuxClient.RenameSession(name, old); err != nil {
+				return "", err
+			}
+			m.emit(EventDeleted, name)
+			return old, nil
+		}
+	}
+	return "", m.DeleteSession(name)
+}
+
+// replace creates a session in place of one retire put aside, then kills
+// the old one (if it's still around) last, since that may end sess
+// A failed create puts the old session back under its name
+func (m *Manager) replace(name, old string, create func() error) error {
+	if err := create(); err != nil {
+		if old != "" {
+			if renameErr := m.tmuxClient.RenameSession(old, name); renameErr != nil {
+				m.settings().logger.Warn("failed to restore the old session", "session", old, "error", renameErr)
+			}
+		}
+		return err
+	}
+	m.opened(EventCreated, name)
+
+	if old == "" {
+		return nil
+	}
+	return m.tmuxClient.DeleteSession(old)
+}
+
+// recreateLayout builds a detached session with the given windows, then switches to it
+func (m *Manager) recreateLayout(name string, windows []Window) error {
+	// The first window comes with the session itself
+	first := Session{Name: name, Type: SessionTypeTmux}
+	if len(windows) > 0 {
+		first.Directory = windows[0].Directory
+	}
+
+	if err := m.tmuxClient.CreateDetachedSession(first); err != nil {
+		return err
+	}
+	if len(windows) > 0 && windows[0].Name != "" {
+		// The new session's only window is its first, whatever base-index is
+		if err := m.tmuxClient.RenameWindow("="+name+":", windows[0].Name); err != nil {
+			return err
+		}
+	}
+
+	for i := 1; i < len(windows); i++ {
+		if err := m.tmuxClient.NewWindow(name, windows[i].Name, windows[i].Directory); err != nil {
+			return err
+		}
+	}
+
+	inTmux := m.tmuxClient.IsInsideTmux()
+	return m.tmuxClient.SwitchToSession(name, inTmux)
+}
+
+// NewSession creates a new plain session, failing if one with the name is already running
+// With cloneEnv, the new session starts with the current session's tmux
+// environment, so it must be called from inside tmux
+func (m *Manager) NewSession(name string, cloneEnv bool) error {
+	name, err := m.sanitize(name)
+	if err != nil {
+		return err
+	}
+
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if exists {
+		return fmt.Errorf("session '%s' already exists", name)
+	}
+
+	sess := Session{Name: name, Type: SessionTypeTmux}
+
+	if cloneEnv {
+		if !m.tmuxClient.IsInsideTmux() {
+			return fmt.Errorf("--clone-env only works inside tmux, there's no current session to copy from")
+		}
+
+		current, err := m.tmuxClient.CurrentSession()
+		if err != nil {
+			return err
+		}
+		env, err := m.tmuxClient.ShowEnvironment(current)
+		if err != nil {
+			return err
+		}
+		sess.Environment = env
+	}
+
+	if err := m.createPlain(sess, m.settings().detached); err != nil {
+		return err
+	}
+	m.created(name)
+	return nil
+}
+
+// Scratch switches to the throwaway scratch session, creating it in dir if needed
+// With reset set, an existing scratch session is killed first so it starts empty
+func (m *Manager) Scratch(name, dir string, reset bool) error {
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+
+	if exists && !reset {
+		inTmux := m.tmuxClient.IsInsideTmux()
+		if err := m.tmuxClient.SwitchToSession(name, inTmux); err != nil {
+			return err
+		}
+		m.opened(EventSwitched, name)
+		return nil
+	}
+
+	if exists {
+		if err := m.DeleteSession(name); err != nil {
+			return err
+		}
+	}
+
+	err = m.tmuxClient.CreateSession(Session{
+		Name:      name,
+		Type:      SessionTypeTmux,
+		Directory: dir,
+	})
+	if err != nil {
+		return err
+	}
+	m.opened(EventCreated, name)
+	return nil
+}
+
+// SyncPanes changes synchronize-panes for the current window and returns the new state
+// mode is "on", "off", or "toggle" (which flips whatever the window has now)
+func (m *Manager) SyncPanes(mode string) (bool, error) {
+	if !m.tmuxClient.IsInsideTmux() {
+		return false, fmt.Errorf("not in a tmux session")
+	}
+
+	var on bool
+	switch mode {
+	case "on":
+		on = true
+	case "off":
+		on = false
+	case "toggle":
+		current, err := m.tmuxClient.GetSyncPanes("")
+		if err != nil {
+			return false, err
+		}
+		on = !current
+	default:
+		return false, fmt.Errorf("invalid mode %q (want on, off, or toggle)", mode)
+	}
+
+	if err := m.tmuxClient.SetSyncPanes("", on); err != nil {
+		return false, err
+	}
+
+	return on, nil
+}
+
+// ReloadSession reloads tmux configuration in one active session
+func (m *Manager) ReloadSession(name string) error {
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if !exists {
+		if !m.tmuxClient.ServerRunning() {
+			return ErrServerNotRunning
+		}
+		return fmt.Errorf("session '%s' is not running", name)
+	}
+
+	return m.tmuxClient.ReloadConfigFor(name)
+}
+
+// Capture returns the text of a running session's active pane, or of every
+// pane with allPanes, including up to scrollback lines of history per pane
+// Each pane's text is preceded by a "==> target <==" header when there are several
+func (m *Manager) Capture(name string, allPanes bool, scrollback int) (string, error) {
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if !exists {
+		return "", fmt.Errorf("session '%s' is not running", name)
+	}
+
+	if !allPanes {
+		// "name:" is the session's current window, and with no pane given
+		// tmux uses that window's active pane; "=" matches the name exactly
+		return m.tmuxClient.CapturePane("="+name+":", scrollback)
+	}
+
+	panes, err := m.tmuxClient.ListPanes(name)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	for i, pane := range panes {
+		text, err := m.tmuxClient.CapturePane("="+pane, scrollback)
+		if err != nil {
+			return "", err
+		}
+
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "==> %s <==\n", pane)
+		b.WriteString(text)
+		if !strings.HasSuffix(text, "\n") {
+			b.WriteString("\n")
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Peek returns what's on screen in a running session's active pane, without
+// the blank lines tmux pads the rest of the screen with
+func (m *Manager) Peek(name string) (string, error) {
+	text, err := m.Capture(name, false, 0)
+	if err != nil {
+		return "", err
+	}
+
+	lines := strings.Split(text, "\n")
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return "", nil
+	}
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+// History returns the recorded history, oldest first
+func (m *Manager) History() ([]HistoryEntry, error) {
+	history := m.settings().history
+	if history == nil {
+		return []HistoryEntry{}, nil
+	}
+	return history.Entries()
+}
+
+// PrevUnique opens the most recent session in the history that isn't one of
+// the last two sessions opened, for cycling through more than two sessions
+// (tmux's own "last" just toggles between the same two)
+func (m *Manager) PrevUnique() error {
+	entries, err := m.History()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range previousUnique(entries, 2) {
+		exists, err := m.SessionExists(name)
+		if err != nil {
+			return err
+		}
+		if exists {
+			return m.CreateOrSwitch(name)
+		}
+	}
+
+	return fmt.Errorf("no earlier session in the history to go back to")
+}
+
+// previousUnique returns the names in the history, most recent first and
+// without repeats, leaving out the last recent distinct sessions opened
+// With recent = 2 and a history of a, b, c, b, c it returns just a: c is
+// the current session and b the one before it
+func previousUnique(entries []HistoryEntry, recent int) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for i := len(entries) - 1; i >= 0; i-- {
+		name := entries[i].Name
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		if recent > 0 {
+			recent--
+			continue
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// PruneHistory removes history entries for sessions that no longer exist in
+// any source, and returns how many were removed
+func (m *Manager) PruneHistory() (int, error) {
+	opts := m.settings()
+	if opts.history == nil {
+		return 0, nil
+	}
+
+	keep := func(name string) bool {
+		exists, err := m.SessionExists(name)
+		// If we can't tell (tmux hiccup), keep the entry rather than lose it
+		return err != nil || exists
+	}
+	if !opts.dryRun {
+		return opts.history.Prune(keep)
+	}
+
+	// Count what would go without writing the history
+	entries, err := opts.history.Entries()
+	if err != nil {
+		return 0, err
+	}
+	pruned := 0
+	for _, entry := range entries {
+		if !keep(entry.Name) {
+			pruned++
+		}
+	}
+	return pruned, nil
+}
+
+// ClearHistory removes the whole history
+func (m *Manager) ClearHistory() error {
+	opts := m.settings()
+	if opts.history == nil || opts.dryRun {
+		return nil
+	}
+	return opts.history.Clear()
+}
+
+// GetSessionInfo returns detailed information about a session
+// This is useful for displaying additional context in the UI
+func (m *Manager) GetSessionInfo(name string) (string, error) {
+	// Check if it's an active session
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return "", err
+	}
+
+	if exists {
+		// Get the active sessions and find this one
+		sessions, err := m.tmuxClient.ListSessions()
+		if err != nil {
+			return "", err
+		}
+
+		for _, sess := range sessions {
+			if sess.Name == name {
+				return sess.DisplayInfo(), nil
+			}
+		}
+	}
+
+	// Check if it's a tmuxinator project
+	if m.tmuxinatorClient.IsInstalled() {
+		isProject, err := m.tmuxinatorClient.ProjectExists(name)
+		if err == nil && isProject {
+			return "tmuxinator project", nil
+		}
+	}
+
+	// Check if it's a default session
+	config, err := m.configLoader.GetSessionConfig(name, m.platform)
+	if err == nil {
+		// A default that points at a tmuxinator project is really started by
+		// tmuxinator, so say so instead of hiding the indirection
+		if config.TmuxinatorProject != "" && m.tmuxinatorClient.IsInstalled() {
+			info := fmt.Sprintf("default → tmuxinator: %s", config.TmuxinatorProject)
+			if config.Description != "" {
+				info += fmt.Sprintf(" (%s)", config.Description)
+			}
+			return info, nil
+		}
+
 		if config.Description != "" {
 			return fmt.Sprintf("default: %s", config.Description), nil
 		}
@@ -1,8 +1,18 @@
 package session
 
 import (
+	"bytes"
 	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
 // Mock implementations for testing
@@ -18,10 +28,57 @@ type MockTmuxClient struct {
 	switchErr      error
 	lastSessionErr error
 	deleteErr      error
+	sendKeysErr    error
+	windows        map[string][]Window
+	syncPanes      bool
+	panes          map[string][]string
+	paneDetails    map[string][]Pane
+	paneText       map[string]string
+	current        string
+	env            map[string]map[string]string
+
+	attachedClients int
+
+	// listDelay makes ListSessions slow, like a busy tmux server
+	listDelay time.Duration
+	listErr   error
+
+	// Calls recorded so tests can assert on what the manager did
+	created  []Session
+	detached []Session
+	deleted  []string
+	switched []string
+	newWins  []Window
+	sentKeys []string
+	attached []attachCall
+	reloaded []string
+	options  []string
+	captured []string
+	renamed  []string
+	moved    []string
+
+	// layoutCalls records window renames, splits, and layouts in order
+	layoutCalls []string
+
+	killedServer   bool
+	detachedClient bool
+
+	// serverDown makes ServerRunning report no tmux server
+	serverDown bool
+}
+
+// attachCall records the arguments of an AttachToSession call
+type attachCall struct {
+	name         string
+	detachOthers bool
 }
 
 // Implement all TmuxClient interface methods
 func (m *MockTmuxClient) ListSessions() ([]Session, error) {
+	time.Sleep(m.listDelay)
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
 	return m.sessions, nil
 }
 
@@ -36,42 +93,175 @@ func (m *MockTmuxClient) SessionExists(name string) (bool, error) {
 }
 
 func (m *MockTmuxClient) CreateSession(session Session) error {
+	m.created = append(m.created, session)
+	return m.createErr
+}
+
+func (m *MockTmuxClient) CreateDetachedSession(session Session) error {
+	m.detached = append(m.detached, session)
 	return m.createErr
 }
 
 func (m *MockTmuxClient) SwitchToSession(name string, fromTmux bool) error {
+	m.switched = append(m.switched, name)
 	return m.switchErr
 }
 
-func (m *MockTmuxClient) AttachToSession(name string) error {
+func (m *MockTmuxClient) AttachToSession(name string, detachOthers bool) error {
+	m.attached = append(m.attached, attachCall{name: name, detachOthers: detachOthers})
 	return nil
 }
 
+func (m *MockTmuxClient) AttachedClients(name string) (int, error) {
+	return m.attachedClients, nil
+}
+
 func (m *MockTmuxClient) IsInsideTmux() bool {
 	return m.isInsideTmux
 }
 
+func (m *MockTmuxClient) CurrentSession() (string, error) {
+	return m.current, nil
+}
+
+func (m *MockTmuxClient) ShowEnvironment(session string) (map[string]string, error) {
+	return m.env[session], nil
+}
+
 func (m *MockTmuxClient) SwitchToLastSession() error {
 	return m.lastSessionErr
 }
 
+func (m *MockTmuxClient) DetachSession() error {
+	m.detachedClient = true
+	return nil
+}
+
 func (m *MockTmuxClient) DeleteSession(name string) error {
+	m.deleted = append(m.deleted, name)
 	return m.deleteErr
 }
 
+func (m *MockTmuxClient) RenameSession(oldName, newName string) error {
+	m.renamed = append(m.renamed, oldName+"->"+newName)
+	return nil
+}
+
+func (m *MockTmuxClient) KillServer() error {
+	m.killedServer = true
+	return nil
+}
+
+func (m *MockTmuxClient) ServerRunning() bool {
+	return !m.serverDown
+}
+
+func (m *MockTmuxClient) ListWindows(session string) ([]Window, error) {
+	return m.windows[session], nil
+}
+
+func (m *MockTmuxClient) ListPanes(session string) ([]string, error) {
+	return m.panes[session], nil
+}
+
+func (m *MockTmuxClient) ListPaneDetails(session string) ([]Pane, error) {
+	return m.paneDetails[session], nil
+}
+
+func (m *MockTmuxClient) SplitWindow(target, directory string) error {
+	m.layoutCalls = append(m.layoutCalls, "split "+target+" "+directory)
+	return nil
+}
+
+func (m *MockTmuxClient) SelectLayout(target, layout string) error {
+	m.layoutCalls = append(m.layoutCalls, "layout "+target+" "+layout)
+	return nil
+}
+
+func (m *MockTmuxClient) RenameWindow(target, name string) error {
+	m.layoutCalls = append(m.layoutCalls, "rename "+target+" "+name)
+	return nil
+}
+
+func (m *MockTmuxClient) MoveWindow(srcSession string, windowIndex int, dstSession string) error {
+	m.moved = append(m.moved, fmt.Sprintf("%s:%d %s", srcSession, windowIndex, dstSession))
+	return nil
+}
+
+func (m *MockTmuxClient) CapturePane(target string, scrollback int) (string, error) {
+	m.captured = append(m.captured, target)
+	return m.paneText[target], nil
+}
+
+func (m *MockTmuxClient) NewWindow(session, name, directory string) error {
+	m.newWins = append(m.newWins, Window{Name: name, Directory: directory})
+	return nil
+}
+
+func (m *MockTmuxClient) SendKeys(target, command string) error {
+	if m.sendKeysErr != nil {
+		return m.sendKeysErr
+	}
+	m.sentKeys = append(m.sentKeys, target+" "+command)
+	return nil
+}
+
+func (m *MockTmuxClient) SetOption(session, key, value string) error {
+	m.options = append(m.options, session+" "+key+" "+value)
+	return nil
+}
+
+func (m *MockTmuxClient) GetOption(session, key string) (string, error) {
+	// The last value set wins, like in tmux
+	value := ""
+	for _, option := range m.options {
+		if rest, ok := strings.CutPrefix(option, session+" "+key+" "); ok {
+			value = rest
+		}
+	}
+	return value, nil
+}
+
+func (m *MockTmuxClient) SetSyncPanes(window string, on bool) error {
+	m.syncPanes = on
+	return nil
+}
+
+func (m *MockTmuxClient) GetSyncPanes(window string) (bool, error) {
+	return m.syncPanes, nil
+}
+
 func (m *MockTmuxClient) ReloadConfig() error {
 	return nil
 }
 
+func (m *MockTmuxClient) ReloadConfigFor(name string) error {
+	m.reloaded = append(m.reloaded, name)
+	return nil
+}
+
 // MockTmuxinatorClient is a fake tmuxinator client for testing
 type MockTmuxinatorClient struct {
 	projects      []string
 	isInstalled   bool
 	projectExists bool
 	startErr      error
+	stopErr       error
+	listErr       error
+
+	// sessionNames are the sessions projects run in, when the project file
+	// names them something other than the project
+	sessionNames map[string]string
+
+	started         []string
+	startedDetached []string
+	stopped         []string
 }
 
 func (m *MockTmuxinatorClient) ListProjects() ([]string, error) {
+	if m.listErr != nil {
+		return nil, m.listErr
+	}
 	return m.projects, nil
 }
 
@@ -85,14 +275,35 @@ func (m *MockTmuxinatorClient) ProjectExists(name string) (bool, error) {
 	return m.projectExists, nil
 }
 
-func (m *MockTmuxinatorClient) StartProject(name string, fromTmux bool) error {
-	return m.startErr
+func (m *MockTmuxinatorClient) StartProject(name string, fromTmux bool) (string, error) {
+	m.started = append(m.started, name)
+	return m.sessionName(name), m.startErr
+}
+
+func (m *MockTmuxinatorClient) StartProjectDetached(name string) (string, error) {
+	m.startedDetached = append(m.startedDetached, name)
+	return m.sessionName(name), m.startErr
+}
+
+// sessionName returns the session a project runs in
+func (m *MockTmuxinatorClient) sessionName(project string) string {
+	if name, ok := m.sessionNames[project]; ok {
+		return name
+	}
+	return project
+}
+
+func (m *MockTmuxinatorClient) StopProject(name string) error {
+	m.stopped = append(m.stopped, name)
+	return m.stopErr
 }
 
 func (m *MockTmuxinatorClient) IsInstalled() bool {
 	return m.isInstalled
 }
 
+func (m *MockTmuxinatorClient) Invalidate() {}
+
 // MockConfigLoader is a fake config loader for testing
 type MockConfigLoader struct {
 	sessions []SessionConfig
@@ -118,6 +329,101 @@ func (m *MockConfigLoader) GetSessionConfig(name, platform string) (*SessionConf
 	return nil, errors.New("session not found")
 }
 
+// fakeSink records emitted events so tests can check the payloads
+type fakeSink struct {
+	events []Event
+}
+
+func (f *fakeSink) Emit(event Event) {
+	f.events = append(f.events, event)
+}
+
+// fakeConfirmer answers every question the same way and remembers what it was asked
+type fakeConfirmer struct {
+	answer    bool
+	err       error
+	questions []string
+
+	// answers are given in order, before falling back to answer
+	answers []bool
+}
+
+func (f *fakeConfirmer) Confirm(question string) (bool, error) {
+	f.questions = append(f.questions, question)
+	if len(f.answers) > 0 {
+		answer := f.answers[0]
+		f.answers = f.answers[1:]
+		return answer, f.err
+	}
+	return f.answer, f.err
+}
+
+// fakeResolver maps names to directories, like a user's resolver script would
+type fakeResolver struct {
+	dirs map[string]string
+	err  error
+}
+
+func (f *fakeResolver) ResolveDirectory(name string) (string, bool, error) {
+	dir, ok := f.dirs[name]
+	return dir, ok, f.err
+}
+
+// MockZoxideClient returns a fixed directory for every query
+type MockZoxideClient struct {
+	installed bool
+	dir       string
+	err       error
+}
+
+func (m *MockZoxideClient) IsInstalled() bool { return m.installed }
+
+func (m *MockZoxideClient) Query(query string) (string, error) { return m.dir, m.err }
+
+// fakeHistory is an in-memory HistoryStore
+type fakeHistory struct {
+	entries   []HistoryEntry
+	recordErr error
+}
+
+func (f *fakeHistory) Record(name string) error {
+	if f.recordErr != nil {
+		return f.recordErr
+	}
+	f.entries = append(f.entries, HistoryEntry{Name: name})
+	return nil
+}
+
+func (f *fakeHistory) Entries() ([]HistoryEntry, error) {
+	return f.entries, nil
+}
+
+func (f *fakeHistory) Prune(keep func(name string) bool) (int, error) {
+	var kept []HistoryEntry
+	for _, entry := range f.entries {
+		if keep(entry.Name) {
+			kept = append(kept, entry)
+		}
+	}
+	removed := len(f.entries) - len(kept)
+	f.entries = kept
+	return removed, nil
+}
+
+func (f *fakeHistory) Clear() error {
+	f.entries = nil
+	return nil
+}
+
+// historyNames returns the names in a history, oldest first
+func historyNames(entries []HistoryEntry) string {
+	names := make([]string, len(entries))
+	for i, entry := range entries {
+		names[i] = entry.Name
+	}
+	return strings.Join(names, ",")
+}
+
 // Test helper function to create a manager with mocks
 func createTestManager(
 	tmuxSessions []Session,
@@ -140,6 +446,41 @@ func createTestManager(
 	return NewManager(tmuxClient, tmuxinatorClient, configLoader, "macos")
 }
 
+// platformLoader is a MockConfigLoader that records the platform of every load
+// ListAll loads from several goroutines, hence the lock
+type platformLoader struct {
+	MockConfigLoader
+
+	mu        sync.Mutex
+	platforms []string
+}
+
+func (l *platformLoader) LoadDefaultSessions(platform string) ([]SessionConfig, error) {
+	l.mu.Lock()
+	l.platforms = append(l.platforms, platform)
+	l.mu.Unlock()
+	return l.MockConfigLoader.LoadDefaultSessions(platform)
+}
+
+// TestPlatformReachesLoader tests that the manager loads the default
+// sessions of the platform it was created with, not a detected one
+func TestPlatformReachesLoader(t *testing.T) {
+	configLoader := &platformLoader{MockConfigLoader: MockConfigLoader{sessions: []SessionConfig{{Name: "notes"}}}}
+	manager := NewManager(&MockTmuxClient{}, &MockTmuxinatorClient{}, configLoader, "work")
+
+	if _, _, err := manager.ListAll(); err != nil {
+		t.Fatalf("ListAll() unexpected error: %v", err)
+	}
+	if len(configLoader.platforms) == 0 {
+		t.Fatal("ListAll() never loaded the default sessions")
+	}
+	for _, platform := range configLoader.platforms {
+		if platform != "work" {
+			t.Errorf("loaded the sessions of platform %q, want work", platform)
+		}
+	}
+}
+
 // TestListAll tests the ListAll function
 // This is a "table-driven test" - a common Go testing pattern
 func TestListAll(t *testing.T) {
@@ -204,7 +545,7 @@ func TestListAll(t *testing.T) {
 			manager := createTestManager(tt.tmuxSessions, tt.tmuxinatorProjects, tt.defaultSessions)
 
 			// Call the function we're testing
-			sessions, err := manager.ListAll()
+			sessions, _, err := manager.ListAll()
 			// Check for errors
 			if err != nil {
 				t.Fatalf("ListAll() returned error: %v", err)
@@ -232,6 +573,142 @@ func TestListAll(t *testing.T) {
 	}
 }
 
+// TestListAllPrecedence tests that an active session still wins over a
+// project or config of the same name when tmux is the slowest source to answer
+func TestListAllPrecedence(t *testing.T) {
+	tmuxClient := &MockTmuxClient{
+		sessions:  []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+		listDelay: 20 * time.Millisecond,
+	}
+	tmuxinatorClient := &MockTmuxinatorClient{projects: []string{"api", "infra"}, isInstalled: true}
+	configLoader := &MockConfigLoader{sessions: []SessionConfig{
+		{Name: "api", Directory: "/code/api"},
+		{Name: "infra", Directory: "/code/infra"},
+		{Name: "notes", Directory: "/notes"},
+	}}
+	manager := NewManager(tmuxClient, tmuxinatorClient, configLoader, "macos")
+
+	sessions, _, err := manager.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll() returned error: %v", err)
+	}
+
+	got := make(map[string]SessionType)
+	for _, sess := range sessions {
+		if _, dup := got[sess.Name]; dup {
+			t.Errorf("ListAll() returned %q twice", sess.Name)
+		}
+		got[sess.Name] = sess.Type
+	}
+
+	want := map[string]SessionType{
+		"api":   SessionTypeTmux,
+		"infra": SessionTypeTmuxinator,
+		"notes": SessionTypeDefault,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ListAll() types = %v, want %v", got, want)
+	}
+}
+
+// TestListAllWarnings tests that a source that can't be read is reported
+// as a warning while the other sources are still listed
+func TestListAllWarnings(t *testing.T) {
+	tests := []struct {
+		name          string
+		tmuxErr       error
+		tmuxinatorErr error
+		configErr     error
+		wantSources   []string
+		wantNames     []string
+	}{
+		{
+			name:      "every source readable",
+			wantNames: []string{"api", "infra", "notes"},
+		},
+		{
+			name:        "tmux broken",
+			tmuxErr:     errors.New("tmux: executable file not found"),
+			wantSources: []string{"tmux"},
+			wantNames:   []string{"infra", "notes"},
+		},
+		{
+			name:          "tmuxinator broken",
+			tmuxinatorErr: errors.New("ruby: command not found"),
+			wantSources:   []string{"tmuxinator"},
+			wantNames:     []string{"api", "notes"},
+		},
+		{
+			name:        "malformed config",
+			configErr:   errors.New("failed to parse YAML"),
+			wantSources: []string{"config"},
+			wantNames:   []string{"api", "infra"},
+		},
+		{
+			name:      "missing config file is not a warning",
+			configErr: fmt.Errorf("failed to read config file: %w", os.ErrNotExist),
+			wantNames: []string{"api", "infra"},
+		},
+		{
+			name:        "everything broken",
+			tmuxErr:     errors.New("no tmux"),
+			configErr:   errors.New("bad yaml"),
+			wantSources: []string{"tmux", "config"},
+			wantNames:   []string{"infra"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager(
+				&MockTmuxClient{sessions: []Session{{Name: "api", Type: SessionTypeTmux}}, listErr: tt.tmuxErr},
+				&MockTmuxinatorClient{projects: []string{"infra"}, isInstalled: true, listErr: tt.tmuxinatorErr},
+				&MockConfigLoader{sessions: []SessionConfig{{Name: "notes", Directory: "/notes"}}, loadErr: tt.configErr},
+				"macos",
+			)
+
+			sessions, warnings, err := manager.ListAll()
+			if err != nil {
+				t.Fatalf("ListAll() returned error: %v", err)
+			}
+
+			var sources []string
+			for _, warning := range warnings {
+				sources = append(sources, warning.Source)
+			}
+			if !reflect.DeepEqual(sources, tt.wantSources) {
+				t.Errorf("ListAll() warnings = %v, want sources %v", warnings, tt.wantSources)
+			}
+
+			var names []string
+			for _, sess := range sessions {
+				names = append(names, sess.Name)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("ListAll() = %v, want %v", names, tt.wantNames)
+			}
+		})
+	}
+}
+
+// BenchmarkListAll measures ListAll when every source takes a moment to
+// answer; the sources are asked at the same time, so it costs about one delay
+func BenchmarkListAll(b *testing.B) {
+	tmuxClient := &MockTmuxClient{
+		sessions:  []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+		listDelay: time.Millisecond,
+	}
+	tmuxinatorClient := &MockTmuxinatorClient{projects: []string{"infra"}, isInstalled: true}
+	configLoader := &MockConfigLoader{sessions: []SessionConfig{{Name: "notes", Directory: "/notes"}}}
+	manager := NewManager(tmuxClient, tmuxinatorClient, configLoader, "macos")
+
+	for b.Loop() {
+		if _, _, err := manager.ListAll(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 // TestCreateOrSwitch tests the CreateOrSwitch function
 func TestCreateOrSwitch(t *testing.T) {
 	tests := []struct {
@@ -295,6 +772,8 @@ func TestGetSessionInfo(t *testing.T) {
 		[]string{"proj1"},
 		[]SessionConfig{
 			{Name: "default1", Directory: "~/dir1", Description: "Test default"},
+			{Name: "api", TmuxinatorProject: "api-proj"},
+			{Name: "web", TmuxinatorProject: "web-proj", Description: "Frontend"},
 		},
 	)
 
@@ -318,6 +797,16 @@ func TestGetSessionInfo(t *testing.T) {
 			sessionName: "default1",
 			wantInfo:    "default: Test default",
 		},
+		{
+			name:        "default session started by tmuxinator",
+			sessionName: "api",
+			wantInfo:    "default → tmuxinator: api-proj",
+		},
+		{
+			name:        "default session started by tmuxinator with description",
+			sessionName: "web",
+			wantInfo:    "default → tmuxinator: web-proj (Frontend)",
+		},
 	}
 
 	for _, tt := range tests {
@@ -332,4 +821,3164 @@ func TestGetSessionInfo(t *testing.T) {
 			}
 		})
 	}
+
+	// Without tmuxinator the config falls back to a plain session, and so does the info
+	t.Run("tmuxinator not installed", func(t *testing.T) {
+		manager := createTestManager(nil, nil, []SessionConfig{{Name: "api", TmuxinatorProject: "api-proj"}})
+
+		info, err := manager.GetSessionInfo("api")
+		if err != nil {
+			t.Fatalf("GetSessionInfo() returned error: %v", err)
+		}
+		if info != "default (not started)" {
+			t.Errorf("GetSessionInfo() = %q, want %q", info, "default (not started)")
+		}
+	})
+}
+
+// TestRestartSession tests the RestartSession function
+func TestRestartSession(t *testing.T) {
+	t.Run("config-backed session is rebuilt from config", func(t *testing.T) {
+		manager := createTestManager(
+			[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			nil,
+			[]SessionConfig{{Name: "api", Directory: "/code/api"}},
+		)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.RestartSession("api"); err != nil {
+			t.Fatalf("RestartSession() unexpected error: %v", err)
+		}
+
+		if len(tmuxClient.deleted) != 1 || tmuxClient.deleted[0] != "api" {
+			t.Errorf("deleted = %v, want [api]", tmuxClient.deleted)
+		}
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Directory != "/code/api" {
+			t.Errorf("created = %v, want one session in /code/api", tmuxClient.created)
+		}
+	})
+
+	t.Run("ad-hoc session is rebuilt from captured layout", func(t *testing.T) {
+		manager := createTestManager(
+			[]Session{{Name: "scratch", Type: SessionTypeTmux, IsActive: true}},
+			nil,
+			nil,
+		)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.windows = map[string][]Window{
+			"scratch": {
+				{Index: 1, Name: "editor", Directory: "/tmp/a"},
+				{Index: 2, Name: "server", Directory: "/tmp/b"},
+				{Index: 3, Name: "logs", Directory: "/tmp/c"},
+			},
+		}
+
+		if err := manager.RestartSession("scratch"); err != nil {
+			t.Fatalf("RestartSession() unexpected error: %v", err)
+		}
+
+		if len(tmuxClient.deleted) != 1 {
+			t.Errorf("deleted = %v, want one kill", tmuxClient.deleted)
+		}
+		if len(tmuxClient.detached) != 1 || tmuxClient.detached[0].Directory != "/tmp/a" {
+			t.Fatalf("detached = %v, want one session in /tmp/a", tmuxClient.detached)
+		}
+
+		wantWindows := []Window{
+			{Name: "server", Directory: "/tmp/b"},
+			{Name: "logs", Directory: "/tmp/c"},
+		}
+		if len(tmuxClient.newWins) != len(wantWindows) {
+			t.Fatalf("created %d extra windows, want %d", len(tmuxClient.newWins), len(wantWindows))
+		}
+		for i, want := range wantWindows {
+			if tmuxClient.newWins[i] != want {
+				t.Errorf("window %d = %+v, want %+v", i, tmuxClient.newWins[i], want)
+			}
+		}
+
+		if len(tmuxClient.switched) != 1 || tmuxClient.switched[0] != "scratch" {
+			t.Errorf("switched = %v, want [scratch]", tmuxClient.switched)
+		}
+		if want := []string{"rename =scratch: editor"}; !reflect.DeepEqual(tmuxClient.layoutCalls, want) {
+			t.Errorf("layout calls = %v, want the first window named %v", tmuxClient.layoutCalls, want)
+		}
+	})
+
+	t.Run("current session is replaced before it's killed", func(t *testing.T) {
+		manager := createTestManager(
+			[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			nil,
+			[]SessionConfig{{Name: "api", Directory: "/code/api"}},
+		)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.isInsideTmux = true
+		tmuxClient.current = "api"
+
+		if err := manager.RestartSession("api"); err != nil {
+			t.Fatalf("RestartSession() unexpected error: %v", err)
+		}
+
+		if want := []string{"api->api-restarting"}; !reflect.DeepEqual(tmuxClient.renamed, want) {
+			t.Errorf("renamed = %v, want %v", tmuxClient.renamed, want)
+		}
+		if len(tmuxClient.detached)+len(tmuxClient.created) != 1 {
+			t.Errorf("created %v and %v, want one new session", tmuxClient.created, tmuxClient.detached)
+		}
+		if want := []string{"api-restarting"}; !reflect.DeepEqual(tmuxClient.deleted, want) {
+			t.Errorf("deleted = %v, want only the old session, last", tmuxClient.deleted)
+		}
+	})
+
+	t.Run("failed create puts the current session back", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "scratch", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.isInsideTmux = true
+		tmuxClient.current = "scratch"
+		tmuxClient.createErr = errors.New("boom")
+
+		if err := manager.RestartSession("scratch"); err == nil {
+			t.Fatal("RestartSession() expected error but got none")
+		}
+		if want := []string{"scratch->scratch-restarting", "scratch-restarting->scratch"}; !reflect.DeepEqual(tmuxClient.renamed, want) {
+			t.Errorf("renamed = %v, want %v", tmuxClient.renamed, want)
+		}
+		if len(tmuxClient.deleted) != 0 {
+			t.Errorf("deleted = %v, want nothing", tmuxClient.deleted)
+		}
+	})
+
+	t.Run("unknown session", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+
+		if err := manager.RestartSession("missing"); err == nil {
+			t.Error("RestartSession() expected error but got none")
+		}
+	})
+}
+
+// TestScratch tests the Scratch function
+func TestScratch(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     []Session
+		reset        bool
+		wantSwitched int
+		wantDeleted  int
+		wantCreated  int
+	}{
+		{
+			name:        "creates scratch when missing",
+			wantCreated: 1,
+		},
+		{
+			name:         "switches to existing scratch",
+			existing:     []Session{{Name: "scratch", Type: SessionTypeTmux, IsActive: true}},
+			wantSwitched: 1,
+		},
+		{
+			name:        "clear kills and recreates existing scratch",
+			existing:    []Session{{Name: "scratch", Type: SessionTypeTmux, IsActive: true}},
+			reset:       true,
+			wantDeleted: 1,
+			wantCreated: 1,
+		},
+		{
+			name:        "clear with no scratch just creates it",
+			reset:       true,
+			wantCreated: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(tt.existing, nil, nil)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			if err := manager.Scratch("scratch", "/tmp/sess-scratch", tt.reset); err != nil {
+				t.Fatalf("Scratch() unexpected error: %v", err)
+			}
+
+			if len(tmuxClient.switched) != tt.wantSwitched {
+				t.Errorf("switched %d times, want %d", len(tmuxClient.switched), tt.wantSwitched)
+			}
+			if len(tmuxClient.deleted) != tt.wantDeleted {
+				t.Errorf("deleted %d times, want %d", len(tmuxClient.deleted), tt.wantDeleted)
+			}
+			if len(tmuxClient.created) != tt.wantCreated {
+				t.Fatalf("created %d times, want %d", len(tmuxClient.created), tt.wantCreated)
+			}
+			if tt.wantCreated > 0 && tmuxClient.created[0].Directory != "/tmp/sess-scratch" {
+				t.Errorf("created in %q, want /tmp/sess-scratch", tmuxClient.created[0].Directory)
+			}
+		})
+	}
+}
+
+// TestListFiltered tests that hidden sessions are excluded unless requested
+func TestListFiltered(t *testing.T) {
+	manager := createTestManager(
+		[]Session{
+			{Name: "api", Type: SessionTypeTmux, IsActive: true},
+			{Name: "monitor", Type: SessionTypeTmux, IsActive: true},
+		},
+		[]string{"infra"},
+		[]SessionConfig{
+			{Name: "dotfiles", Directory: "~/dotfiles"},
+			{Name: "scratch", Directory: "/tmp", Hidden: true},
+		},
+	)
+	opts := ListOptions{Hidden: []string{"monitor"}}
+
+	tests := []struct {
+		name          string
+		includeHidden bool
+		want          []string
+	}{
+		{
+			name: "hidden sessions excluded by default",
+			want: []string{"api", "dotfiles", "infra"},
+		},
+		{
+			name:          "all sessions shown with include hidden",
+			includeHidden: true,
+			want:          []string{"api", "dotfiles", "infra", "monitor", "scratch"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts.IncludeHidden = tt.includeHidden
+
+			sessions, _, err := manager.ListFiltered(opts)
+			if err != nil {
+				t.Fatalf("ListFiltered() returned error: %v", err)
+			}
+
+			got := make([]string, len(sessions))
+			for i, sess := range sessions {
+				got[i] = sess.Name
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ListFiltered() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCountByType tests tallying sessions by type
+func TestCountByType(t *testing.T) {
+	tests := []struct {
+		name     string
+		sessions []Session
+		want     map[SessionType]int
+	}{
+		{
+			name: "mixed",
+			sessions: []Session{
+				{Name: "api", Type: SessionTypeTmux},
+				{Name: "web", Type: SessionTypeTmux},
+				{Name: "infra", Type: SessionTypeTmuxinator},
+				{Name: "dotfiles", Type: SessionTypeDefault},
+			},
+			want: map[SessionType]int{SessionTypeTmux: 2, SessionTypeTmuxinator: 1, SessionTypeDefault: 1},
+		},
+		{
+			name:     "one type",
+			sessions: []Session{{Name: "dotfiles", Type: SessionTypeDefault}},
+			want:     map[SessionType]int{SessionTypeDefault: 1},
+		},
+		{name: "empty", want: map[SessionType]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CountByType(tt.sessions); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CountByType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFilterByName tests narrowing sessions to the names matching a pattern
+func TestFilterByName(t *testing.T) {
+	sessions := []Session{
+		{Name: "api"},
+		{Name: "api-v2"},
+		{Name: "legacy-API"},
+		{Name: "web-app"},
+		{Name: "My-Notes", DisplayName: "My Notes"},
+	}
+
+	tests := []struct {
+		name      string
+		pattern   string
+		match     NameMatch
+		want      string
+		wantError bool
+	}{
+		{name: "substring ignores case", pattern: "api", want: "api,api-v2,legacy-API"},
+		{name: "substring of a display name", pattern: "my notes", want: "My-Notes"},
+		{name: "exact", pattern: "api", match: MatchExact, want: "api"},
+		{name: "exact is case-sensitive", pattern: "API", match: MatchExact, want: ""},
+		{name: "glob", pattern: "api*", match: MatchGlob, want: "api,api-v2"},
+		{name: "glob ignores case", pattern: "*-api", match: MatchGlob, want: "legacy-API"},
+		{name: "glob with a class", pattern: "[wl]*", match: MatchGlob, want: "legacy-API,web-app"},
+		{name: "no match", pattern: "nothing", want: ""},
+		{name: "empty pattern keeps everything", pattern: "", match: MatchExact, want: "api,api-v2,legacy-API,web-app,My-Notes"},
+		{name: "bad glob", pattern: "[api", match: MatchGlob, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filtered, err := FilterByName(sessions, tt.pattern, tt.match)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("FilterByName() error = %v, wantError %v", err, tt.wantError)
+			}
+
+			names := make([]string, len(filtered))
+			for i, sess := range filtered {
+				names[i] = sess.Name
+			}
+			if got := strings.Join(names, ","); got != tt.want {
+				t.Errorf("FilterByName(%q) = %s, want %s", tt.pattern, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("through ListFiltered", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, []string{"api-infra"}, []SessionConfig{{Name: "web"}})
+		sessions, _, err := manager.ListFiltered(ListOptions{Pattern: "API"})
+		if err != nil {
+			t.Fatalf("ListFiltered() returned error: %v", err)
+		}
+		if len(sessions) != 2 || sessions[0].Name != "api" || sessions[1].Name != "api-infra" {
+			t.Errorf("ListFiltered() = %+v, want api and api-infra", sessions)
+		}
+	})
+}
+
+// TestListFilteredTag tests filtering the list by tag, with tags from config and metadata
+func TestListFilteredTag(t *testing.T) {
+	manager := createTestManager(
+		[]Session{
+			{Name: "api", Type: SessionTypeTmux, IsActive: true},
+			{Name: "scratch", Type: SessionTypeTmux, IsActive: true},
+		},
+		[]string{"infra"},
+		[]SessionConfig{
+			{Name: "api", Directory: "/code/api", Tags: []string{"work"}},
+			{Name: "dotfiles", Directory: "~/dotfiles", Tags: []string{"personal"}},
+			{Name: "web", Directory: "/code/web", Tags: []string{"work", "frontend"}},
+		},
+	)
+	manager.SetMetadataStore(&fakeMetadata{values: map[string]map[string]string{
+		"infra": {MetaTags: "work, ops"},
+	}})
+
+	tests := []struct {
+		tag  string
+		want []string
+	}{
+		{tag: "work", want: []string{"api", "infra", "web"}},
+		{tag: "ops", want: []string{"infra"}},
+		{tag: UntaggedTag, want: []string{"scratch"}},
+		{tag: "nothing", want: []string{}},
+		{tag: "", want: []string{"api", "dotfiles", "infra", "scratch", "web"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			sessions, _, err := manager.ListFiltered(ListOptions{Tag: tt.tag})
+			if err != nil {
+				t.Fatalf("ListFiltered() returned error: %v", err)
+			}
+
+			got := make([]string, len(sessions))
+			for i, sess := range sessions {
+				got[i] = sess.Name
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ListFiltered(tag %q) = %v, want %v", tt.tag, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("all tags", func(t *testing.T) {
+		sessions, _, _ := manager.ListAll()
+		want := []string{"frontend", "ops", "personal", "work", UntaggedTag}
+		if got := Tags(sessions); !reflect.DeepEqual(got, want) {
+			t.Errorf("Tags() = %v, want %v", got, want)
+		}
+	})
+}
+
+// TestSyncPanes tests the SyncPanes function
+func TestSyncPanes(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      string
+		current   bool
+		want      bool
+		wantError bool
+	}{
+		{name: "toggle off to on", mode: "toggle", current: false, want: true},
+		{name: "toggle on to off", mode: "toggle", current: true, want: false},
+		{name: "on when already on", mode: "on", current: true, want: true},
+		{name: "off when on", mode: "off", current: true, want: false},
+		{name: "invalid mode", mode: "sideways", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(nil, nil, nil)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.isInsideTmux = true
+			tmuxClient.syncPanes = tt.current
+
+			got, err := manager.SyncPanes(tt.mode)
+			if tt.wantError {
+				if err == nil {
+					t.Error("SyncPanes() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SyncPanes() unexpected error: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("SyncPanes() = %v, want %v", got, tt.want)
+			}
+			if tmuxClient.syncPanes != tt.want {
+				t.Errorf("synchronize-panes set to %v, want %v", tmuxClient.syncPanes, tt.want)
+			}
+		})
+	}
+
+	t.Run("outside tmux", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+
+		if _, err := manager.SyncPanes("toggle"); err == nil {
+			t.Error("SyncPanes() expected error outside tmux but got none")
+		}
+	})
+}
+
+// TestCreateProjectSession tests that a referenced project's layout is expanded
+func TestCreateProjectSession(t *testing.T) {
+	project := &Project{
+		Directory: "/code/webapp",
+		Commands:  []string{"nvim"},
+		Windows: []WindowConfig{
+			{Name: "server", Commands: []string{"npm install", "npm run dev"}},
+			{Name: "logs", Directory: "/var/log"},
+		},
+	}
+	manager := createTestManager(nil, nil, []SessionConfig{
+		{Name: "webapp", Project: "webapp", ResolvedProject: project},
+	})
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+	if err := manager.CreateOrSwitch("webapp"); err != nil {
+		t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+	}
+
+	if len(tmuxClient.detached) != 1 || tmuxClient.detached[0].Directory != "/code/webapp" {
+		t.Fatalf("detached = %v, want one session in /code/webapp", tmuxClient.detached)
+	}
+
+	// Windows without their own directory inherit the session's
+	wantWindows := []Window{
+		{Name: "server", Directory: "/code/webapp"},
+		{Name: "logs", Directory: "/var/log"},
+	}
+	if len(tmuxClient.newWins) != len(wantWindows) {
+		t.Fatalf("created %d windows, want %d", len(tmuxClient.newWins), len(wantWindows))
+	}
+	for i, want := range wantWindows {
+		if tmuxClient.newWins[i] != want {
+			t.Errorf("window %d = %+v, want %+v", i, tmuxClient.newWins[i], want)
+		}
+	}
+
+	wantKeys := []string{"webapp nvim", "webapp:{end} npm install", "webapp:{end} npm run dev"}
+	if strings.Join(tmuxClient.sentKeys, "|") != strings.Join(wantKeys, "|") {
+		t.Errorf("sent keys = %v, want %v", tmuxClient.sentKeys, wantKeys)
+	}
+
+	if len(tmuxClient.switched) != 1 {
+		t.Errorf("switched %d times, want 1", len(tmuxClient.switched))
+	}
+}
+
+// TestCreateFlatDefaultSession tests that configs without a project still work
+func TestCreateFlatDefaultSession(t *testing.T) {
+	manager := createTestManager(nil, nil, []SessionConfig{
+		{Name: "dotfiles", Directory: "/home/me/dotfiles"},
+	})
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+	if err := manager.CreateOrSwitch("dotfiles"); err != nil {
+		t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+	}
+
+	if len(tmuxClient.created) != 1 || tmuxClient.created[0].Directory != "/home/me/dotfiles" {
+		t.Errorf("created = %v, want one session in /home/me/dotfiles", tmuxClient.created)
+	}
+	if len(tmuxClient.detached) != 0 || len(tmuxClient.sentKeys) != 0 {
+		t.Errorf("flat config should not build a layout")
+	}
+}
+
+// TestAttachTakeover tests attaching from outside tmux to a session that's open elsewhere
+func TestAttachTakeover(t *testing.T) {
+	t.Run("default attach reports the other client", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.switchErr = errors.New("exit status 1")
+		tmuxClient.attachedClients = 1
+
+		err := manager.CreateOrSwitch("api")
+		if err == nil || !strings.Contains(err.Error(), "--takeover") {
+			t.Errorf("CreateOrSwitch() error = %v, want a hint about --takeover", err)
+		}
+		if len(tmuxClient.attached) != 0 {
+			t.Errorf("attached = %v, want no forced attach", tmuxClient.attached)
+		}
+	})
+
+	t.Run("default attach succeeds", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.CreateOrSwitch("api"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+		if len(tmuxClient.switched) != 1 {
+			t.Errorf("switched %d times, want 1", len(tmuxClient.switched))
+		}
+	})
+
+	t.Run("takeover detaches other clients", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		manager.SetTakeover(true)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.CreateOrSwitch("api"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+
+		want := []attachCall{{name: "api", detachOthers: true}}
+		if len(tmuxClient.attached) != 1 || tmuxClient.attached[0] != want[0] {
+			t.Errorf("attached = %v, want %v", tmuxClient.attached, want)
+		}
+	})
+}
+
+// TestReloadSession tests the ReloadSession function
+func TestReloadSession(t *testing.T) {
+	manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+	if err := manager.ReloadSession("api"); err != nil {
+		t.Fatalf("ReloadSession() unexpected error: %v", err)
+	}
+	if len(tmuxClient.reloaded) != 1 || tmuxClient.reloaded[0] != "api" {
+		t.Errorf("reloaded = %v, want [api]", tmuxClient.reloaded)
+	}
+
+	if err := manager.ReloadSession("missing"); err == nil || errors.Is(err, ErrServerNotRunning) {
+		t.Errorf("ReloadSession() error = %v, want the session isn't running", err)
+	}
+
+	tmuxClient.serverDown = true
+	if err := manager.ReloadSession("missing"); !errors.Is(err, ErrServerNotRunning) {
+		t.Errorf("ReloadSession() error = %v, want %v", err, ErrServerNotRunning)
+	}
+}
+
+// TestCreateSessionWithOptions tests that configured options are applied after creation
+func TestCreateSessionWithOptions(t *testing.T) {
+	manager := createTestManager(nil, nil, []SessionConfig{
+		{
+			Name:      "prod",
+			Directory: "/srv/prod",
+			Options: map[string]string{
+				"status-style":       "bg=red",
+				"@env":               "production",
+				"destroy-unattached": "off",
+			},
+		},
+	})
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+	// Run it a few times - map iteration order changes, the applied order must not
+	for i := 0; i < 5; i++ {
+		tmuxClient.options = nil
+
+		if err := manager.CreateOrSwitch("prod"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+
+		want := []string{
+			"prod @env production",
+			"prod destroy-unattached off",
+			"prod status-style bg=red",
+		}
+		if strings.Join(tmuxClient.options, "|") != strings.Join(want, "|") {
+			t.Errorf("options = %v, want %v", tmuxClient.options, want)
+		}
+	}
+
+	if value, _ := tmuxClient.GetOption("prod", "destroy-unattached"); value != "off" {
+		t.Errorf("destroy-unattached = %q, want off", value)
+	}
+
+	// Options must be set before the user lands in the session
+	if len(tmuxClient.created) != 0 || len(tmuxClient.detached) == 0 {
+		t.Errorf("session should be created detached, got created=%v detached=%v", tmuxClient.created, tmuxClient.detached)
+	}
+}
+
+// TestValidateOptionName tests the tmux option name check
+func TestValidateOptionName(t *testing.T) {
+	valid := []string{"status-style", "destroy-unattached", "status-format[1]", "@theme_color", "@env"}
+	for _, key := range valid {
+		if err := ValidateOptionName(key); err != nil {
+			t.Errorf("ValidateOptionName(%q) unexpected error: %v", key, err)
+		}
+	}
+
+	invalid := []string{"", "Status-Style", "status style", "-status", "@", "status-format[x]"}
+	for _, key := range invalid {
+		if err := ValidateOptionName(key); err == nil {
+			t.Errorf("ValidateOptionName(%q) expected error but got none", key)
+		}
+	}
+}
+
+// TestEvents tests the events emitted on create, switch, and delete
+func TestEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		existing  []Session
+		deleteErr error
+		run       func(m *Manager) error
+		want      []EventType
+	}{
+		{
+			name: "create",
+			run:  func(m *Manager) error { return m.CreateOrSwitch("api") },
+			want: []EventType{EventCreated},
+		},
+		{
+			name:     "switch",
+			existing: []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			run:      func(m *Manager) error { return m.CreateOrSwitch("api") },
+			want:     []EventType{EventSwitched},
+		},
+		{
+			name:     "delete",
+			existing: []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			run:      func(m *Manager) error { return m.DeleteSession("api") },
+			want:     []EventType{EventDeleted},
+		},
+		{
+			name:      "failed delete emits nothing",
+			existing:  []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			deleteErr: errors.New("exit status 1"),
+			run:       func(m *Manager) error { return m.DeleteSession("api") },
+			want:      nil,
+		},
+		{
+			name:     "restart",
+			existing: []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			run:      func(m *Manager) error { return m.RestartSession("api") },
+			want:     []EventType{EventDeleted, EventCreated},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(tt.existing, nil, nil)
+			manager.tmuxClient.(*MockTmuxClient).deleteErr = tt.deleteErr
+			sink := &fakeSink{}
+			manager.SetEventSink(sink)
+
+			err := tt.run(manager)
+			if tt.deleteErr == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(sink.events) != len(tt.want) {
+				t.Fatalf("got %d events %v, want %v", len(sink.events), sink.events, tt.want)
+			}
+			for i, event := range sink.events {
+				if event.Type != tt.want[i] {
+					t.Errorf("event %d type = %q, want %q", i, event.Type, tt.want[i])
+				}
+				if event.Session != "api" {
+					t.Errorf("event %d session = %q, want api", i, event.Session)
+				}
+				if event.Time.IsZero() {
+					t.Errorf("event %d has no timestamp", i)
+				}
+			}
+		})
+	}
+}
+
+// TestMissingDirectory tests the decisions made when a default session's directory doesn't exist
+func TestMissingDirectory(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      MissingDirPolicy
+		confirmer   *fakeConfirmer
+		wantCreated bool // session and directory created
+		wantAsked   bool
+		wantError   bool
+	}{
+		{
+			name:        "ignore leaves it to tmux",
+			policy:      MissingDirIgnore,
+			wantCreated: true,
+		},
+		{
+			name:        "create without asking",
+			policy:      MissingDirCreate,
+			confirmer:   &fakeConfirmer{},
+			wantCreated: true,
+		},
+		{
+			name:      "abort",
+			policy:    MissingDirAbort,
+			confirmer: &fakeConfirmer{answer: true},
+			wantError: true,
+		},
+		{
+			name:        "ask and user says yes",
+			policy:      MissingDirAsk,
+			confirmer:   &fakeConfirmer{answer: true},
+			wantCreated: true,
+			wantAsked:   true,
+		},
+		{
+			name:      "ask and user says no",
+			policy:    MissingDirAsk,
+			confirmer: &fakeConfirmer{answer: false},
+			wantAsked: true,
+			wantError: true,
+		},
+		{
+			name:      "ask with nobody to ask",
+			policy:    MissingDirAsk,
+			wantError: true,
+		},
+		{
+			name:      "ask fails",
+			policy:    MissingDirAsk,
+			confirmer: &fakeConfirmer{err: errors.New("read error")},
+			wantAsked: true,
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "code", "api")
+			manager := createTestManager(nil, nil, []SessionConfig{{Name: "api", Directory: dir}})
+			manager.SetMissingDirPolicy(tt.policy)
+			if tt.confirmer != nil {
+				manager.SetConfirmer(tt.confirmer)
+			}
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			err := manager.CreateOrSwitch("api")
+			if tt.wantError && err == nil {
+				t.Error("CreateOrSwitch() expected error but got none")
+			}
+			if !tt.wantError && err != nil {
+				t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+			}
+
+			if created := len(tmuxClient.created) == 1; created != tt.wantCreated {
+				t.Errorf("session created = %v, want %v", created, tt.wantCreated)
+			}
+
+			// Ignore hands the missing directory to tmux as-is
+			_, statErr := os.Stat(dir)
+			dirCreated := statErr == nil
+			if wantDir := tt.wantCreated && tt.policy != MissingDirIgnore; dirCreated != wantDir {
+				t.Errorf("directory created = %v, want %v", dirCreated, wantDir)
+			}
+
+			asked := tt.confirmer != nil && len(tt.confirmer.questions) > 0
+			if asked != tt.wantAsked {
+				t.Errorf("asked = %v, want %v", asked, tt.wantAsked)
+			}
+			if asked && !strings.Contains(tt.confirmer.questions[0], dir) {
+				t.Errorf("question %q doesn't mention the directory", tt.confirmer.questions[0])
+			}
+		})
+	}
+
+	t.Run("existing directory is not questioned", func(t *testing.T) {
+		confirmer := &fakeConfirmer{}
+		manager := createTestManager(nil, nil, []SessionConfig{{Name: "api", Directory: t.TempDir()}})
+		manager.SetMissingDirPolicy(MissingDirAsk)
+		manager.SetConfirmer(confirmer)
+
+		if err := manager.CreateOrSwitch("api"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+		if len(confirmer.questions) != 0 {
+			t.Errorf("asked %v for a directory that exists", confirmer.questions)
+		}
+	})
+}
+
+// TestGoToSessionNotFound tests that a missing session is reported with ErrSessionNotFound
+func TestGoToSessionNotFound(t *testing.T) {
+	manager := createTestManager(nil, nil, nil)
+
+	err := manager.GoToSession("missing")
+	if !errors.Is(err, ErrSessionNotFound) {
+		t.Errorf("GoToSession() error = %v, want ErrSessionNotFound", err)
+	}
+}
+
+// TestGoToSessionRunningProject tests that a running session named after a
+// tmuxinator project is switched to, not started again (which would re-run
+// the project's setup)
+func TestGoToSessionRunningProject(t *testing.T) {
+	tests := []struct {
+		name        string
+		sessions    []Session
+		wantStarted []string
+		wantSwitch  []string
+	}{
+		{
+			name:       "already running",
+			sessions:   []Session{{Name: "blog", Type: SessionTypeTmux, IsActive: true}},
+			wantSwitch: []string{"blog"},
+		},
+		{
+			name:        "not running yet",
+			wantStarted: []string{"blog"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(tt.sessions, []string{"blog"}, nil)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxinatorClient := manager.tmuxinatorClient.(*MockTmuxinatorClient)
+
+			if err := manager.GoToSession("blog"); err != nil {
+				t.Fatalf("GoToSession() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tmuxinatorClient.started, tt.wantStarted) {
+				t.Errorf("started projects = %v, want %v", tmuxinatorClient.started, tt.wantStarted)
+			}
+			if !reflect.DeepEqual(tmuxClient.switched, tt.wantSwitch) {
+				t.Errorf("switched = %v, want %v", tmuxClient.switched, tt.wantSwitch)
+			}
+		})
+	}
+}
+
+// TestCreateWithResolver tests the resolver fallback for names found nowhere else
+func TestCreateWithResolver(t *testing.T) {
+	resolver := &fakeResolver{dirs: map[string]string{"api": "/code/api", "dotfiles": "/wrong"}}
+
+	tests := []struct {
+		name      string
+		session   string
+		resolver  *fakeResolver
+		wantDir   string
+		wantError bool
+	}{
+		{
+			name:     "resolver found a directory",
+			session:  "api",
+			resolver: resolver,
+			wantDir:  "/code/api",
+		},
+		{
+			name:     "resolver doesn't know the name",
+			session:  "scratchpad",
+			resolver: resolver,
+			wantDir:  "",
+		},
+		{
+			name:     "config wins over the resolver",
+			session:  "dotfiles",
+			resolver: resolver,
+			wantDir:  "~/dotfiles",
+		},
+		{
+			name:      "resolver error",
+			session:   "api",
+			resolver:  &fakeResolver{err: errors.New("exec: not found")},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(nil, nil, []SessionConfig{{Name: "dotfiles", Directory: "~/dotfiles"}})
+			manager.SetResolver(tt.resolver)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			err := manager.CreateOrSwitch(tt.session)
+			if tt.wantError {
+				if err == nil {
+					t.Error("CreateOrSwitch() expected error but got none")
+				}
+				if len(tmuxClient.created) != 0 {
+					t.Errorf("created = %v, want nothing after a resolver error", tmuxClient.created)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+			}
+
+			if len(tmuxClient.created) != 1 || tmuxClient.created[0].Directory != tt.wantDir {
+				t.Errorf("created = %+v, want one session in %q", tmuxClient.created, tt.wantDir)
+			}
+		})
+	}
+}
+
+// TestSessionNameForDirectory tests deriving session names from directories
+func TestSessionNameForDirectory(t *testing.T) {
+	tests := []struct {
+		dir  string
+		want string
+	}{
+		{dir: "/home/me/code/api", want: "api"},
+		{dir: "/home/me/code/api/", want: "api"},
+		{dir: "/home/me/dotfiles", want: "dotfiles"},
+		{dir: "/home/me/code/example.com", want: "example_com"},
+		{dir: "/srv/host:8080", want: "host_8080"},
+		{dir: "/home/me/.config", want: "_config"},
+		{dir: "/", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.dir, func(t *testing.T) {
+			if got := SessionNameForDirectory(tt.dir); got != tt.want {
+				t.Errorf("SessionNameForDirectory(%q) = %q, want %q", tt.dir, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestZoxide tests opening a session through a zoxide query
+func TestZoxide(t *testing.T) {
+	t.Run("creates a session named after the directory", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		manager.SetZoxide(&MockZoxideClient{installed: true, dir: "/home/me/code/example.com"})
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.Zoxide("example"); err != nil {
+			t.Fatalf("Zoxide() unexpected error: %v", err)
+		}
+
+		want := Session{Name: "example_com", Type: SessionTypeTmux, Directory: "/home/me/code/example.com"}
+		if len(tmuxClient.created) != 1 || !reflect.DeepEqual(tmuxClient.created[0], want) {
+			t.Errorf("created = %+v, want %+v", tmuxClient.created, want)
+		}
+	})
+
+	t.Run("switches when the session is already running", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		manager.SetZoxide(&MockZoxideClient{installed: true, dir: "/home/me/code/api"})
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.Zoxide("api"); err != nil {
+			t.Fatalf("Zoxide() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 0 || len(tmuxClient.switched) != 1 {
+			t.Errorf("created = %v, switched = %v, want a single switch", tmuxClient.created, tmuxClient.switched)
+		}
+	})
+
+	t.Run("no match is an error", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		manager.SetZoxide(&MockZoxideClient{installed: true, err: errors.New("no zoxide match")})
+
+		if err := manager.Zoxide("nothing"); err == nil {
+			t.Error("Zoxide() expected error but got none")
+		}
+	})
+
+	t.Run("not installed falls back to the query as a session name", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		manager.SetZoxide(&MockZoxideClient{installed: false, dir: "/should/not/be/used"})
+		var notices bytes.Buffer
+		manager.SetNotices(&notices)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.Zoxide("api"); err != nil {
+			t.Fatalf("Zoxide() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Name != "api" || tmuxClient.created[0].Directory != "" {
+			t.Errorf("created = %+v, want a plain session named api", tmuxClient.created)
+		}
+		if !strings.Contains(notices.String(), "github.com/ajeetdsouza/zoxide") {
+			t.Errorf("notices = %q, want where to install zoxide", notices.String())
+		}
+	})
+}
+
+// TestOpenDirectory tests naming a session after its directory, including
+// the directories whose basename wouldn't make a good name
+func TestOpenDirectory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tests := []struct {
+		name     string
+		dir      string
+		wantName string
+		wantDir  string
+	}{
+		{name: "basename", dir: "/code/api", wantName: "api", wantDir: "/code/api"},
+		{name: "dots and colons", dir: "/code/example.com:8080", wantName: "example_com_8080", wantDir: "/code/example.com:8080"},
+		{name: "trailing slash", dir: "/code/api/", wantName: "api", wantDir: "/code/api"},
+		{name: "home directory", dir: home, wantName: "home", wantDir: home},
+		{name: "filesystem root", dir: "/", wantName: "root", wantDir: "/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(nil, nil, nil)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			if err := manager.OpenDirectory(tt.dir); err != nil {
+				t.Fatalf("OpenDirectory() unexpected error: %v", err)
+			}
+
+			want := Session{Name: tt.wantName, Type: SessionTypeTmux, Directory: tt.wantDir}
+			if len(tmuxClient.created) != 1 || !reflect.DeepEqual(tmuxClient.created[0], want) {
+				t.Errorf("created = %+v, want %+v", tmuxClient.created, want)
+			}
+		})
+	}
+}
+
+// TestDryRunWritesNothing tests that a dry run leaves the history, events,
+// and directories alone, and says which directory it would have created
+func TestDryRunWritesNothing(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "web")
+	manager := createTestManager(nil, nil, []SessionConfig{{Name: "web", Directory: missing}})
+	manager.SetDryRun(true)
+	manager.SetMissingDirPolicy(MissingDirCreate)
+	history := &fakeHistory{}
+	manager.SetHistory(history)
+	sink := &fakeSink{}
+	manager.SetEventSink(sink)
+	var notices bytes.Buffer
+	manager.SetNotices(&notices)
+
+	if err := manager.CreateOrSwitch("web"); err != nil {
+		t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+	}
+
+	if len(history.entries) != 0 {
+		t.Errorf("history = %+v, want nothing recorded", history.entries)
+	}
+	if len(sink.events) != 0 {
+		t.Errorf("events = %+v, want none sent", sink.events)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("%s was created during a dry run", missing)
+	}
+	if !strings.Contains(notices.String(), "mkdir -p "+missing) {
+		t.Errorf("notices = %q, want the mkdir it would run", notices.String())
+	}
+}
+
+// TestHistoryRecording tests that opening sessions records them
+func TestHistoryRecording(t *testing.T) {
+	manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+	history := &fakeHistory{}
+	manager.SetHistory(history)
+
+	for _, name := range []string{"api", "web", "api"} {
+		if err := manager.CreateOrSwitch(name); err != nil {
+			t.Fatalf("CreateOrSwitch(%q) unexpected error: %v", name, err)
+		}
+	}
+	if err := manager.DeleteSession("api"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := historyNames(history.entries); got != "api,web,api" {
+		t.Errorf("history = %s, want api,web,api", got)
+	}
+}
+
+// TestCreateRenamedProject tests that a tmuxinator project whose session
+// isn't named after it is recorded under the session it actually started
+func TestCreateRenamedProject(t *testing.T) {
+	manager := createTestManager(nil, []string{"api"}, nil)
+	manager.tmuxinatorClient.(*MockTmuxinatorClient).sessionNames = map[string]string{"api": "backend"}
+	history := &fakeHistory{}
+	manager.SetHistory(history)
+	sink := &fakeSink{}
+	manager.SetEventSink(sink)
+
+	if err := manager.CreateOrSwitch("api"); err != nil {
+		t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+	}
+
+	if got := historyNames(history.entries); got != "backend" {
+		t.Errorf("history = %s, want backend", got)
+	}
+	if len(sink.events) != 1 || sink.events[0].Type != EventCreated || sink.events[0].Session != "backend" {
+		t.Errorf("events = %+v, want one created event for backend", sink.events)
+	}
+}
+
+// TestLogger tests that errors the manager carries on past are logged
+func TestLogger(t *testing.T) {
+	manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+	manager.SetHistory(&fakeHistory{recordErr: errors.New("disk full")})
+
+	var logs bytes.Buffer
+	manager.SetLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})))
+
+	if err := manager.CreateOrSwitch("api"); err != nil {
+		t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+	}
+	if !strings.Contains(logs.String(), "level=WARN") || !strings.Contains(logs.String(), `error="disk full"`) {
+		t.Errorf("logged %q, want a warning about the history", logs.String())
+	}
+
+	// Info messages are below the level, so creating a session logs nothing
+	logs.Reset()
+	manager.SetHistory(nil)
+	if err := manager.CreateOrSwitch("web"); err != nil {
+		t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+	}
+	if logs.Len() != 0 {
+		t.Errorf("logged %q at warn level, want nothing", logs.String())
+	}
+}
+
+// TestPruneHistory tests dropping history entries for sessions found in no source
+func TestPruneHistory(t *testing.T) {
+	manager := createTestManager(
+		[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+		[]string{"infra"},
+		[]SessionConfig{{Name: "dotfiles", Directory: "~/dotfiles"}},
+	)
+	history := &fakeHistory{entries: []HistoryEntry{
+		{Name: "api"}, {Name: "gone"}, {Name: "infra"}, {Name: "old-proj"}, {Name: "dotfiles"}, {Name: "api"},
+	}}
+	manager.SetHistory(history)
+
+	removed, err := manager.PruneHistory()
+	if err != nil {
+		t.Fatalf("PruneHistory() unexpected error: %v", err)
+	}
+
+	if removed != 2 {
+		t.Errorf("PruneHistory() removed %d, want 2", removed)
+	}
+	if got := historyNames(history.entries); got != "api,infra,dotfiles,api" {
+		t.Errorf("history = %s, want api,infra,dotfiles,api", got)
+	}
+
+	if err := manager.ClearHistory(); err != nil {
+		t.Fatalf("ClearHistory() unexpected error: %v", err)
+	}
+	if len(history.entries) != 0 {
+		t.Errorf("history = %v, want empty after clear", history.entries)
+	}
+}
+
+// TestFuzzyMatches tests which names count as close to a typed name
+func TestFuzzyMatches(t *testing.T) {
+	names := []string{"dotfiles", "production-db", "api", "apps"}
+
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"dotfile", "dotfiles"},   // one typo
+		{"dotflies", "dotfiles"},  // two typos
+		{"prod", "production-db"}, // prefix
+		{"ap", "api,apps"},        // several matches
+		{"api", "apps"},           // the exact name itself isn't a fuzzy match
+		{"kubernetes", ""},        // nothing close
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := strings.Join(FuzzyMatches(tt.query, names), ","); got != tt.want {
+				t.Errorf("FuzzyMatches(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGoToSessionFuzzy tests switching silently to close matches and
+// confirming matches that differ a lot from what was typed
+func TestGoToSessionFuzzy(t *testing.T) {
+	sessions := []Session{
+		{Name: "dotfiles", Type: SessionTypeTmux, IsActive: true},
+		{Name: "production-db", Type: SessionTypeTmux, IsActive: true},
+	}
+
+	tests := []struct {
+		name         string
+		query        string
+		confirmer    *fakeConfirmer
+		wantSwitch   string
+		wantAsked    bool
+		wantNotFound bool
+	}{
+		{
+			name:       "above the threshold switches silently",
+			query:      "dotfile",
+			confirmer:  &fakeConfirmer{},
+			wantSwitch: "dotfiles",
+		},
+		{
+			name:       "below the threshold confirms first",
+			query:      "prod",
+			confirmer:  &fakeConfirmer{answer: true},
+			wantSwitch: "production-db",
+			wantAsked:  true,
+		},
+		{
+			name:         "declining doesn't switch",
+			query:        "prod",
+			confirmer:    &fakeConfirmer{answer: false},
+			wantAsked:    true,
+			wantNotFound: true,
+		},
+		{
+			name:         "below the threshold without a terminal doesn't switch",
+			query:        "prod",
+			wantNotFound: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(sessions, nil, nil)
+			manager.SetFuzzy(true)
+			manager.SetFuzzyConfirmThreshold(0.7)
+			if tt.confirmer != nil {
+				manager.SetConfirmer(tt.confirmer)
+			}
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			err := manager.GoToSession(tt.query)
+			if tt.wantNotFound {
+				if !errors.Is(err, ErrSessionNotFound) {
+					t.Errorf("GoToSession(%q) error = %v, want ErrSessionNotFound", tt.query, err)
+				}
+			} else if err != nil {
+				t.Fatalf("GoToSession(%q) unexpected error: %v", tt.query, err)
+			}
+
+			if got := strings.Join(tmuxClient.switched, ","); got != tt.wantSwitch {
+				t.Errorf("switched = %q, want %q", got, tt.wantSwitch)
+			}
+			if tt.confirmer != nil {
+				if asked := len(tt.confirmer.questions) > 0; asked != tt.wantAsked {
+					t.Errorf("asked = %v (%v), want %v", asked, tt.confirmer.questions, tt.wantAsked)
+				}
+			}
+		})
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		if err := manager.GoToSession("dotfile"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("GoToSession() error = %v, want ErrSessionNotFound", err)
+		}
+	})
+
+	t.Run("asks about every match until a threshold is set", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		manager.SetFuzzy(true)
+		confirmer := &fakeConfirmer{answer: true}
+		manager.SetConfirmer(confirmer)
+
+		if err := manager.GoToSession("dotfile"); err != nil {
+			t.Fatalf("GoToSession() unexpected error: %v", err)
+		}
+		if len(confirmer.questions) != 1 {
+			t.Errorf("asked %v, want one question", confirmer.questions)
+		}
+	})
+
+	t.Run("a threshold of 0 never asks", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		manager.SetFuzzy(true)
+		manager.SetFuzzyConfirmThreshold(0)
+		confirmer := &fakeConfirmer{}
+		manager.SetConfirmer(confirmer)
+
+		if err := manager.GoToSession("prod"); err != nil {
+			t.Fatalf("GoToSession() unexpected error: %v", err)
+		}
+		if len(confirmer.questions) != 0 {
+			t.Errorf("asked %v, want no questions", confirmer.questions)
+		}
+	})
+}
+
+// TestCreateOrSwitchFuzzy tests the near-miss fallback before a new session is created
+func TestCreateOrSwitchFuzzy(t *testing.T) {
+	sessions := []Session{
+		{Name: "dotfiles", Type: SessionTypeTmux, IsActive: true},
+		{Name: "api-v1", Type: SessionTypeTmux, IsActive: true},
+		{Name: "api-v2", Type: SessionTypeTmux, IsActive: true},
+	}
+	configs := []SessionConfig{{Name: "api", Directory: "~/code/api"}}
+
+	tests := []struct {
+		name          string
+		query         string
+		wantSwitch    string
+		wantCreated   string
+		wantAmbiguous bool
+	}{
+		{
+			name:       "single match switches",
+			query:      "dotfils",
+			wantSwitch: "dotfiles",
+		},
+		{
+			name:          "several matches list the candidates",
+			query:         "api-v",
+			wantAmbiguous: true,
+		},
+		{
+			name:        "no match creates",
+			query:       "notes",
+			wantCreated: "notes",
+		},
+		{
+			name:        "default session isn't treated as a typo",
+			query:       "api",
+			wantCreated: "api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(sessions, nil, configs)
+			manager.SetFuzzy(true)
+			manager.SetFuzzyConfirmThreshold(0.7)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			err := manager.CreateOrSwitch(tt.query)
+			if tt.wantAmbiguous {
+				if !errors.Is(err, ErrAmbiguousName) {
+					t.Fatalf("CreateOrSwitch(%q) error = %v, want ErrAmbiguousName", tt.query, err)
+				}
+				if !strings.Contains(err.Error(), "api-v1, api-v2") {
+					t.Errorf("error %q doesn't list the candidates", err)
+				}
+			} else if err != nil {
+				t.Fatalf("CreateOrSwitch(%q) unexpected error: %v", tt.query, err)
+			}
+
+			if got := strings.Join(tmuxClient.switched, ","); got != tt.wantSwitch {
+				t.Errorf("switched = %q, want %q", got, tt.wantSwitch)
+			}
+			var created []string
+			for _, sess := range tmuxClient.created {
+				created = append(created, sess.Name)
+			}
+			if got := strings.Join(created, ","); got != tt.wantCreated {
+				t.Errorf("created = %q, want %q", got, tt.wantCreated)
+			}
+		})
+	}
+
+	t.Run("off by default", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.CreateOrSwitch("dotfils"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Name != "dotfils" {
+			t.Errorf("created = %v, want a new 'dotfils' session", tmuxClient.created)
+		}
+	})
+
+	t.Run("go falls back to the picker on several matches", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		manager.SetFuzzy(true)
+
+		if err := manager.GoToSession("api-v"); !errors.Is(err, ErrSessionNotFound) {
+			t.Errorf("GoToSession() error = %v, want ErrSessionNotFound", err)
+		}
+	})
+}
+
+// TestCapture tests capturing the active pane and aggregating every pane
+func TestCapture(t *testing.T) {
+	sessions := []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}
+
+	t.Run("active pane", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.paneText = map[string]string{"=api:": "$ make test\nok\n"}
+
+		text, err := manager.Capture("api", false, 100)
+		if err != nil {
+			t.Fatalf("Capture() unexpected error: %v", err)
+		}
+		if text != "$ make test\nok\n" {
+			t.Errorf("Capture() = %q", text)
+		}
+		if got := strings.Join(tmuxClient.captured, ","); got != "=api:" {
+			t.Errorf("captured %s, want =api:", got)
+		}
+	})
+
+	t.Run("all panes", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.panes = map[string][]string{"api": {"api:1.0", "api:2.0"}}
+		tmuxClient.paneText = map[string]string{
+			"=api:1.0": "server log\n",
+			"=api:2.0": "$ vim", // no trailing newline
+		}
+
+		text, err := manager.Capture("api", true, 0)
+		if err != nil {
+			t.Fatalf("Capture() unexpected error: %v", err)
+		}
+
+		want := "==> api:1.0 <==\nserver log\n\n==> api:2.0 <==\n$ vim\n"
+		if text != want {
+			t.Errorf("Capture() = %q, want %q", text, want)
+		}
+	})
+
+	t.Run("session not running", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		if _, err := manager.Capture("api", false, 0); err == nil {
+			t.Error("Capture() expected error but got none")
+		}
+	})
+}
+
+// TestMoveWindow tests moving a window between running sessions
+func TestMoveWindow(t *testing.T) {
+	sessions := []Session{
+		{Name: "api", Type: SessionTypeTmux, IsActive: true},
+		{Name: "web", Type: SessionTypeTmux, IsActive: true},
+		{Name: "notes", Type: SessionTypeTmux, IsActive: true},
+	}
+	windows := map[string][]Window{
+		"api":   {{Index: 1, Name: "editor"}, {Index: 2, Name: "logs"}},
+		"notes": {{Index: 1, Name: "vim"}},
+	}
+
+	tests := []struct {
+		name      string
+		src       string
+		index     int
+		dst       string
+		wantMoved []string
+		wantEnded bool
+		wantError bool
+	}{
+		{name: "moves the window", src: "api", index: 2, dst: "web", wantMoved: []string{"api:2 web"}},
+		{name: "last window ends the session", src: "notes", index: 1, dst: "web", wantMoved: []string{"notes:1 web"}, wantEnded: true},
+		{name: "no such window", src: "api", index: 7, dst: "web", wantError: true},
+		{name: "source not running", src: "missing", index: 1, dst: "web", wantError: true},
+		{name: "destination not running", src: "api", index: 1, dst: "missing", wantError: true},
+		{name: "same session", src: "api", index: 1, dst: "api", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(sessions, nil, nil)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.windows = windows
+
+			ended, err := manager.MoveWindow(tt.src, tt.index, tt.dst)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("MoveWindow() error = %v, wantError %v", err, tt.wantError)
+			}
+			if ended != tt.wantEnded {
+				t.Errorf("MoveWindow() ended = %v, want %v", ended, tt.wantEnded)
+			}
+			if !reflect.DeepEqual(tmuxClient.moved, tt.wantMoved) {
+				t.Errorf("moved = %v, want %v", tmuxClient.moved, tt.wantMoved)
+			}
+		})
+	}
+}
+
+// TestPeek tests showing a session's screen without the padding below it
+func TestPeek(t *testing.T) {
+	sessions := []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}
+
+	tests := []struct {
+		name   string
+		screen string
+		want   string
+	}{
+		{name: "trailing blank lines", screen: "$ make test\nok\n\n  \n\n", want: "$ make test\nok\n"},
+		{name: "blank lines in between", screen: "one\n\ntwo\n", want: "one\n\ntwo\n"},
+		{name: "empty screen", screen: "\n\n\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(sessions, nil, nil)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.paneText = map[string]string{"=api:": tt.screen}
+
+			text, err := manager.Peek("api")
+			if err != nil {
+				t.Fatalf("Peek() unexpected error: %v", err)
+			}
+			if text != tt.want {
+				t.Errorf("Peek() = %q, want %q", text, tt.want)
+			}
+		})
+	}
+
+	t.Run("session not running", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		if _, err := manager.Peek("api"); err == nil {
+			t.Error("Peek() expected error but got none")
+		}
+	})
+}
+
+// TestSlugify tests turning messy names into ones tmux accepts
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"api", "api"},
+		{"My Notes", "My-Notes"},
+		{"  padded  name  ", "padded-name"},
+		{"tabs\tand\nnewlines", "tabs-and-newlines"},
+		{"example.com", "example_com"},
+		{"host:8080", "host_8080"},
+		{"a - b", "a-b"},
+		{"--leading and trailing--", "leading-and-trailing"},
+		{".config", "_config"},
+		{"café notes", "café-notes"},
+		{"   ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := slugify(tt.input); got != tt.want {
+				t.Errorf("slugify(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestNormalizeNames tests creating sessions under a slugified name
+func TestNormalizeNames(t *testing.T) {
+	t.Run("creates under the slug and keeps the original", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		manager.SetNormalizeNames(true)
+		sink := &fakeSink{}
+		manager.SetEventSink(sink)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.CreateOrSwitch("My Notes"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+
+		want := Session{Name: "My-Notes", DisplayName: "My Notes", Type: SessionTypeTmux}
+		if len(tmuxClient.created) != 1 || !reflect.DeepEqual(tmuxClient.created[0], want) {
+			t.Errorf("created = %+v, want %+v", tmuxClient.created, want)
+		}
+		if len(sink.events) != 1 || sink.events[0].Session != "My-Notes" {
+			t.Errorf("events = %+v, want created My-Notes", sink.events)
+		}
+	})
+
+	t.Run("switches to the slug when it's running", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "My-Notes", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		manager.SetNormalizeNames(true)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.CreateOrSwitch("My Notes"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 0 || strings.Join(tmuxClient.switched, ",") != "My-Notes" {
+			t.Errorf("created = %v, switched = %v, want a switch to My-Notes", tmuxClient.created, tmuxClient.switched)
+		}
+	})
+
+	t.Run("off leaves names alone", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.CreateOrSwitch("My Notes"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Name != "My Notes" || tmuxClient.created[0].DisplayName != "" {
+			t.Errorf("created = %+v, want My Notes unchanged", tmuxClient.created)
+		}
+	})
+
+	t.Run("directory sessions", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		manager.SetNormalizeNames(true)
+		manager.SetZoxide(&MockZoxideClient{installed: true, dir: "/home/me/My Project"})
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.Zoxide("project"); err != nil {
+			t.Fatalf("Zoxide() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Name != "My-Project" || tmuxClient.created[0].DisplayName != "My Project" {
+			t.Errorf("created = %+v, want My-Project displayed as My Project", tmuxClient.created)
+		}
+	})
+}
+
+// TestSwitchToLastOutsideTmux tests falling back to the history when tmux has no previous session
+func TestSwitchToLastOutsideTmux(t *testing.T) {
+	running := []Session{
+		{Name: "api", Type: SessionTypeTmux, IsActive: true},
+		{Name: "web", Type: SessionTypeTmux, IsActive: true},
+	}
+
+	tests := []struct {
+		name       string
+		history    []string // oldest first
+		wantSwitch string
+		wantErr    bool
+	}{
+		{name: "most recent session", history: []string{"web", "api"}, wantSwitch: "api"},
+		{name: "skips sessions that aren't running", history: []string{"web", "api", "gone"}, wantSwitch: "api"},
+		{name: "nothing running", history: []string{"gone"}, wantErr: true},
+		{name: "empty history", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(running, nil, nil)
+			history := &fakeHistory{}
+			for _, name := range tt.history {
+				history.entries = append(history.entries, HistoryEntry{Name: name})
+			}
+			manager.SetHistory(history)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			err := manager.SwitchToLast(1)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("SwitchToLast() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SwitchToLast() unexpected error: %v", err)
+			}
+
+			if got := strings.Join(tmuxClient.switched, ","); got != tt.wantSwitch {
+				t.Errorf("switched = %q, want %q", got, tt.wantSwitch)
+			}
+		})
+	}
+
+	t.Run("inside tmux with nothing to go back to asks tmux", func(t *testing.T) {
+		manager := createTestManager(running, nil, nil)
+		manager.SetHistory(&fakeHistory{entries: []HistoryEntry{{Name: "api"}}})
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.isInsideTmux = true
+		tmuxClient.current = "api"
+		tmuxClient.lastSessionErr = errors.New("no last session")
+
+		if err := manager.SwitchToLast(1); err == nil || len(tmuxClient.switched) != 0 {
+			t.Errorf("SwitchToLast() = %v, switched = %v, want tmux's own error", err, tmuxClient.switched)
+		}
+	})
+}
+
+// TestSwitchToLastBack tests going back through the history inside tmux,
+// and pruning the sessions that are gone on the way
+func TestSwitchToLastBack(t *testing.T) {
+	running := []Session{
+		{Name: "api", Type: SessionTypeTmux, IsActive: true},
+		{Name: "web", Type: SessionTypeTmux, IsActive: true},
+		{Name: "notes", Type: SessionTypeTmux, IsActive: true},
+	}
+	configs := []SessionConfig{{Name: "dotfiles"}}
+
+	// Oldest first; web is current, and gone and dotfiles aren't running
+	history := []string{"notes", "gone", "dotfiles", "api", "notes", "api", "web"}
+
+	tests := []struct {
+		name       string
+		back       int
+		wantSwitch string
+		wantErr    bool
+	}{
+		{name: "previous session", back: 1, wantSwitch: "api"},
+		{name: "repeats count once", back: 2, wantSwitch: "notes"},
+		{name: "further back than the history", back: 3, wantErr: true},
+		{name: "zero", back: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(running, nil, configs)
+			store := &fakeHistory{}
+			for _, name := range history {
+				store.entries = append(store.entries, HistoryEntry{Name: name})
+			}
+			manager.SetHistory(store)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.isInsideTmux = true
+			tmuxClient.current = "web"
+
+			err := manager.SwitchToLast(tt.back)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SwitchToLast(%d) error = %v, wantErr %v", tt.back, err, tt.wantErr)
+			}
+			if got := strings.Join(tmuxClient.switched, ","); got != tt.wantSwitch {
+				t.Errorf("switched = %q, want %q", got, tt.wantSwitch)
+			}
+			if !tt.wantErr && store.entries[len(store.entries)-1].Name != tt.wantSwitch {
+				t.Errorf("history = %s, want %s recorded last", historyNames(store.entries), tt.wantSwitch)
+			}
+			if tt.back > 0 && strings.Contains(historyNames(store.entries), "gone") {
+				t.Errorf("history = %s, want gone pruned", historyNames(store.entries))
+			}
+			if tt.back > 0 && !strings.Contains(historyNames(store.entries), "dotfiles") {
+				t.Errorf("history = %s, want the stopped default session kept", historyNames(store.entries))
+			}
+		})
+	}
+}
+
+// TestResolveSession tests which source a name resolves to, with a name in
+// several sources going to the one sess opens first
+func TestResolveSession(t *testing.T) {
+	manager := createTestManager(
+		[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+		[]string{"api", "blog"},
+		[]SessionConfig{{Name: "api"}, {Name: "blog"}, {Name: "notes"}},
+	)
+
+	tests := []struct {
+		name       string
+		wantType   SessionType
+		wantExists bool
+	}{
+		{name: "api", wantType: SessionTypeTmux, wantExists: true},
+		{name: "blog", wantType: SessionTypeTmuxinator, wantExists: true},
+		{name: "notes", wantType: SessionTypeDefault, wantExists: true},
+		{name: "nope"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			typ, exists, err := manager.ResolveSession(tt.name)
+			if err != nil {
+				t.Fatalf("ResolveSession() unexpected error: %v", err)
+			}
+			if typ != tt.wantType || exists != tt.wantExists {
+				t.Errorf("ResolveSession(%q) = %q, %v, want %q, %v", tt.name, typ, exists, tt.wantType, tt.wantExists)
+			}
+		})
+	}
+}
+
+// TestStopProject tests that only tmuxinator projects are stopped, and
+// that a failed stop of a project that isn't running says so
+func TestStopProject(t *testing.T) {
+	running := []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}, {Name: "notes", Type: SessionTypeTmux, IsActive: true}}
+
+	tests := []struct {
+		name         string
+		stop         string
+		notInstalled bool
+		stopErr      error
+		wantStopped  bool
+		wantErr      string
+	}{
+		{name: "running project", stop: "api", wantStopped: true},
+		{name: "tmux session", stop: "notes", wantErr: "use 'sess delete'"},
+		{name: "not a project", stop: "nope", wantErr: "not a tmuxinator project"},
+		{name: "tmuxinator not installed", stop: "api", notInstalled: true, wantErr: "not installed"},
+		{name: "project that isn't running", stop: "blog", stopErr: errors.New("can't find session: blog"), wantErr: "isn't running"},
+		{name: "failed stop", stop: "api", stopErr: errors.New("can't find session: api"), wantErr: "can't find session"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(running, []string{"api", "blog"}, nil)
+			sink := &fakeSink{}
+			manager.SetEventSink(sink)
+			tmuxinatorClient := manager.tmuxinatorClient.(*MockTmuxinatorClient)
+			tmuxinatorClient.isInstalled = !tt.notInstalled
+			tmuxinatorClient.stopErr = tt.stopErr
+
+			err := manager.StopProject(tt.stop)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("StopProject() error = %v, want it to say %q", err, tt.wantErr)
+				}
+				if len(sink.events) != 0 {
+					t.Errorf("events = %+v, want none", sink.events)
+				}
+			} else if err != nil {
+				t.Fatalf("StopProject() unexpected error: %v", err)
+			}
+
+			if stopped := len(tmuxinatorClient.stopped) == 1 && tt.stopErr == nil; stopped != tt.wantStopped {
+				t.Errorf("stopped = %v, want stopped: %v", tmuxinatorClient.stopped, tt.wantStopped)
+			}
+			if tt.wantStopped && (len(sink.events) != 1 || sink.events[0].Type != EventDeleted) {
+				t.Errorf("events = %+v, want one deleted event", sink.events)
+			}
+		})
+	}
+}
+
+// TestTeardown tests routing deletes of tmuxinator projects through tmuxinator stop
+func TestTeardown(t *testing.T) {
+	sessions := []Session{
+		{Name: "api", Type: SessionTypeTmux, IsActive: true},
+		{Name: "scratch", Type: SessionTypeTmux, IsActive: true},
+	}
+
+	tests := []struct {
+		name          string
+		session       string
+		opts          TeardownOptions
+		confirmer     *fakeConfirmer
+		want          TeardownResult
+		wantQuestions int
+	}{
+		{name: "flag stops the project", session: "api", opts: TeardownOptions{StopProject: true}, confirmer: &fakeConfirmer{answer: true}, want: TeardownStopped, wantQuestions: 1},
+		{name: "asks and stops", session: "api", confirmer: &fakeConfirmer{answer: true}, want: TeardownStopped, wantQuestions: 2},
+		{name: "asks and kills", session: "api", confirmer: &fakeConfirmer{answers: []bool{true, false}}, want: TeardownDeleted, wantQuestions: 2},
+		{name: "declined", session: "scratch", confirmer: &fakeConfirmer{answer: false}, want: TeardownKept, wantQuestions: 1},
+		{name: "not a project kills after asking once", session: "scratch", opts: TeardownOptions{StopProject: true}, confirmer: &fakeConfirmer{answer: true}, want: TeardownDeleted, wantQuestions: 1},
+		{name: "force kills without asking", session: "api", opts: TeardownOptions{Force: true}, confirmer: &fakeConfirmer{answer: true}, want: TeardownDeleted},
+		{name: "force stops without asking", session: "api", opts: TeardownOptions{Force: true, StopProject: true}, want: TeardownStopped},
+		{name: "force with no one to ask", session: "scratch", opts: TeardownOptions{Force: true}, want: TeardownDeleted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(sessions, []string{"api"}, nil)
+			if tt.confirmer != nil {
+				manager.SetConfirmer(tt.confirmer)
+			}
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxinatorClient := manager.tmuxinatorClient.(*MockTmuxinatorClient)
+
+			result, err := manager.Teardown(tt.session, tt.opts)
+			if err != nil {
+				t.Fatalf("Teardown() unexpected error: %v", err)
+			}
+			if result != tt.want {
+				t.Errorf("Teardown() = %v, want %v", result, tt.want)
+			}
+
+			switch tt.want {
+			case TeardownStopped:
+				if strings.Join(tmuxinatorClient.stopped, ",") != tt.session || len(tmuxClient.deleted) != 0 {
+					t.Errorf("stopped = %v, deleted = %v, want only tmuxinator stop", tmuxinatorClient.stopped, tmuxClient.deleted)
+				}
+			case TeardownDeleted:
+				if len(tmuxinatorClient.stopped) != 0 || strings.Join(tmuxClient.deleted, ",") != tt.session {
+					t.Errorf("stopped = %v, deleted = %v, want only kill-session", tmuxinatorClient.stopped, tmuxClient.deleted)
+				}
+			default:
+				if len(tmuxinatorClient.stopped) != 0 || len(tmuxClient.deleted) != 0 {
+					t.Errorf("stopped = %v, deleted = %v, want nothing", tmuxinatorClient.stopped, tmuxClient.deleted)
+				}
+			}
+
+			if tt.confirmer != nil && len(tt.confirmer.questions) != tt.wantQuestions {
+				t.Errorf("asked %q, want %d questions", tt.confirmer.questions, tt.wantQuestions)
+			}
+		})
+	}
+
+	t.Run("says what a name that isn't running is", func(t *testing.T) {
+		manager := createTestManager(sessions, []string{"blog"}, []SessionConfig{{Name: "notes"}})
+
+		for name, want := range map[string]string{
+			"blog":  "tmuxinator project that isn't running",
+			"notes": "default session that isn't running",
+			"nope":  "does not exist",
+		} {
+			if _, err := manager.Teardown(name, TeardownOptions{Force: true}); err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("Teardown(%q) error = %v, want it to say %q", name, err, want)
+			}
+		}
+	})
+
+	t.Run("says how many windows", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		confirmer := &fakeConfirmer{}
+		manager.SetConfirmer(confirmer)
+		manager.tmuxClient.(*MockTmuxClient).windows = map[string][]Window{"scratch": {{Index: 1}, {Index: 2}}}
+
+		if _, err := manager.Teardown("scratch", TeardownOptions{}); err != nil {
+			t.Fatalf("Teardown() unexpected error: %v", err)
+		}
+		want := "Delete session 'scratch' (2 windows)?"
+		if len(confirmer.questions) != 1 || confirmer.questions[0] != want {
+			t.Errorf("asked %q, want %q", confirmer.questions, want)
+		}
+	})
+
+	t.Run("refuses with no one to ask", func(t *testing.T) {
+		manager := createTestManager(sessions, nil, nil)
+		if _, err := manager.Teardown("scratch", TeardownOptions{}); err == nil {
+			t.Error("Teardown() expected error but got none")
+		}
+		if deleted := manager.tmuxClient.(*MockTmuxClient).deleted; len(deleted) != 0 {
+			t.Errorf("deleted = %v, want nothing", deleted)
+		}
+	})
+
+	t.Run("session that isn't running", func(t *testing.T) {
+		manager := createTestManager(nil, []string{"api"}, nil)
+		if _, err := manager.Teardown("api", TeardownOptions{Force: true, StopProject: true}); err == nil {
+			t.Error("Teardown() expected error but got none")
+		}
+	})
+}
+
+// fakeMetadata is an in-memory MetadataStore
+type fakeMetadata struct {
+	values map[string]map[string]string
+	err    error
+	loads  int
+}
+
+func (f *fakeMetadata) Load() (map[string]map[string]string, error) {
+	f.loads++
+	return f.values, f.err
+}
+
+func (f *fakeMetadata) Set(name, key, value string) error {
+	if f.values[name] == nil {
+		f.values[name] = map[string]string{}
+	}
+	f.values[name][key] = value
+	return nil
+}
+
+// TestAttachTags tests tagging sessions listed outside ListAll, as
+// sess list --all-sockets does
+func TestAttachTags(t *testing.T) {
+	manager := createTestManager(nil, nil, []SessionConfig{
+		{Name: "api", Directory: "/code/api", Tags: []string{"work"}},
+	})
+	manager.SetMetadataStore(&fakeMetadata{values: map[string]map[string]string{
+		"web": {MetaTags: "frontend"},
+	}})
+
+	sessions := []Session{
+		{Name: "api", Type: SessionTypeTmux, Socket: "work"},
+		{Name: "web", Type: SessionTypeTmux, Socket: "default"},
+		{Name: "scratch", Type: SessionTypeTmux, Socket: "default"},
+	}
+	if warnings := manager.AttachTags(sessions); len(warnings) != 0 {
+		t.Errorf("AttachTags() warnings = %v, want none", warnings)
+	}
+
+	want := map[string][]string{"api": {"work"}, "web": {"frontend"}, "scratch": nil}
+	for _, sess := range sessions {
+		if !reflect.DeepEqual(sess.Tags, want[sess.Name]) {
+			t.Errorf("%s tags = %v, want %v", sess.Name, sess.Tags, want[sess.Name])
+		}
+	}
+	if got := FilterByTag(sessions, "work"); len(got) != 1 || got[0].Name != "api" {
+		t.Errorf("FilterByTag(work) = %v, want api", got)
+	}
+}
+
+// TestListAllMetadata tests enriching a mixed session list from one metadata load
+func TestListAllMetadata(t *testing.T) {
+	manager := createTestManager(
+		[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+		[]string{"infra"},
+		[]SessionConfig{{Name: "dotfiles", Directory: "~/dotfiles"}},
+	)
+	store := &fakeMetadata{values: map[string]map[string]string{
+		"api":      {MetaNote: "deploy on fridays"},
+		"infra":    {MetaTags: "work,ops"},
+		"archived": {MetaNote: "not listed anywhere"},
+	}}
+	manager.SetMetadataStore(store)
+
+	sessions, _, err := manager.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll() unexpected error: %v", err)
+	}
+
+	want := map[string]map[string]string{
+		"api":      {MetaNote: "deploy on fridays"},
+		"infra":    {MetaTags: "work,ops"},
+		"dotfiles": nil,
+	}
+	if len(sessions) != len(want) {
+		t.Fatalf("ListAll() returned %d sessions, want %d", len(sessions), len(want))
+	}
+	for _, sess := range sessions {
+		if !reflect.DeepEqual(sess.Metadata, want[sess.Name]) {
+			t.Errorf("%s metadata = %v, want %v", sess.Name, sess.Metadata, want[sess.Name])
+		}
+	}
+	if store.loads != 1 {
+		t.Errorf("metadata loaded %d times, want once", store.loads)
+	}
+
+	t.Run("unreadable store lists without metadata", func(t *testing.T) {
+		store.err = errors.New("corrupt")
+		sessions, warnings, err := manager.ListAll()
+		if err != nil || len(sessions) != 3 || sessions[0].Metadata != nil {
+			t.Errorf("ListAll() = %+v, %v, want the sessions without metadata", sessions, err)
+		}
+		if len(warnings) != 1 || warnings[0].Source != "metadata" {
+			t.Errorf("ListAll() warnings = %v, want one metadata warning", warnings)
+		}
+	})
+}
+
+// TestPreviousUnique tests picking earlier sessions past the last two distinct ones
+func TestPreviousUnique(t *testing.T) {
+	tests := []struct {
+		name    string
+		history string // oldest first
+		want    string // most recent first
+	}{
+		{name: "ping-pong", history: "a,b,c,b,c", want: "a"},
+		{name: "repeats collapse", history: "a,b,a,c,d,c,d", want: "a,b"},
+		{name: "same session twice in a row", history: "a,b,c,c", want: "a"},
+		{name: "only two sessions", history: "a,b,a,b", want: ""},
+		{name: "empty", history: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var entries []HistoryEntry
+			if tt.history != "" {
+				for _, name := range strings.Split(tt.history, ",") {
+					entries = append(entries, HistoryEntry{Name: name})
+				}
+			}
+
+			if got := strings.Join(previousUnique(entries, 2), ","); got != tt.want {
+				t.Errorf("previousUnique(%s) = %q, want %q", tt.history, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPrevUnique tests switching to the earlier session and skipping ones that are gone
+func TestPrevUnique(t *testing.T) {
+	manager := createTestManager([]Session{
+		{Name: "a", Type: SessionTypeTmux, IsActive: true},
+		{Name: "b", Type: SessionTypeTmux, IsActive: true},
+		{Name: "c", Type: SessionTypeTmux, IsActive: true},
+	}, nil, nil)
+	history := &fakeHistory{}
+	for _, name := range []string{"a", "gone", "b", "c", "b", "c"} {
+		history.entries = append(history.entries, HistoryEntry{Name: name})
+	}
+	manager.SetHistory(history)
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+	// Cycling visits a, then b, then c - never stuck between two
+	for _, want := range []string{"a", "b", "c"} {
+		if err := manager.PrevUnique(); err != nil {
+			t.Fatalf("PrevUnique() unexpected error: %v", err)
+		}
+		if got := tmuxClient.switched[len(tmuxClient.switched)-1]; got != want {
+			t.Errorf("switched to %q, want %q", got, want)
+		}
+	}
+
+	if err := createTestManager(nil, nil, nil).PrevUnique(); err == nil {
+		t.Error("PrevUnique() with no history expected error but got none")
+	}
+}
+
+// TestNewSession tests creating sessions with and without the current session's environment
+func TestNewSession(t *testing.T) {
+	env := map[string]string{"AWS_PROFILE": "staging", "KUBECONFIG": "/tmp/kube"}
+
+	t.Run("clones the current session's environment", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "work", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.isInsideTmux = true
+		tmuxClient.current = "work"
+		tmuxClient.env = map[string]map[string]string{"work": env}
+
+		if err := manager.NewSession("spike", true); err != nil {
+			t.Fatalf("NewSession() unexpected error: %v", err)
+		}
+
+		want := Session{Name: "spike", Type: SessionTypeTmux, Environment: env}
+		if len(tmuxClient.created) != 1 || !reflect.DeepEqual(tmuxClient.created[0], want) {
+			t.Errorf("created = %+v, want %+v", tmuxClient.created, want)
+		}
+	})
+
+	t.Run("without the flag the environment isn't read", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.isInsideTmux = true
+		tmuxClient.current = "work"
+		tmuxClient.env = map[string]map[string]string{"work": env}
+
+		if err := manager.NewSession("spike", false); err != nil {
+			t.Fatalf("NewSession() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Environment != nil {
+			t.Errorf("created = %+v, want no environment", tmuxClient.created)
+		}
+	})
+
+	t.Run("outside tmux", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		if err := manager.NewSession("spike", true); err == nil {
+			t.Error("NewSession() expected error but got none")
+		}
+	})
+
+	t.Run("already running", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "spike", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+		if err := manager.NewSession("spike", false); err == nil {
+			t.Error("NewSession() expected error but got none")
+		}
+	})
+
+	t.Run("sanitizes the name", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.isInsideTmux = true
+		tmuxClient.current = "work"
+
+		if err := manager.NewSession(" v1.2 ", false); err != nil {
+			t.Fatalf("NewSession() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Name != "v1_2" {
+			t.Errorf("created = %+v, want v1_2", tmuxClient.created)
+		}
+	})
+}
+
+// TestSanitizeName tests turning names into ones tmux accepts
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name        string
+		want        string
+		wantChanged bool
+	}{
+		{name: "api", want: "api"},
+		{name: "my.project", want: "my_project", wantChanged: true},
+		{name: "host:8080", want: "host_8080", wantChanged: true},
+		{name: "my notes", want: "my notes"},
+		{name: "  api\t", want: "api", wantChanged: true},
+		{name: " example.com:443 ", want: "example_com_443", wantChanged: true},
+		{name: "   ", want: "", wantChanged: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, changed := SanitizeName(tt.name)
+			if got != tt.want || changed != tt.wantChanged {
+				t.Errorf("SanitizeName(%q) = (%q, %v), want (%q, %v)", tt.name, got, changed, tt.want, tt.wantChanged)
+			}
+		})
+	}
+}
+
+// TestDetached tests that with SetDetached, sessions are created in the
+// background and nothing is switched to or attached
+func TestDetached(t *testing.T) {
+	configs := []SessionConfig{
+		{Name: "dotfiles", Directory: "/tmp"},
+		{Name: "api", Directory: "/tmp", Commands: []string{"make dev"}},
+		{Name: "blog", TmuxinatorProject: "blog"},
+	}
+
+	tests := []struct {
+		name         string
+		open         string
+		inTmux       bool
+		wantDetached string
+		wantProject  string
+	}{
+		{name: "plain session outside tmux", open: "spike", wantDetached: "spike"},
+		{name: "plain session inside tmux", open: "spike", inTmux: true, wantDetached: "spike"},
+		{name: "default session", open: "dotfiles", wantDetached: "dotfiles"},
+		{name: "default session with commands", open: "api", wantDetached: "api"},
+		{name: "tmuxinator project", open: "infra", wantProject: "infra"},
+		{name: "default session started by tmuxinator", open: "blog", wantProject: "blog"},
+		{name: "already running", open: "web"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager([]Session{{Name: "web", Type: SessionTypeTmux, IsActive: true}}, []string{"infra"}, configs)
+			manager.SetDetached(true)
+			history := &fakeHistory{}
+			manager.SetHistory(history)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.isInsideTmux = tt.inTmux
+			tmuxinatorClient := manager.tmuxinatorClient.(*MockTmuxinatorClient)
+			tmuxinatorClient.isInstalled = true
+
+			if err := manager.CreateOrSwitch(tt.open); err != nil {
+				t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+			}
+
+			if len(tmuxClient.switched) != 0 || len(tmuxClient.attached) != 0 || len(tmuxClient.created) != 0 || len(tmuxinatorClient.started) != 0 {
+				t.Errorf("switched %v, attached %v, created %v, started %v: want none", tmuxClient.switched, tmuxClient.attached, tmuxClient.created, tmuxinatorClient.started)
+			}
+			if tt.wantDetached != "" && (len(tmuxClient.detached) != 1 || tmuxClient.detached[0].Name != tt.wantDetached) {
+				t.Errorf("created detached %+v, want %s", tmuxClient.detached, tt.wantDetached)
+			}
+			if tt.wantProject != "" && strings.Join(tmuxinatorClient.startedDetached, ",") != tt.wantProject {
+				t.Errorf("started detached %v, want %s", tmuxinatorClient.startedDetached, tt.wantProject)
+			}
+			if len(history.entries) != 0 {
+				t.Errorf("recorded %v in the history, want nothing", history.entries)
+			}
+		})
+	}
+
+	t.Run("new session", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		manager.SetDetached(true)
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+		if err := manager.NewSession("spike", false); err != nil {
+			t.Fatalf("NewSession() unexpected error: %v", err)
+		}
+		if len(tmuxClient.created) != 0 || len(tmuxClient.detached) != 1 || tmuxClient.detached[0].Name != "spike" {
+			t.Errorf("created %+v, detached %+v, want only spike detached", tmuxClient.created, tmuxClient.detached)
+		}
+	})
+}
+
+// TestBringUpDefaults tests that sess up starts the default sessions that
+// aren't running, in the background, and skips the rest
+func TestBringUpDefaults(t *testing.T) {
+	configs := []SessionConfig{
+		{Name: "api", Directory: "/tmp", Tags: []string{"work"}},
+		{Name: "web", Directory: "/tmp", Tags: []string{"work"}},
+		{Name: "notes", Directory: "/tmp"},
+		{Name: "blog", TmuxinatorProject: "blog"},
+	}
+
+	tests := []struct {
+		name        string
+		opts        UpOptions
+		wantCreated []string
+		wantSkipped []string
+		wantErr     bool
+	}{
+		{name: "everything", wantCreated: []string{"api", "notes", "blog"}, wantSkipped: []string{"web"}},
+		{name: "only", opts: UpOptions{Only: []string{"api", "web"}}, wantCreated: []string{"api"}, wantSkipped: []string{"web"}},
+		{name: "only an unknown session", opts: UpOptions{Only: []string{"nope"}}, wantErr: true},
+		{name: "tag", opts: UpOptions{Tag: "work"}, wantCreated: []string{"api"}, wantSkipped: []string{"web"}},
+		{name: "untagged", opts: UpOptions{Tag: UntaggedTag}, wantCreated: []string{"notes", "blog"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager([]Session{{Name: "web", Type: SessionTypeTmux, IsActive: true}}, []string{"blog"}, configs)
+			history := &fakeHistory{}
+			manager.SetHistory(history)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxinatorClient := manager.tmuxinatorClient.(*MockTmuxinatorClient)
+
+			result, err := manager.BringUpDefaults(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BringUpDefaults() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(tmuxClient.detached) != 0 {
+					t.Errorf("created %+v, want nothing", tmuxClient.detached)
+				}
+				return
+			}
+
+			if !reflect.DeepEqual(result.Created, tt.wantCreated) || !reflect.DeepEqual(result.Skipped, tt.wantSkipped) {
+				t.Errorf("created %v, skipped %v, want %v and %v", result.Created, result.Skipped, tt.wantCreated, tt.wantSkipped)
+			}
+			if len(tmuxClient.switched) != 0 || len(tmuxClient.attached) != 0 || len(tmuxClient.created) != 0 || len(tmuxinatorClient.started) != 0 {
+				t.Errorf("switched %v, attached %v, created %v, started %v: want none", tmuxClient.switched, tmuxClient.attached, tmuxClient.created, tmuxinatorClient.started)
+			}
+			var built []string
+			for _, sess := range tmuxClient.detached {
+				built = append(built, sess.Name)
+			}
+			built = append(built, tmuxinatorClient.startedDetached...)
+			if len(built) != len(tt.wantCreated) {
+				t.Errorf("built %v, want %v", built, tt.wantCreated)
+			}
+			if len(history.entries) != 0 {
+				t.Errorf("recorded %v in the history, want nothing", history.entries)
+			}
+		})
+	}
+
+	t.Run("keeps going after a failure", func(t *testing.T) {
+		manager := createTestManager(nil, nil, configs[:3])
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		tmuxClient.createErr = errors.New("boom")
+
+		result, err := manager.BringUpDefaults(UpOptions{})
+		if err == nil {
+			t.Fatal("BringUpDefaults() expected an error")
+		}
+		for _, name := range []string{"api", "web", "notes"} {
+			if !strings.Contains(err.Error(), name) {
+				t.Errorf("error %q doesn't mention %s", err, name)
+			}
+		}
+		if len(result.Created) != 0 || len(tmuxClient.detached) != 3 {
+			t.Errorf("created %v after %d attempts, want none after 3", result.Created, len(tmuxClient.detached))
+		}
+	})
+}
+
+// TestOnAttach tests that a default session's on_attach command runs when
+// switching to it, and only then
+func TestOnAttach(t *testing.T) {
+	configs := []SessionConfig{{Name: "api", OnAttach: "git fetch"}, {Name: "notes"}}
+
+	tests := []struct {
+		name      string
+		sessions  []Session
+		open      string
+		inTmux    bool
+		skipHooks bool
+		wantKeys  []string
+	}{
+		{
+			name:     "switch inside tmux",
+			sessions: []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			open:     "api",
+			inTmux:   true,
+			wantKeys: []string{"=api: git fetch"},
+		},
+		{
+			name:     "attach from outside tmux",
+			sessions: []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			open:     "api",
+			wantKeys: []string{"=api: git fetch"},
+		},
+		{
+			name:      "hooks skipped",
+			sessions:  []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}},
+			open:      "api",
+			inTmux:    true,
+			skipHooks: true,
+		},
+		{
+			name:     "no hook configured",
+			sessions: []Session{{Name: "notes", Type: SessionTypeTmux, IsActive: true}},
+			open:     "notes",
+			inTmux:   true,
+		},
+		{
+			name:   "not when creating the session",
+			open:   "api",
+			inTmux: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(tt.sessions, nil, configs)
+			manager.SetSkipHooks(tt.skipHooks)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.isInsideTmux = tt.inTmux
+
+			if err := manager.CreateOrSwitch(tt.open); err != nil {
+				t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(tmuxClient.sentKeys, tt.wantKeys) {
+				t.Errorf("sent keys = %q, want %q", tmuxClient.sentKeys, tt.wantKeys)
+			}
+		})
+	}
+
+	t.Run("not when listing", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}, nil, configs)
+		if _, _, err := manager.ListAll(); err != nil {
+			t.Fatalf("ListAll() unexpected error: %v", err)
+		}
+		if sent := manager.tmuxClient.(*MockTmuxClient).sentKeys; len(sent) != 0 {
+			t.Errorf("sent keys = %q, want none", sent)
+		}
+	})
+}
+
+// TestCreateOrSwitchSanitizesName tests that new sessions get a name tmux
+// accepts, and that the user is told when it isn't the one they typed
+func TestCreateOrSwitchSanitizesName(t *testing.T) {
+	t.Run("creates under the sanitized name", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		var notices bytes.Buffer
+		manager.SetNotices(&notices)
+
+		if err := manager.CreateOrSwitch("my.project"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Name != "my_project" {
+			t.Errorf("created = %+v, want my_project", tmuxClient.created)
+		}
+		if !strings.Contains(notices.String(), "'my_project'") {
+			t.Errorf("notices = %q, want it to name my_project", notices.String())
+		}
+	})
+
+	t.Run("switches to the running sanitized session", func(t *testing.T) {
+		manager := createTestManager([]Session{{Name: "my_project", Type: SessionTypeTmux, IsActive: true}}, nil, nil)
+
+		if err := manager.CreateOrSwitch("my.project"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		if len(tmuxClient.created) != 0 {
+			t.Errorf("created = %+v, want nothing", tmuxClient.created)
+		}
+	})
+
+	t.Run("no notice for a valid name", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		var notices bytes.Buffer
+		manager.SetNotices(&notices)
+
+		if err := manager.CreateOrSwitch("my notes"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+		if notices.Len() != 0 {
+			t.Errorf("notices = %q, want none", notices.String())
+		}
+	})
+
+	t.Run("default session keeps its config", func(t *testing.T) {
+		manager := createTestManager(nil, nil, []SessionConfig{{Name: "web.app", Directory: "/tmp"}})
+		var notices bytes.Buffer
+		manager.SetNotices(&notices)
+
+		if err := manager.CreateOrSwitch("web.app"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+
+		tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+		if len(tmuxClient.created) != 1 || tmuxClient.created[0].Name != "web_app" || tmuxClient.created[0].Directory != "/tmp" {
+			t.Errorf("created = %+v, want web_app in the configured /tmp", tmuxClient.created)
+		}
+		if notices.Len() != 0 {
+			t.Errorf("notices = %q, want none for a configured session", notices.String())
+		}
+	})
+
+	t.Run("tmuxinator project is started by its name", func(t *testing.T) {
+		manager := createTestManager(nil, []string{"web.app"}, nil)
+
+		if err := manager.CreateOrSwitch("web.app"); err != nil {
+			t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+		}
+
+		tmuxinatorClient := manager.tmuxinatorClient.(*MockTmuxinatorClient)
+		if strings.Join(tmuxinatorClient.started, ",") != "web.app" {
+			t.Errorf("started %v, want web.app", tmuxinatorClient.started)
+		}
+	})
+
+	t.Run("blank name", func(t *testing.T) {
+		manager := createTestManager(nil, nil, nil)
+		if err := manager.CreateOrSwitch("  "); err == nil {
+			t.Error("CreateOrSwitch() expected error but got none")
+		}
+	})
+}
+
+// TestSortByCustom tests listing sessions in a saved order
+func TestSortByCustom(t *testing.T) {
+	manager := createTestManager(
+		[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}, {Name: "web", Type: SessionTypeTmux, IsActive: true}},
+		[]string{"infra"},
+		[]SessionConfig{{Name: "dotfiles"}, {Name: "blog"}},
+	)
+
+	names := func() string {
+		sessions, _, err := manager.ListAll()
+		if err != nil {
+			t.Fatalf("ListAll() unexpected error: %v", err)
+		}
+		var list []string
+		for _, sess := range sessions {
+			list = append(list, sess.Name)
+		}
+		return strings.Join(list, ",")
+	}
+
+	if got := names(); got != "api,blog,dotfiles,infra,web" {
+		t.Errorf("by name = %s, want api,blog,dotfiles,infra,web", got)
+	}
+
+	// Names missing from the order come after, alphabetically, and names
+	// in the order that don't exist are ignored
+	manager.SetSort(SortByCustom, []string{"web", "gone", "dotfiles", "api"})
+	if got := names(); got != "web,dotfiles,api,blog,infra" {
+		t.Errorf("custom = %s, want web,dotfiles,api,blog,infra", got)
+	}
+}
+
+// TestSortByRecent tests listing the most recently opened sessions first
+func TestSortByRecent(t *testing.T) {
+	manager := createTestManager(
+		[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}, {Name: "web", Type: SessionTypeTmux, IsActive: true}},
+		[]string{"infra"},
+		[]SessionConfig{{Name: "dotfiles"}, {Name: "blog"}},
+	)
+
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	manager.SetHistory(&fakeHistory{entries: []HistoryEntry{
+		{Name: "dotfiles", Time: base},
+		{Name: "infra", Time: base.Add(time.Hour)},
+		{Name: "dotfiles", Time: base.Add(2 * time.Hour)},
+		{Name: "gone", Time: base.Add(3 * time.Hour)},
+	}})
+
+	tests := []struct {
+		mode SortMode
+		want string
+	}{
+		// Opened sessions newest first, then the running ones, then the rest
+		{mode: SortByRecent, want: "dotfiles,infra,api,web,blog"},
+		{mode: SortByActiveFirst, want: "api,web,blog,dotfiles,infra"},
+	}
+
+	for _, tt := range tests {
+		manager.SetSort(tt.mode, nil)
+
+		sessions, _, err := manager.ListAll()
+		if err != nil {
+			t.Fatalf("ListAll() unexpected error: %v", err)
+		}
+		var names []string
+		for _, sess := range sessions {
+			names = append(names, sess.Name)
+		}
+		if got := strings.Join(names, ","); got != tt.want {
+			t.Errorf("sort %d = %s, want %s", tt.mode, got, tt.want)
+		}
+	}
+}
+
+// TestSortByActivity tests listing the sessions most recently used in tmux
+// first, with sessions tmux has no time for after them
+func TestSortByActivity(t *testing.T) {
+	base := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	manager := createTestManager(
+		[]Session{
+			{Name: "api", Type: SessionTypeTmux, IsActive: true, LastActivity: base},
+			{Name: "web", Type: SessionTypeTmux, IsActive: true, LastActivity: base.Add(time.Hour)},
+			{Name: "notes", Type: SessionTypeTmux, IsActive: true},
+			{Name: "logs", Type: SessionTypeTmux, IsActive: true, LastActivity: base.Add(time.Hour)},
+		},
+		[]string{"infra"},
+		[]SessionConfig{{Name: "dotfiles"}},
+	)
+	manager.SetSort(SortByActivity, nil)
+
+	sessions, _, err := manager.ListAll()
+	if err != nil {
+		t.Fatalf("ListAll() unexpected error: %v", err)
+	}
+	var names []string
+	for _, sess := range sessions {
+		names = append(names, sess.Name)
+	}
+
+	// Ties go alphabetically, and a running session without a time still
+	// comes before the ones that aren't running
+	if got := strings.Join(names, ","); got != "logs,web,api,notes,dotfiles,infra" {
+		t.Errorf("by activity = %s, want logs,web,api,notes,dotfiles,infra", got)
+	}
+}
+
+// TestParseSortMode tests the sort names accepted in config.yml
+func TestParseSortMode(t *testing.T) {
+	for name, want := range map[string]SortMode{
+		"name":     SortByName,
+		"custom":   SortByCustom,
+		"recent":   SortByRecent,
+		"active":   SortByActiveFirst,
+		"activity": SortByActivity,
+	} {
+		if got, err := ParseSortMode(name); err != nil || got != want {
+			t.Errorf("ParseSortMode(%q) = %v, %v, want %v", name, got, err, want)
+		}
+	}
+
+	if _, err := ParseSortMode("newest"); err == nil {
+		t.Error("ParseSortMode(\"newest\") returned no error")
+	}
+}
+
+// TestBuildImport tests turning an active session's windows into config
+func TestBuildImport(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows []Window
+		want    SessionConfig
+	}{
+		{
+			name: "no windows",
+			want: SessionConfig{Name: "api"},
+		},
+		{
+			name:    "single window",
+			windows: []Window{{Index: 1, Name: "zsh", Command: "zsh", Directory: "/code/api"}},
+			want:    SessionConfig{Name: "api", Directory: "/code/api"},
+		},
+		{
+			name: "extra windows",
+			windows: []Window{
+				{Index: 1, Name: "editor", Command: "nvim", Directory: "/code/api"},
+				{Index: 2, Name: "server", Directory: "/code/api"},
+				{Index: 3, Name: "logs", Directory: "/var/log"},
+			},
+			want: SessionConfig{Name: "api", Directory: "/code/api", WindowName: "editor", Windows: []WindowConfig{
+				{Name: "server"},
+				{Name: "logs", Directory: "/var/log"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildImport("api", tt.windows)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("BuildImport() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestImportRunning tests capturing every active session
+func TestImportRunning(t *testing.T) {
+	manager := createTestManager(
+		[]Session{
+			{Name: "api", Type: SessionTypeTmux, IsActive: true},
+			{Name: "notes", Type: SessionTypeTmux, IsActive: true},
+		},
+		nil,
+		nil,
+	)
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+	tmuxClient.windows = map[string][]Window{
+		"api":   {{Index: 1, Name: "editor", Directory: "/code/api"}, {Index: 2, Name: "server", Directory: "/code/api"}},
+		"notes": {{Index: 1, Name: "zsh", Directory: "/notes"}},
+	}
+
+	imports, err := manager.ImportRunning()
+	if err != nil {
+		t.Fatalf("ImportRunning() unexpected error: %v", err)
+	}
+
+	if len(imports) != 2 {
+		t.Fatalf("ImportRunning() returned %d sessions, want 2", len(imports))
+	}
+	if imports[0].Name != "api" || len(imports[0].Windows) != 1 {
+		t.Errorf("imports[0] = %+v, want api with one extra window", imports[0])
+	}
+	if imports[1].Name != "notes" || imports[1].Directory != "/notes" || len(imports[1].Windows) != 0 {
+		t.Errorf("imports[1] = %+v, want notes in /notes without extra windows", imports[1])
+	}
+}
+
+// TestManagerConcurrentUse tests that a shared Manager gives consistent
+// results while listing, resolving names, and changing settings at once
+// Run it with -race (task test:race) to check for data races
+func TestManagerConcurrentUse(t *testing.T) {
+	manager := createTestManager(
+		[]Session{{Name: "api", Type: SessionTypeTmux, IsActive: true, WindowCount: 2}},
+		[]string{"web"},
+		[]SessionConfig{{Name: "dotfiles", Directory: "/tmp"}},
+	)
+
+	const workers = 20
+	const rounds = 50
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers*rounds*2)
+
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range rounds {
+				sessions, _, err := manager.ListAll()
+				if err != nil {
+					errs <- err
+					continue
+				}
+				names := make([]string, len(sessions))
+				for j, sess := range sessions {
+					names[j] = sess.Name
+				}
+				sort.Strings(names)
+				if got := strings.Join(names, ","); got != "api,dotfiles,web" {
+					errs <- fmt.Errorf("ListAll() = %s, want api,dotfiles,web", got)
+				}
+
+				for _, name := range []string{"api", "web", "dotfiles"} {
+					exists, err := manager.SessionExists(name)
+					if err != nil || !exists {
+						errs <- fmt.Errorf("SessionExists(%q) = %v, %v, want true", name, exists, err)
+					}
+				}
+				if exists, _ := manager.SessionExists("missing"); exists {
+					errs <- fmt.Errorf("SessionExists(missing) = true, want false")
+				}
+
+				// Change settings while other goroutines are reading them
+				if i%2 == 0 {
+					manager.SetSort(SortByCustom, []string{"web", "api"})
+					manager.SetFuzzy(i%4 == 0)
+				} else {
+					manager.SetSort(SortByName, nil)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+}
+
+// TestAttach tests attaching with attach-session to a running session
+func TestAttach(t *testing.T) {
+	sessions := []Session{{Name: "api", Type: SessionTypeTmux, IsActive: true}}
+
+	tests := []struct {
+		name         string
+		session      string
+		detachOthers bool
+		insideTmux   bool
+		want         []attachCall
+		wantNotFound bool
+		wantErr      bool
+	}{
+		{name: "attach", session: "api", want: []attachCall{{name: "api"}}},
+		{name: "detach others", session: "api", detachOthers: true, want: []attachCall{{name: "api", detachOthers: true}}},
+		{name: "not running", session: "web", wantNotFound: true},
+		{name: "inside tmux", session: "api", insideTmux: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(sessions, nil, nil)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.isInsideTmux = tt.insideTmux
+
+			err := manager.Attach(tt.session, tt.detachOthers)
+			switch {
+			case tt.wantNotFound:
+				if !errors.Is(err, ErrSessionNotFound) {
+					t.Errorf("Attach() error = %v, want ErrSessionNotFound", err)
+				}
+			case tt.wantErr:
+				if err == nil {
+					t.Error("Attach() expected error but got none")
+				}
+			case err != nil:
+				t.Fatalf("Attach() unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tmuxClient.attached, tt.want) {
+				t.Errorf("attached = %v, want %v", tmuxClient.attached, tt.want)
+			}
+			if len(tmuxClient.switched) != 0 {
+				t.Errorf("switched = %v, want attach-session only", tmuxClient.switched)
+			}
+		})
+	}
+}
+
+// TestDetach tests that only a client inside tmux is detached
+func TestDetach(t *testing.T) {
+	tests := []struct {
+		name         string
+		insideTmux   bool
+		wantErr      bool
+		wantDetached bool
+	}{
+		{name: "inside tmux", insideTmux: true, wantDetached: true},
+		{name: "outside tmux", insideTmux: false, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmuxClient := &MockTmuxClient{isInsideTmux: tt.insideTmux}
+			manager := NewManager(tmuxClient, &MockTmuxinatorClient{}, &MockConfigLoader{}, "macos")
+
+			err := manager.Detach()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Detach() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tmuxClient.detachedClient != tt.wantDetached {
+				t.Errorf("detached = %v, want %v", tmuxClient.detachedClient, tt.wantDetached)
+			}
+		})
+	}
+}
+
+// TestRenameSession tests renaming an active session
+func TestRenameSession(t *testing.T) {
+	tests := []struct {
+		name        string
+		oldName     string
+		newName     string
+		wantErr     string
+		wantRenamed string
+	}{
+		{name: "rename", oldName: "api", newName: "backend", wantRenamed: "api->backend"},
+		{name: "not running", oldName: "missing", newName: "backend", wantErr: "not running"},
+		{name: "name taken", oldName: "api", newName: "web", wantErr: "already exists"},
+		{name: "same name", oldName: "api", newName: "api", wantErr: "already named"},
+		{name: "empty name", oldName: "api", newName: "", wantErr: "can't be empty"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(
+				[]Session{
+					{Name: "api", Type: SessionTypeTmux, IsActive: true},
+					{Name: "web", Type: SessionTypeTmux, IsActive: true},
+				},
+				nil,
+				nil,
+			)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			err := manager.RenameSession(tt.oldName, tt.newName)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RenameSession() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				if len(tmuxClient.renamed) != 0 {
+					t.Errorf("renamed = %v, want nothing", tmuxClient.renamed)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("RenameSession() unexpected error: %v", err)
+			}
+			if len(tmuxClient.renamed) != 1 || tmuxClient.renamed[0] != tt.wantRenamed {
+				t.Errorf("renamed = %v, want [%s]", tmuxClient.renamed, tt.wantRenamed)
+			}
+		})
+	}
+}
+
+// TestKillAll tests killing every session, sparing the current one
+func TestKillAll(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       KillAllOptions
+		inTmux     bool
+		confirmer  *fakeConfirmer
+		want       []string
+		wantErr    string
+		wantServer bool
+	}{
+		{name: "forced, outside tmux", opts: KillAllOptions{Force: true}, want: []string{"api", "web", "notes"}},
+		{name: "spares the current session", opts: KillAllOptions{Force: true}, inTmux: true, want: []string{"api", "notes"}},
+		{name: "include current", opts: KillAllOptions{Force: true, IncludeCurrent: true}, inTmux: true, want: []string{"api", "web", "notes"}},
+		{name: "confirmed", confirmer: &fakeConfirmer{answer: true}, want: []string{"api", "web", "notes"}},
+		{name: "declined", confirmer: &fakeConfirmer{answer: false}},
+		{name: "no one to confirm", wantErr: "--force"},
+		{name: "server", opts: KillAllOptions{Force: true, Server: true}, want: []string{"api", "web", "notes"}, wantServer: true},
+		{name: "server would end the current session", opts: KillAllOptions{Force: true, Server: true}, inTmux: true, wantErr: "--include-current"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(
+				[]Session{
+					{Name: "api", Type: SessionTypeTmux, IsActive: true},
+					{Name: "web", Type: SessionTypeTmux, IsActive: true},
+					{Name: "notes", Type: SessionTypeTmux, IsActive: true},
+				},
+				nil,
+				nil,
+			)
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+			tmuxClient.isInsideTmux = tt.inTmux
+			tmuxClient.current = "web"
+			if tt.confirmer != nil {
+				manager.SetConfirmer(tt.confirmer)
+			}
+
+			killed, err := manager.KillAll(tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("KillAll() error = %v, want it to mention %q", err, tt.wantErr)
+				}
+				if len(tmuxClient.deleted) != 0 || tmuxClient.killedServer {
+					t.Errorf("killed sessions despite the error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("KillAll() unexpected error: %v", err)
+			}
+
+			if len(killed) != len(tt.want) || (len(tt.want) > 0 && !reflect.DeepEqual(killed, tt.want)) {
+				t.Errorf("KillAll() = %v, want %v", killed, tt.want)
+			}
+			if tmuxClient.killedServer != tt.wantServer {
+				t.Errorf("killed server = %v, want %v", tmuxClient.killedServer, tt.wantServer)
+			}
+			if !tt.wantServer && len(tmuxClient.deleted) != len(tt.want) {
+				t.Errorf("deleted = %v, want %v", tmuxClient.deleted, tt.want)
+			}
+		})
+	}
+}
+
+// TestStartupCommands tests typing a default session's commands into its first window
+func TestStartupCommands(t *testing.T) {
+	tests := []struct {
+		name         string
+		commands     []string
+		project      *Project
+		wantKeys     []string
+		wantDetached bool
+	}{
+		{name: "no commands", wantKeys: nil},
+		{name: "one command", commands: []string{"nvim"}, wantKeys: []string{"api nvim"}, wantDetached: true},
+		{
+			name:         "several commands in order",
+			commands:     []string{"git pull", "npm install", "npm run dev"},
+			wantKeys:     []string{"api git pull", "api npm install", "api npm run dev"},
+			wantDetached: true,
+		},
+		{
+			name:         "after the project's commands",
+			commands:     []string{"nvim"},
+			project:      &Project{Commands: []string{"source .envrc"}},
+			wantKeys:     []string{"api source .envrc", "api nvim"},
+			wantDetached: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := createTestManager(nil, nil, []SessionConfig{
+				{Name: "api", Directory: t.TempDir(), Commands: tt.commands, ResolvedProject: tt.project},
+			})
+			tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+			if err := manager.CreateOrSwitch("api"); err != nil {
+				t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+			}
+
+			if !reflect.DeepEqual(tmuxClient.sentKeys, tt.wantKeys) {
+				t.Errorf("sent keys = %q, want %q", tmuxClient.sentKeys, tt.wantKeys)
+			}
+			// Sessions with commands are built detached so the commands are
+			// typed before the user sees them
+			if (len(tmuxClient.detached) == 1) != tt.wantDetached {
+				t.Errorf("detached = %v, want detached: %v", tmuxClient.detached, tt.wantDetached)
+			}
+		})
+	}
+}
+
+// TestStartupCommandError tests that a failed command is named in the error
+func TestStartupCommandError(t *testing.T) {
+	manager := createTestManager(nil, nil, []SessionConfig{
+		{Name: "api", Directory: t.TempDir(), Commands: []string{"nvim", "npm run dev"}},
+	})
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+	tmuxClient.sendKeysErr = errors.New("no server running")
+
+	err := manager.CreateOrSwitch("api")
+	if err == nil || !strings.Contains(err.Error(), `"nvim"`) || !strings.Contains(err.Error(), "no server running") {
+		t.Errorf("CreateOrSwitch() error = %v, want it to name the command and the cause", err)
+	}
+}
+
+// TestInlineWindows tests creating a default session's own windows
+func TestInlineWindows(t *testing.T) {
+	dir := t.TempDir()
+	manager := createTestManager(nil, nil, []SessionConfig{{
+		Name:       "webapp",
+		Directory:  dir,
+		WindowName: "editor",
+		Windows: []WindowConfig{
+			{Name: "server", Commands: []string{"npm run dev"}},
+			{Name: "logs", Directory: "/var/log"},
+		},
+		ResolvedProject: &Project{Windows: []WindowConfig{{Name: "tests"}}},
+	}})
+	tmuxClient := manager.tmuxClient.(*MockTmuxClient)
+
+	if err := manager.CreateOrSwitch("webapp"); err != nil {
+		t.Fatalf("CreateOrSwitch() unexpected error: %v", err)
+	}
+
+	if len(tmuxClient.detached) != 1 || tmuxClient.detached[0].Directory != dir {
+		t.Fatalf("detached = %v, want one session in %s", tmuxClient.detached, dir)
+	}
+	if want := []string{"rename =webapp: editor"}; !reflect.DeepEqual(tmuxClient.layoutCalls, want) {
+		t.Errorf("layout calls = %q, want the first window renamed %q", tmuxClient.layoutCalls, want)
+	}
+
+	// The project's windows come first, then the session's, in order
+	wantWindows := []Window{
+		{Name: "tests", Directory: dir},
+		{Name: "server", Directory: dir},
+		{Name: "logs", Directory: "/var/log"},
+	}
+	if !reflect.DeepEqual(tmuxClient.newWins, wantWindows) {
+		t.Errorf("windows = %+v, want %+v", tmuxClient.newWins, wantWindows)
+	}
+
+	wantKeys := []string{"webapp:{end} npm run dev"}
+	if !reflect.DeepEqual(tmuxClient.sentKeys, wantKeys) {
+		t.Errorf("sent keys = %q, want %q", tmuxClient.sentKeys, wantKeys)
+	}
+	if len(tmuxClient.switched) != 1 || tmuxClient.switched[0] != "webapp" {
+		t.Errorf("switched = %v, want [webapp]", tmuxClient.switched)
+	}
+}
+
+// TestBuildSnapshot tests grouping panes under their windows
+func TestBuildSnapshot(t *testing.T) {
+	windows := []Window{
+		{Index: 1, Name: "editor", Directory: "/code/api", Layout: "a1"},
+		{Index: 2, Name: "logs", Directory: "/var/log"},
+	}
+	panes := []Pane{
+		{WindowIndex: 1, Command: "nvim", Directory: "/code/api"},
+		{WindowIndex: 1, Command: "-zsh", Directory: "/code/api/cmd"},
+		{WindowIndex: 2, Command: "tail", Directory: "/var/log"},
+	}
+
+	want := &Snapshot{
+		Name: "api",
+		Windows: []SnapshotWindow{
+			{Name: "editor", Layout: "a1", Panes: []SnapshotPane{
+				{Directory: "/code/api", Command: "nvim"},
+				{Directory: "/code/api/cmd"}, // a shell prompt has nothing to rerun
+			}},
+			{Name: "logs", Panes: []SnapshotPane{{Directory: "/var/log", Command: "tail"}}},
+		},
+	}
+
+	if got := BuildSnapshot("api", windows, panes); !reflect.DeepEqual(got, want) {
+		t.Errorf("BuildSnapshot() = %+v, want %+v", got, want)
+	}
+}
+
+// TestRestore tests recreating a snapshot's windows and panes
+func TestRestore(t *testing.T) {
+	snapshot := &Snapshot{
+		Name: "api",
+		Windows: []SnapshotWindow{
+			{Name: "editor", Layout: "a1", Panes: []SnapshotPane{
+				{Directory: "/code/api", Command: "nvim"},
+				{Directory: "/code/api/cmd"},
+			}},
+			{Name: "logs", Panes: []SnapshotPane{{Directory: "/var/log", Command: "tail"}}},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		running      bool
+		opts         RestoreOptions
+		wantErr      bool
+		wantDeleted  bool
+		wantSentKeys []string
+	}{
+		{name: "restore"},
+		{name: "with commands", opts: RestoreOptions{RunCommands: true}, wantSentKeys: []string{"api:{end} nvim", "api:{end} tail"}},
+		{name: "already running", running: true, wantErr: true},
+		{name: "overwrite", running: true, opts: RestoreOptions{Overwrite: true}, wantDeleted: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmuxClient := &MockTmuxClient{}
+			if tt.running {
+				tmuxClient.sessions = []Session{{Name: "api", Type: SessionTypeTmux}}
+			}
+			manager := NewManager(tmuxClient, &MockTmuxinatorClient{}, &MockConfigLoader{}, "macos")
+
+			err := manager.Restore(snapshot, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Restore() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				if len(tmuxClient.detached) != 0 || len(tmuxClient.deleted) != 0 {
+					t.Errorf("Restore() touched the running session: created %v, deleted %v", tmuxClient.detached, tmuxClient.deleted)
+				}
+				return
+			}
+
+			if got := len(tmuxClient.deleted) > 0; got != tt.wantDeleted {
+				t.Errorf("deleted = %v, want %v", tmuxClient.deleted, tt.wantDeleted)
+			}
+			if len(tmuxClient.detached) != 1 || tmuxClient.detached[0].Directory != "/code/api" {
+				t.Errorf("created %+v, want api in /code/api", tmuxClient.detached)
+			}
+			if want := []Window{{Name: "logs", Directory: "/var/log"}}; !reflect.DeepEqual(tmuxClient.newWins, want) {
+				t.Errorf("new windows = %+v, want %+v", tmuxClient.newWins, want)
+			}
+			wantLayout := []string{"rename api:{end} editor", "split api:{end} /code/api/cmd", "layout api:{end} a1"}
+			if !reflect.DeepEqual(tmuxClient.layoutCalls, wantLayout) {
+				t.Errorf("layout calls = %v, want %v", tmuxClient.layoutCalls, wantLayout)
+			}
+			if !reflect.DeepEqual(tmuxClient.sentKeys, tt.wantSentKeys) {
+				t.Errorf("sent keys = %v, want %v", tmuxClient.sentKeys, tt.wantSentKeys)
+			}
+			if len(tmuxClient.switched) != 1 || tmuxClient.switched[0] != "api" {
+				t.Errorf("switched to %v, want api", tmuxClient.switched)
+			}
+		})
+	}
 }
@@ -0,0 +1,57 @@
+package session
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// NameMatch decides how FilterByName compares a pattern to session names
+type NameMatch int
+
+const (
+	// MatchSubstring keeps names containing the pattern, ignoring case
+	MatchSubstring NameMatch = iota
+
+	// MatchExact keeps only the name that is the pattern
+	MatchExact
+
+	// MatchGlob keeps names matching a shell glob (*, ?, [abc]), ignoring case
+	MatchGlob
+)
+
+// FilterByName returns the sessions whose name matches pattern, in the same order
+// A session created under a normalized name also matches by its display name
+// An empty pattern matches every session
+func FilterByName(sessions []Session, pattern string, match NameMatch) ([]Session, error) {
+	if pattern == "" {
+		return sessions, nil
+	}
+	if match == MatchGlob {
+		// path.Match only reports a bad pattern once it gets that far, so check it whole
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid glob %q: %w", pattern, err)
+		}
+	}
+
+	filtered := make([]Session, 0, len(sessions))
+	for _, sess := range sessions {
+		if nameMatches(sess.Name, pattern, match) || (sess.DisplayName != "" && nameMatches(sess.DisplayName, pattern, match)) {
+			filtered = append(filtered, sess)
+		}
+	}
+	return filtered, nil
+}
+
+// nameMatches reports whether one name matches pattern
+func nameMatches(name, pattern string, match NameMatch) bool {
+	switch match {
+	case MatchExact:
+		return name == pattern
+	case MatchGlob:
+		ok, _ := path.Match(strings.ToLower(pattern), strings.ToLower(name))
+		return ok
+	default:
+		return strings.Contains(strings.ToLower(name), strings.ToLower(pattern))
+	}
+}
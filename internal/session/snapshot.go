@@ -0,0 +1,168 @@
+package session
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Snapshot is a session's layout as "sess save" keeps it: each window,
+// how its panes are arranged, and where each pane was and what it was running
+type Snapshot struct {
+	Name    string           `yaml:"name"`
+	Windows []SnapshotWindow `yaml:"windows"`
+}
+
+// SnapshotWindow is one window of a Snapshot
+type SnapshotWindow struct {
+	Name string `yaml:"name"`
+
+	// Layout is tmux's window_layout, which puts the panes back where they were
+	Layout string `yaml:"layout,omitempty"`
+
+	Panes []SnapshotPane `yaml:"panes"`
+}
+
+// SnapshotPane is one pane of a SnapshotWindow
+type SnapshotPane struct {
+	Directory string `yaml:"directory"`
+
+	// Command is the program that was running, empty for a shell prompt
+	// tmux only reports the program, not its arguments
+	Command string `yaml:"command,omitempty"`
+}
+
+// RestoreOptions controls how Restore recreates a snapshot
+type RestoreOptions struct {
+	// RunCommands runs each pane's saved command again
+	RunCommands bool
+
+	// Overwrite kills a running session of the same name first; without
+	// it, a running session makes Restore fail
+	Overwrite bool
+}
+
+// shells are the programs a pane shows when it's sitting at a prompt
+// Their panes are saved without a command, since restoring one would only
+// start a shell inside the shell
+var shells = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "fish": true, "dash": true,
+	"ksh": true, "tcsh": true, "csh": true, "nu": true, "elvish": true,
+}
+
+// Snapshot captures an active session's windows and panes
+func (m *Manager) Snapshot(name string) (*Snapshot, error) {
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("session '%s' is not running", name)
+	}
+
+	windows, err := m.tmuxClient.ListWindows(name)
+	if err != nil {
+		return nil, err
+	}
+	panes, err := m.tmuxClient.ListPaneDetails(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return BuildSnapshot(name, windows, panes), nil
+}
+
+// BuildSnapshot assembles a Snapshot from a session's windows and panes
+// It's separate from Snapshot so the grouping can be tested without tmux
+func BuildSnapshot(name string, windows []Window, panes []Pane) *Snapshot {
+	byWindow := make(map[int][]SnapshotPane)
+	for _, pane := range panes {
+		saved := SnapshotPane{Directory: pane.Directory}
+		// Login shells show up as "-zsh"
+		if !shells[strings.TrimPrefix(pane.Command, "-")] {
+			saved.Command = pane.Command
+		}
+		byWindow[pane.WindowIndex] = append(byWindow[pane.WindowIndex], saved)
+	}
+
+	snapshot := &Snapshot{Name: name}
+	for _, window := range windows {
+		saved := SnapshotWindow{Name: window.Name, Layout: window.Layout, Panes: byWindow[window.Index]}
+		// A window always has a pane, even if the listing missed it
+		if len(saved.Panes) == 0 {
+			saved.Panes = []SnapshotPane{{Directory: window.Directory}}
+		}
+		snapshot.Windows = append(snapshot.Windows, saved)
+	}
+
+	return snapshot
+}
+
+// Restore recreates a saved session and switches to it
+// Windows are added in order, each split into its saved panes and
+// arranged by its saved layout
+func (m *Manager) Restore(snapshot *Snapshot, opts RestoreOptions) error {
+	if len(snapshot.Windows) == 0 {
+		return fmt.Errorf("snapshot of '%s' has no windows", snapshot.Name)
+	}
+	name := snapshot.Name
+
+	exists, err := m.tmuxClient.SessionExists(name)
+	if err != nil {
+		return fmt.Errorf("failed to check if session exists: %w", err)
+	}
+	if exists {
+		if !opts.Overwrite {
+			return fmt.Errorf("session '%s' is already running (use --overwrite to replace it)", name)
+		}
+		if err := m.DeleteSession(name); err != nil {
+			return err
+		}
+	}
+
+	// "{end}" is always the window just created: windows are appended
+	current := name + ":{end}"
+
+	for i, window := range snapshot.Windows {
+		directory := window.Panes[0].Directory
+		if i == 0 {
+			// The first window comes with the session itself
+			if err := m.tmuxClient.CreateDetachedSession(Session{Name: name, Type: SessionTypeTmux, Directory: directory}); err != nil {
+				return err
+			}
+			if window.Name != "" {
+				if err := m.tmuxClient.RenameWindow(current, window.Name); err != nil {
+					return err
+				}
+			}
+		} else if err := m.tmuxClient.NewWindow(name, window.Name, directory); err != nil {
+			return err
+		}
+
+		// Each pane is the window's active one right after it's created, so
+		// its command goes to the window target
+		for j, pane := range window.Panes {
+			if j > 0 {
+				if err := m.tmuxClient.SplitWindow(current, pane.Directory); err != nil {
+					return err
+				}
+			}
+			if opts.RunCommands && pane.Command != "" {
+				if err := m.tmuxClient.SendKeys(current, pane.Command); err != nil {
+					return fmt.Errorf("failed to run %q: %w", pane.Command, err)
+				}
+			}
+		}
+
+		if window.Layout != "" {
+			if err := m.tmuxClient.SelectLayout(current, window.Layout); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := m.tmuxClient.SwitchToSession(name, m.tmuxClient.IsInsideTmux()); err != nil {
+		return err
+	}
+	m.opened(EventCreated, name)
+	return nil
+}
@@ -0,0 +1,77 @@
+package session
+
+import (
+	"slices"
+	"strings"
+)
+
+// UntaggedTag is the pseudo-tag of sessions without any tags, so filtering
+// by tag can also pick out the sessions nobody has sorted yet
+const UntaggedTag = "untagged"
+
+// HasTag reports whether the session is tagged tag
+// UntaggedTag matches the sessions with no tags at all
+func (s Session) HasTag(tag string) bool {
+	return hasTag(s.Tags, tag)
+}
+
+// HasTag reports whether the config is tagged tag, like Session.HasTag
+func (c SessionConfig) HasTag(tag string) bool {
+	return hasTag(c.Tags, tag)
+}
+
+// hasTag reports whether tags includes tag, or is empty for UntaggedTag
+func hasTag(tags []string, tag string) bool {
+	if tag == UntaggedTag {
+		return len(tags) == 0
+	}
+	return slices.Contains(tags, tag)
+}
+
+// FilterByTag returns the sessions tagged tag, in the same order
+func FilterByTag(sessions []Session, tag string) []Session {
+	filtered := make([]Session, 0, len(sessions))
+	for _, sess := range sessions {
+		if sess.HasTag(tag) {
+			filtered = append(filtered, sess)
+		}
+	}
+	return filtered
+}
+
+// Tags returns every tag used by sessions, sorted, followed by UntaggedTag
+// when some sessions have none
+func Tags(sessions []Session) []string {
+	var tags []string
+	untagged := false
+	for _, sess := range sessions {
+		if len(sess.Tags) == 0 {
+			untagged = true
+		}
+		for _, tag := range sess.Tags {
+			if !slices.Contains(tags, tag) {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	slices.Sort(tags)
+
+	if untagged {
+		tags = append(tags, UntaggedTag)
+	}
+	return tags
+}
+
+// mergeTags adds the comma-separated tags from the metadata store to a
+// session's configured ones, skipping duplicates and blanks
+func mergeTags(tags []string, extra string) []string {
+	// Clipped so appending never writes into the config's own slice
+	tags = slices.Clip(tags)
+	for _, tag := range strings.Split(extra, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(tags, tag) {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
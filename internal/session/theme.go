@@ -0,0 +1,62 @@
+package session
+
+// Appearance is how sessions of one type are drawn in lists
+type Appearance struct {
+	// Icon goes in front of the session's name
+	Icon string `yaml:"icon"`
+
+	// Color is a lipgloss color for the icon: an ANSI number like "10" or a
+	// hex color like "#89b4fa"
+	Color string `yaml:"color"`
+}
+
+// Theme maps session types to how they're drawn
+// Types it leaves out, and fields left empty, keep their default appearance,
+// so a nil Theme is the default theme
+type Theme map[SessionType]Appearance
+
+// defaultTheme is what sessions look like unless config.yml says otherwise
+// This matches the bash version: ● for active, ⚙ for tmuxinator, ○ for default
+var defaultTheme = Theme{
+	SessionTypeTmux:       {Icon: "●", Color: "10"}, // Green filled circle for active sessions
+	SessionTypeTmuxinator: {Icon: "⚙", Color: "11"}, // Yellow gear for tmuxinator projects
+	SessionTypeDefault:    {Icon: "○", Color: "12"}, // Blue hollow circle for not-yet-started default sessions
+}
+
+// asciiIcons stand in for the icons where they can't be drawn, like output
+// piped to a file or a terminal without the font for them
+var asciiIcons = map[SessionType]string{
+	SessionTypeTmux:       "*",
+	SessionTypeTmuxinator: "+",
+	SessionTypeDefault:    "-",
+}
+
+// ASCIIIcon returns the plain ASCII marker for a session type
+func ASCIIIcon(sessionType SessionType) string {
+	if icon := asciiIcons[sessionType]; icon != "" {
+		return icon
+	}
+	return " "
+}
+
+// SessionTypes are the session types a Theme can set, in list order
+var SessionTypes = []SessionType{SessionTypeTmux, SessionTypeTmuxinator, SessionTypeDefault}
+
+// Icon returns the icon for a session type
+func (t Theme) Icon(sessionType SessionType) string {
+	if icon := t[sessionType].Icon; icon != "" {
+		return icon
+	}
+	if icon := defaultTheme[sessionType].Icon; icon != "" {
+		return icon
+	}
+	return " "
+}
+
+// Color returns the icon color for a session type ("" for the terminal's own)
+func (t Theme) Color(sessionType SessionType) string {
+	if color := t[sessionType].Color; color != "" {
+		return color
+	}
+	return defaultTheme[sessionType].Color
+}
@@ -2,7 +2,12 @@ package session
 
 import (
 	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
+	"unicode"
 )
 
 // SessionType represents the different types of sessions we support
@@ -20,35 +25,80 @@ const (
 	SessionTypeDefault SessionType = "default"
 )
 
+// CountByType tallies sessions by type
+// Types with no sessions are left out, so a missing key counts as 0
+func CountByType(sessions []Session) map[SessionType]int {
+	counts := make(map[SessionType]int)
+	for _, sess := range sessions {
+		counts[sess.Type]++
+	}
+	return counts
+}
+
 // Session represents a tmux session with metadata
 // In Go, we use structs to define data structures
 // The fields with capital letters are "exported" (public)
 type Session struct {
 	// Name is the session name
-	Name string
+	Name string `json:"name"`
+
+	// DisplayName is the name the session was asked for, when normalization
+	// created it under a different Name (empty otherwise)
+	DisplayName string `json:"display_name,omitempty"`
 
 	// Type indicates the session type (tmux, tmuxinator, or default)
-	Type SessionType
+	Type SessionType `json:"type"`
 
 	// WindowCount is the number of windows (only for active sessions)
-	WindowCount int
+	WindowCount int `json:"window_count"`
 
 	// Directory is the starting directory (for default sessions)
-	Directory string
+	Directory string `json:"directory"`
 
 	// Description provides additional context about the session
-	Description string
+	Description string `json:"description"`
 
 	// IsActive indicates if the session is currently running
-	IsActive bool
+	IsActive bool `json:"is_active"`
 
 	// TmuxinatorProject is the tmuxinator project name (if applicable)
-	TmuxinatorProject string
+	TmuxinatorProject string `json:"tmuxinator_project,omitempty"`
 
 	// CreatedAt is when the session was created (for active sessions)
-	CreatedAt time.Time
+	CreatedAt time.Time `json:"created_at,omitzero"`
+
+	// LastActivity is when someone last used the session, as tmux tracks it
+	// (for active sessions)
+	LastActivity time.Time `json:"last_activity,omitzero"`
+
+	// Tags group the session with others (work, personal, ...), from its
+	// config and the MetaTags metadata
+	Tags []string `json:"tags,omitempty"`
+
+	// Socket is the tmux server socket the session runs on, when listing
+	// across servers (empty otherwise)
+	Socket string `json:"socket,omitempty"`
+
+	// Environment is set in the session's tmux environment when it's created
+	// It isn't read back when listing
+	Environment map[string]string `json:"-"`
+
+	// Metadata holds extra details about the session from the MetadataStore,
+	// under keys like MetaNote (nil when there are none)
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
+// Well-known Metadata keys
+// Features that attach something to a session store it under one of these
+// instead of keeping a store of their own
+const (
+	// MetaNote is a free-form note about the session
+	MetaNote = "note"
+
+	// MetaTags is a comma-separated list of tags
+	MetaTags = "tags"
+)
+
 // SessionConfig represents a default session from YAML configuration
 // This maps to the structure in ~/.config/sess/sessions-macos.yml
 type SessionConfig struct {
@@ -56,7 +106,7 @@ type SessionConfig struct {
 	Name string `yaml:"name"`
 
 	// Description explains what the session is for
-	Description string `yaml:"description"`
+	Description string `yaml:"description,omitempty"`
 
 	// Directory is the starting directory (can use ~ for home)
 	Directory string `yaml:"directory"`
@@ -65,6 +115,371 @@ type SessionConfig struct {
 	// The backticks define "struct tags" - metadata about the field
 	// yaml:"tmuxinator_project" tells the YAML parser what field name to look for
 	TmuxinatorProject string `yaml:"tmuxinator_project,omitempty"`
+
+	// Hidden keeps the session out of the picker and list unless --all is given
+	Hidden bool `yaml:"hidden,omitempty"`
+
+	// Tags group the session with others, for filtering with list --tag or
+	// t in the picker; a running session with this name gets them too
+	Tags []string `yaml:"tags,omitempty"`
+
+	// Project names an entry under the top-level projects: key whose layout
+	// (directory, windows, commands) this session uses
+	Project string `yaml:"project,omitempty"`
+
+	// WindowName names the session's first window; empty leaves tmux to
+	// name it after what's running in it
+	WindowName string `yaml:"window_name,omitempty"`
+
+	// Windows are created after the first window, in order, for sessions
+	// that don't need a shared project layout; they come after a project's windows
+	Windows []WindowConfig `yaml:"windows,omitempty"`
+
+	// Commands are typed into the session's first window after it's created, in order
+	// They run after the project's own commands, if it uses a project
+	Commands []string `yaml:"commands,omitempty"`
+
+	// OnAttach is typed into the session's active pane every time sess
+	// switches to it while it's running, e.g. "git fetch"
+	OnAttach string `yaml:"on_attach,omitempty"`
+
+	// Options are tmux session options set right after the session is created
+	// e.g. status-style: "bg=red" to make a production session stand out
+	Options map[string]string `yaml:"options,omitempty"`
+
+	// ResolvedProject is the project referenced by Project, filled in by the config loader
+	// The yaml:"-" tag keeps it out of the YAML file
+	ResolvedProject *Project `yaml:"-"`
+}
+
+// Project is a reusable session layout: a directory plus windows and startup commands
+// Several default sessions can share a project by referencing it by name
+type Project struct {
+	// Directory is the starting directory (a session's own directory takes precedence)
+	Directory string `yaml:"directory,omitempty"`
+
+	// Commands run in the session's first window, in order
+	Commands []string `yaml:"commands,omitempty"`
+
+	// Windows are created after the first window, in order
+	Windows []WindowConfig `yaml:"windows,omitempty"`
+}
+
+// WindowConfig describes an additional window in a project layout
+type WindowConfig struct {
+	// Name is the window name
+	Name string `yaml:"name"`
+
+	// Directory is the window's starting directory (defaults to the session's)
+	Directory string `yaml:"directory,omitempty"`
+
+	// Commands run in the window, in order
+	Commands []string `yaml:"commands,omitempty"`
+}
+
+// Validate checks a project for mistakes that would only show up when starting it
+func (p Project) Validate() error {
+	for _, command := range p.Commands {
+		if strings.TrimSpace(command) == "" {
+			return fmt.Errorf("empty command")
+		}
+	}
+
+	for i, window := range p.Windows {
+		if window.Name == "" {
+			return fmt.Errorf("window %d has no name", i+1)
+		}
+		for _, command := range window.Commands {
+			if strings.TrimSpace(command) == "" {
+				return fmt.Errorf("window %q has an empty command", window.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// HistoryEntry records one time a session was opened
+type HistoryEntry struct {
+	Name string    `json:"name"`
+	Time time.Time `json:"time"`
+}
+
+// MissingDirPolicy decides what happens when a default session's directory doesn't exist
+type MissingDirPolicy int
+
+const (
+	// MissingDirIgnore leaves it to tmux, which starts the session in the home directory
+	MissingDirIgnore MissingDirPolicy = iota
+
+	// MissingDirAsk asks the Confirmer whether to create it, and aborts without one
+	MissingDirAsk
+
+	// MissingDirCreate creates the directory without asking
+	MissingDirCreate
+
+	// MissingDirAbort refuses to start the session
+	MissingDirAbort
+)
+
+// EventType identifies what happened to a session
+type EventType string
+
+const (
+	// EventCreated is emitted after a session is created
+	EventCreated EventType = "created"
+
+	// EventSwitched is emitted after switching (or attaching) to an existing session
+	EventSwitched EventType = "switched"
+
+	// EventDeleted is emitted after a session is killed
+	EventDeleted EventType = "deleted"
+)
+
+// Event describes a change to a session, sent to the manager's EventSink
+// The json tags define the payload external listeners receive
+type Event struct {
+	Type    EventType `json:"type"`
+	Session string    `json:"session"`
+	Time    time.Time `json:"time"`
+}
+
+// SortMode decides the order ListAll returns sessions in
+type SortMode int
+
+const (
+	// SortByName sorts sessions alphabetically
+	SortByName SortMode = iota
+
+	// SortByCustom follows a saved order (the picker's manual reordering)
+	// Sessions the order doesn't mention come after, alphabetically
+	SortByCustom
+
+	// SortByRecent puts the most recently opened sessions first, going by
+	// the history; sessions never opened come after, active ones first
+	SortByRecent
+
+	// SortByActiveFirst puts active sessions first, then the rest,
+	// each group alphabetically
+	SortByActiveFirst
+
+	// SortByActivity puts the sessions most recently used in tmux first,
+	// going by tmux's own activity time, so it covers sessions sess didn't
+	// open too; sessions that aren't running come after
+	SortByActivity
+)
+
+// ParseSortMode returns the SortMode for its name in config.yml:
+// name, custom, recent, or active
+func ParseSortMode(name string) (SortMode, error) {
+	switch name {
+	case "name":
+		return SortByName, nil
+	case "custom":
+		return SortByCustom, nil
+	case "recent":
+		return SortByRecent, nil
+	case "active":
+		return SortByActiveFirst, nil
+	case "activity":
+		return SortByActivity, nil
+	}
+	return SortByName, fmt.Errorf("unknown sort %q (want name, custom, recent, active, or activity)", name)
+}
+
+// sortSessions sorts sessions in place by mode
+// order is only used by SortByCustom, and lastOpened by SortByRecent
+func sortSessions(sessions []Session, mode SortMode, order []string, lastOpened map[string]time.Time) {
+	// Alphabetical first: it's SortByName, and the tiebreak for every other mode
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Name < sessions[j].Name
+	})
+
+	switch mode {
+	case SortByCustom:
+		sortByOrder(sessions, order)
+
+	case SortByRecent:
+		sort.SliceStable(sessions, func(i, j int) bool {
+			ti, tj := lastOpened[sessions[i].Name], lastOpened[sessions[j].Name]
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+			// Neither was opened (or both at once): running sessions first
+			return sessions[i].IsActive && !sessions[j].IsActive
+		})
+
+	case SortByActiveFirst:
+		sort.SliceStable(sessions, func(i, j int) bool {
+			return sessions[i].IsActive && !sessions[j].IsActive
+		})
+
+	case SortByActivity:
+		sort.SliceStable(sessions, func(i, j int) bool {
+			ti, tj := sessions[i].LastActivity, sessions[j].LastActivity
+			if !ti.Equal(tj) {
+				return ti.After(tj)
+			}
+			// Neither has a time (or both the same): running sessions first
+			return sessions[i].IsActive && !sessions[j].IsActive
+		})
+	}
+}
+
+// sortByOrder stably sorts sessions by their position in order
+// Sessions the order doesn't mention go after every one it does
+func sortByOrder(sessions []Session, order []string) {
+	position := make(map[string]int, len(order))
+	for i, name := range order {
+		if _, seen := position[name]; !seen {
+			position[name] = i
+		}
+	}
+	rank := func(name string) int {
+		if i, ok := position[name]; ok {
+			return i
+		}
+		return len(order) // After every ordered name
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return rank(sessions[i].Name) < rank(sessions[j].Name)
+	})
+}
+
+// ListOptions controls which sessions ListFiltered returns
+type ListOptions struct {
+	// IncludeHidden returns hidden sessions too (the --all flag)
+	IncludeHidden bool
+
+	// Hidden lists session names to hide, on top of configs marked hidden: true
+	// This is how active sessions that aren't in any config get hidden
+	Hidden []string
+
+	// Tag keeps only the sessions with this tag (see Session.HasTag); empty keeps all
+	Tag string
+
+	// Pattern keeps only the sessions whose name matches it, compared as
+	// Match says (see FilterByName); empty keeps all
+	Pattern string
+	Match   NameMatch
+}
+
+// ListWarning is a source ListAll couldn't read
+// The other sources are still listed, so a broken tmux install or a
+// malformed config leaves a gap in the list instead of emptying it, and
+// the warning explains the gap
+type ListWarning struct {
+	// Source is what couldn't be read: "tmux", "tmuxinator", "config", or "metadata"
+	Source string
+
+	// Err is why
+	Err error
+}
+
+// Error describes the warning, prefixed with its source
+func (w ListWarning) Error() string {
+	return fmt.Sprintf("%s: %v", w.Source, w.Err)
+}
+
+// Unwrap returns the underlying error
+func (w ListWarning) Unwrap() error {
+	return w.Err
+}
+
+// UpOptions picks the default sessions BringUpDefaults starts
+type UpOptions struct {
+	// Only limits it to these names; empty starts every default session
+	Only []string
+
+	// Tag limits it to the sessions with this tag (see SessionConfig.HasTag)
+	Tag string
+}
+
+// UpResult is what BringUpDefaults did, by session name in config order
+type UpResult struct {
+	// Created were started in the background
+	Created []string
+
+	// Skipped were already running
+	Skipped []string
+}
+
+// TeardownOptions controls how Teardown ends a session
+type TeardownOptions struct {
+	// StopProject stops a tmuxinator project's session with tmuxinator
+	// stop, without asking
+	StopProject bool
+
+	// Force skips every question: the session is killed, or stopped if
+	// StopProject is set
+	Force bool
+}
+
+// TeardownResult is what Teardown did with a session
+type TeardownResult int
+
+const (
+	// TeardownKept means the user declined and the session is still running
+	TeardownKept TeardownResult = iota
+
+	// TeardownDeleted means the session was killed
+	TeardownDeleted
+
+	// TeardownStopped means the session's tmuxinator project was stopped
+	TeardownStopped
+)
+
+// KillAllOptions controls which sessions KillAll kills and how
+type KillAllOptions struct {
+	// IncludeCurrent kills the session sess is running in too; without it
+	// that session is left alone
+	IncludeCurrent bool
+
+	// Server ends the whole tmux server (kill-server) instead of killing
+	// the sessions one at a time
+	Server bool
+
+	// Force skips asking for confirmation
+	Force bool
+}
+
+// Window represents a single window inside a tmux session
+type Window struct {
+	// Index is the window's position in the session (as shown in the status bar)
+	Index int
+
+	// Name is the window name
+	Name string
+
+	// Directory is the working directory of the window's active pane
+	Directory string
+
+	// Command is the program running in the window's active pane
+	Command string
+
+	// Active is true for the session's current window
+	Active bool
+
+	// PaneCount is how many panes the window is split into
+	PaneCount int
+
+	// Layout is tmux's description of how the panes are arranged
+	// (window_layout), which select-layout accepts to arrange them again
+	Layout string
+}
+
+// Pane is one pane of a session's window
+type Pane struct {
+	// WindowIndex is the index of the window the pane is in
+	WindowIndex int
+
+	// Command is the program running in the pane (e.g. "nvim" or "zsh"),
+	// without its arguments
+	Command string
+
+	// Directory is the pane's working directory
+	Directory string
 }
 
 // SessionsConfig represents the root YAML configuration
@@ -82,7 +497,7 @@ func (s Session) DisplayInfo() string {
 	switch s.Type {
 	case SessionTypeTmux:
 		// If it's an active tmux session, show window count
-		return s.Name + " (" + formatWindowCount(s.WindowCount) + ")"
+		return s.label() + " (" + formatWindowCount(s.WindowCount) + ")"
 	case SessionTypeTmuxinator:
 		// If it's a tmuxinator project, indicate that
 		return s.Name + " (tmuxinator)"
@@ -95,19 +510,83 @@ func (s Session) DisplayInfo() string {
 	}
 }
 
-// Icon returns the visual indicator for the session type
-// This matches the bash version: ● for active, ⚙ for tmuxinator, ○ for default
+// label is the name shown for the session: the display name if it has one
+func (s Session) label() string {
+	if s.DisplayName != "" {
+		return s.DisplayName
+	}
+	return s.Name
+}
+
+// Icon returns the visual indicator for the session type in the default theme
+// (see Theme.Icon for a configured one)
 func (s Session) Icon() string {
-	switch s.Type {
-	case SessionTypeTmux:
-		return "●" // Filled circle for active sessions
-	case SessionTypeTmuxinator:
-		return "⚙" // Gear icon for tmuxinator projects
-	case SessionTypeDefault:
-		return "○" // Hollow circle for not-yet-started default sessions
-	default:
-		return " "
+	return Theme(nil).Icon(s.Type)
+}
+
+// ASCIIIcon returns the ASCII counterpart of Icon: *, +, or -
+func (s Session) ASCIIIcon() string {
+	return ASCIIIcon(s.Type)
+}
+
+// SessionNameForDirectory derives a session name from a directory's basename
+// tmux doesn't allow '.' or ':' in session names (it would read them as
+// window and pane separators), so they become '_' - the same thing tmux does
+func SessionNameForDirectory(dir string) string {
+	base := filepath.Base(filepath.Clean(dir))
+	if base == "/" || base == "." {
+		return ""
+	}
+	name, _ := SanitizeName(base)
+	return name
+}
+
+// SanitizeName returns name as tmux will accept it, and whether that took
+// any changes: surrounding whitespace is trimmed, and '.' and ':' become
+// '_', since tmux would read them as window and pane separators (and
+// quietly replace them itself, leaving sess looking for a session that
+// doesn't exist). Spaces inside the name are fine.
+func SanitizeName(name string) (string, bool) {
+	sanitized := strings.NewReplacer(".", "_", ":", "_").Replace(strings.TrimSpace(name))
+	return sanitized, sanitized != name
+}
+
+// slugify turns a messy name into one tmux accepts as is
+// Runs of whitespace and dashes become a single '-', and '.' and ':' become
+// '_' like in SessionNameForDirectory. Leading and trailing dashes are dropped
+func slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range name {
+		switch {
+		case unicode.IsSpace(r) || r == '-':
+			dash = true
+			continue
+		case r == '.' || r == ':':
+			r = '_'
+		}
+
+		// Only write a pending dash between two other characters
+		if dash && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		dash = false
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// optionNamePattern matches tmux option names: built-in options like
+// "status-style" (optionally with an array index, "status-format[1]")
+// and user options like "@theme_color"
+var optionNamePattern = regexp.MustCompile(`^(@[A-Za-z0-9_-]+|[a-z][a-z0-9-]*(\[[0-9]+\])?)$`)
+
+// ValidateOptionName checks that key looks like a tmux option name
+func ValidateOptionName(key string) error {
+	if !optionNamePattern.MatchString(key) {
+		return fmt.Errorf("%q is not a valid tmux option name", key)
 	}
+	return nil
 }
 
 // formatWindowCount formats the window count for display
@@ -1,53 +1,183 @@
 package tmux
 
 import (
+	"errors"
 	"fmt"
 	"os"
-	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/datapointchris/sess/internal/runner"
 	"github.com/datapointchris/sess/internal/session"
+	"github.com/datapointchris/sess/internal/ui"
 )
 
 // Client is the real implementation of the TmuxClient interface
 // It executes actual tmux commands
 type Client struct {
-	// In a real application, you might have configuration here
-	// For now, we'll keep it simple
+	// runner executes the tmux commands (swapped for a fake in tests)
+	runner runner.Runner
+
+	// binary is the tmux executable, "tmux" (found on PATH) unless set
+	binary string
+
+	// configPath is the tmux config that reloads source, tried before the
+	// usual locations (see ConfigCandidates)
+	configPath string
+
+	// socketName selects a tmux server other than the default (tmux -L)
+	// socketPath does the same by the socket's full path (tmux -S); at most one is set
+	socketName string
+	socketPath string
+
+	// hasTerminal reports whether stdin is a terminal (replaced in tests)
+	hasTerminal func() bool
 }
 
+// DefaultBinary is the tmux executable used when no other is configured
+const DefaultBinary = "tmux"
+
 // NewClient creates a new tmux client
 // This is a "constructor" function - Go doesn't have constructors like Java/C++
 // Instead, we use functions that return initialized structs
 func NewClient() *Client {
+	return NewClientWithRunner(runner.New())
+}
+
+// NewClientWithRunner creates a tmux client that executes commands through r
+func NewClientWithRunner(r runner.Runner) *Client {
 	// The & operator creates a pointer to the struct
 	// Pointers are important in Go - they let you modify the original
 	// instead of a copy
-	return &Client{}
+	return &Client{runner: r, binary: DefaultBinary, hasTerminal: func() bool { return ui.IsTerminal(os.Stdin) }}
+}
+
+// NewClientWithBinary creates a tmux client that runs the tmux at path
+// instead of the one on PATH, e.g. a wrapper script or a tmux built from source
+func NewClientWithBinary(path string) *Client {
+	return NewClient().WithBinary(path)
+}
+
+// WithConfigPath returns a copy of the client that reloads the tmux config at path
+// An empty path keeps looking in the usual locations
+func (c *Client) WithConfigPath(path string) *Client {
+	clone := *c
+	clone.configPath = path
+	return &clone
+}
+
+// Binary returns the tmux executable the client runs
+func (c *Client) Binary() string {
+	return c.binary
+}
+
+// WithBinary returns a copy of the client that runs the tmux at path
+// An empty path keeps the current binary
+func (c *Client) WithBinary(path string) *Client {
+	clone := *c
+	if path != "" {
+		clone.binary = path
+	}
+	return &clone
+}
+
+// WithSocket returns a copy of the client that talks to the tmux server on
+// the named socket (tmux -L <name>). An empty name means the default server.
+func (c *Client) WithSocket(name string) *Client {
+	clone := *c
+	clone.socketName = name
+	clone.socketPath = ""
+	return &clone
+}
+
+// WithSocketPath returns a copy of the client that talks to the tmux server
+// listening on the socket at path (tmux -S), for sockets outside tmux's own
+// directory. An empty path keeps the current server.
+func (c *Client) WithSocketPath(path string) *Client {
+	clone := *c
+	clone.socketPath = path
+	if path != "" {
+		clone.socketName = ""
+	}
+	return &clone
+}
+
+// output runs a tmux command whose output sess reads
+// Without a UTF-8 locale, tmux replaces tabs and non-ASCII characters in
+// what it prints with '_', which would break the tab-separated list
+// formats and mangle names. -u tells it the output is UTF-8 regardless
+func (c *Client) output(args ...string) ([]byte, error) {
+	return c.runner.Output(c.binary, append([]string{"-u"}, c.args(args...)...)...)
+}
+
+// args builds the full tmux argument list for a subcommand
+// Server selection flags must come before the subcommand, so every
+// invocation goes through here
+func (c *Client) args(args ...string) []string {
+	switch {
+	case c.socketPath != "":
+		return append([]string{"-S", c.socketPath}, args...)
+	case c.socketName != "":
+		return append([]string{"-L", c.socketName}, args...)
+	default:
+		return args
+	}
 }
 
+// displayNameOption is the tmux user option holding the name a session was
+// asked for, when normalization created it under a different one
+const displayNameOption = "@sess_display_name"
+
+// listSessionsFormat is the list-sessions line format ListSessions parses
+// The display name goes last because it's free text that may contain tabs
+const listSessionsFormat = "#{session_name}\t#{session_windows}\t#{session_created}\t#{session_activity}\t#{" + displayNameOption + "}"
+
 // ListSessions returns all active tmux sessions
 // The (c *Client) is the receiver - it makes this a method on Client
 // The * means it receives a pointer to Client
 func (c *Client) ListSessions() ([]session.Session, error) {
-	// exec.Command creates a command to run
-	// We're running: tmux list-sessions -F "#{session_name}:#{session_windows}"
-	cmd := exec.Command("tmux", "list-sessions", "-F", "#{session_name}:#{session_windows}")
-
-	// Run the command and capture output
-	output, err := cmd.CombinedOutput()
+	// We're running: tmux -u list-sessions -F "#{session_name}<tab>#{session_windows}<tab>#{session_created}<tab>#{session_activity}<tab>#{@sess_display_name}"
+	// Tabs separate the fields because display names may contain anything
+	output, err := c.output("list-sessions", "-F", listSessionsFormat)
 	if err != nil {
-		// If tmux returns an error (like "no sessions"), that's not really an error
-		// for us - it just means no sessions exist
+		// If no server is running, that's not really an error for us - it
+		// just means no sessions exist
 		// We'll return an empty slice (Go's term for a dynamic array)
-		return []session.Session{}, nil
+		if noServer(err) {
+			return []session.Session{}, nil
+		}
+
+		// Anything else (tmux missing, a server that won't answer) is a real failure
+		return nil, fmt.Errorf("failed to list tmux sessions: %w", err)
 	}
 
-	// Parse the output into sessions
-	// strings.TrimSpace removes leading/trailing whitespace
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	return parseSessions(string(output)), nil
+}
+
+// noServer reports whether err is tmux saying there's no server to talk to
+// tmux prints "no server running on <socket>" or "error connecting to
+// <socket>" depending on whether the socket file exists
+func noServer(err error) bool {
+	var cmdErr *runner.CommandError
+	if !errors.As(err, &cmdErr) {
+		return false
+	}
+	return strings.Contains(cmdErr.Stderr, "no server running") ||
+		strings.Contains(cmdErr.Stderr, "error connecting to")
+}
+
+// parseSessions parses list-sessions output in listSessionsFormat
+// Fields are tab separated rather than colon separated: tmux itself turns
+// ':' in a new name into '_', but a name can still arrive with colons (an
+// older tmux, or another tool renaming the session), and a colon split
+// would silently drop those sessions
+func parseSessions(output string) []session.Session {
+	// Only trim newlines: trimming all whitespace would also eat the tab
+	// before an empty display name on the last line
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
 
 	// Make a slice to hold our sessions
 	// make() allocates memory for a slice
@@ -61,12 +191,26 @@ func (c *Client) ListSessions() ([]session.Session, error) {
 			continue // skip empty lines
 		}
 
-		// Split each line into name and window count
-		// Format is "name:count"
-		parts := strings.Split(line, ":")
-		if len(parts) != 2 {
+		// Split each line into name, window count, creation time, last
+		// activity, and display name
+		// The display name is empty for sessions sess didn't rename; SplitN
+		// keeps any tabs inside it
+		parts := strings.SplitN(line, "\t", 5)
+		if len(parts) < 2 {
 			continue // skip malformed lines
 		}
+		createdAt := time.Time{}
+		if len(parts) >= 3 {
+			createdAt = parseUnixTime(parts[2])
+		}
+		lastActivity := time.Time{}
+		if len(parts) >= 4 {
+			lastActivity = parseUnixTime(parts[3])
+		}
+		displayName := ""
+		if len(parts) == 5 {
+			displayName = parts[4]
+		}
 
 		name := parts[0]
 		windowCount, err := strconv.Atoi(parts[1])
@@ -77,25 +221,34 @@ func (c *Client) ListSessions() ([]session.Session, error) {
 
 		// Append to our sessions slice
 		sessions = append(sessions, session.Session{
-			Name:        name,
-			Type:        session.SessionTypeTmux,
-			WindowCount: windowCount,
-			IsActive:    true,
-			CreatedAt:   time.Now(), // We could parse this from tmux if needed
+			Name:         name,
+			DisplayName:  displayName,
+			Type:         session.SessionTypeTmux,
+			WindowCount:  windowCount,
+			IsActive:     true,
+			CreatedAt:    createdAt,
+			LastActivity: lastActivity,
 		})
 	}
 
-	return sessions, nil
+	return sessions
+}
+
+// parseUnixTime parses a tmux timestamp (seconds since the epoch)
+// Anything unparseable gives the zero time, which callers treat as unknown
+func parseUnixTime(value string) time.Time {
+	seconds, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
 }
 
 // SessionExists checks if a session exists
 func (c *Client) SessionExists(name string) (bool, error) {
 	// tmux has-session -t <name>
 	// Returns 0 if session exists, 1 if it doesn't
-	cmd := exec.Command("tmux", "has-session", "-t", name)
-
-	// Run() executes the command and waits for it to complete
-	err := cmd.Run()
+	err := c.runner.Run(c.binary, c.args("has-session", "-t", name)...)
 	if err != nil {
 		// If has-session returns error, session doesn't exist
 		return false, nil
@@ -109,72 +262,237 @@ func (c *Client) CreateSession(sess session.Session) error {
 	// Determine if we're already in tmux
 	inTmux := c.IsInsideTmux()
 
-	var cmd *exec.Cmd
 	if inTmux {
 		// If we're in tmux, create a detached session then switch to it
-		// tmux new-session -d -s <name> -c <directory>
-		if sess.Directory != "" {
-			cmd = exec.Command("tmux", "new-session", "-d", "-s", sess.Name, "-c", sess.Directory)
-		} else {
-			cmd = exec.Command("tmux", "new-session", "-d", "-s", sess.Name)
-		}
-
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create session: %w", err)
+		if err := c.CreateDetachedSession(sess); err != nil {
+			return err
 		}
 
 		// Now switch to it
 		return c.SwitchToSession(sess.Name, true)
-	} else {
-		// If we're not in tmux, create and attach in one command
-		// tmux new-session -s <name> -c <directory>
-		if sess.Directory != "" {
-			cmd = exec.Command("tmux", "new-session", "-s", sess.Name, "-c", sess.Directory)
-		} else {
-			cmd = exec.Command("tmux", "new-session", "-s", sess.Name)
-		}
+	}
 
-		// For attach commands, we need to connect stdin/stdout/stderr
-		// so the user can interact with tmux
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+	// If we're not in tmux, create and attach in one command
+	// tmux new-session -s <name> -c <directory>
+	args := []string{"new-session", "-s", sess.Name}
+	if sess.Directory != "" {
+		args = append(args, "-c", sess.Directory)
+	}
+	args = append(args, environmentArgs(sess)...)
+	args = append(args, displayNameArgs(sess)...)
+
+	// Attaching needs stdin/stdout/stderr so the user can interact with tmux
+	return c.runner.Interactive(c.binary, c.args(args...)...)
+}
 
-		return cmd.Run()
+// CreateDetachedSession creates a new tmux session in the background
+func (c *Client) CreateDetachedSession(sess session.Session) error {
+	// tmux new-session -d -s <name> -c <directory>
+	args := []string{"new-session", "-d", "-s", sess.Name}
+	if sess.Directory != "" {
+		args = append(args, "-c", sess.Directory)
 	}
+	args = append(args, environmentArgs(sess)...)
+	args = append(args, displayNameArgs(sess)...)
+
+	if err := c.runner.Run(c.binary, c.args(args...)...); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+// environmentArgs returns the new-session -e flags for the session's environment
+// -e sets the variables in the session environment, like set-environment,
+// but also in the first pane's shell, which set-environment after the
+// session exists would be too late for
+func environmentArgs(sess session.Session) []string {
+	keys := make([]string, 0, len(sess.Environment))
+	for key := range sess.Environment {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys) // Map order is random, keep the command stable
+
+	var args []string
+	for _, key := range keys {
+		args = append(args, "-e", key+"="+sess.Environment[key])
+	}
+	return args
+}
+
+// displayNameArgs chains a set-option after new-session that remembers the
+// session's display name, if it has one
+// Chaining (tmux's ";" separator) sets it before an attaching new-session blocks
+func displayNameArgs(sess session.Session) []string {
+	if sess.DisplayName == "" {
+		return nil
+	}
+	return []string{";", "set-option", "-t", sess.Name, displayNameOption, sess.DisplayName}
 }
 
 // SwitchToSession switches to an existing session
 func (c *Client) SwitchToSession(name string, fromTmux bool) error {
-	var cmd *exec.Cmd
 	if fromTmux {
 		// If we're in tmux, use switch-client
-		cmd = exec.Command("tmux", "switch-client", "-t", name)
-	} else {
-		// If we're not in tmux, use attach-session
-		cmd = exec.Command("tmux", "attach-session", "-t", name)
-		cmd.Stdin = os.Stdin
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+		err := c.runner.Run(c.binary, c.args("switch-client", "-t", name)...)
+
+		switch switchFallbackFor(err, c.hasTerminal) {
+		case fallbackAttach:
+			// TMUX is what made us think we were in a client; tmux refuses to
+			// attach while it's set, thinking the attach would be nested
+			return c.runner.InteractiveEnv(withoutEnv(os.Environ(), "TMUX"), c.binary, c.args("attach-session", "-t", name)...)
+		case fallbackDetached:
+			attach := strings.Join(append([]string{c.binary}, c.args("attach-session", "-t", name)...), " ")
+			fmt.Fprintf(os.Stderr, "No tmux client to switch, session '%s' is running in the background (%s)\n", name, attach)
+			return nil
+		}
+		return err
+	}
+
+	// If we're not in tmux, use attach-session
+	return c.AttachToSession(name, false)
+}
+
+// withoutEnv returns env (KEY=value entries) without the named variable
+func withoutEnv(env []string, name string) []string {
+	kept := make([]string, 0, len(env))
+	for _, entry := range env {
+		if !strings.HasPrefix(entry, name+"=") {
+			kept = append(kept, entry)
+		}
 	}
+	return kept
+}
+
+// switchFallback is what to do after switch-client fails
+type switchFallback int
+
+const (
+	// fallbackNone means a real failure, to be reported as-is
+	fallbackNone switchFallback = iota
 
-	return cmd.Run()
+	// fallbackAttach means there's no client to switch, but a terminal to attach from
+	fallbackAttach
+
+	// fallbackDetached means there's no client and no terminal: the session
+	// stays running in the background and the user is told how to get to it
+	fallbackDetached
+)
+
+// switchFallbackFor decides how to recover from a switch-client error
+// switch-client needs a client to switch, and there isn't one when sess runs
+// from a script or a keybinding outside any client (TMUX is set, but nothing
+// is attached to us)
+func switchFallbackFor(err error, hasTerminal func() bool) switchFallback {
+	if err == nil || !isNoClientError(err) {
+		return fallbackNone
+	}
+	if hasTerminal() {
+		return fallbackAttach
+	}
+	return fallbackDetached
+}
+
+// isNoClientError reports whether a tmux error means there was no client to act on
+func isNoClientError(err error) bool {
+	message := err.Error()
+	return strings.Contains(message, "no current client") || strings.Contains(message, "can't find client")
 }
 
 // AttachToSession attaches to a session (used when not in tmux)
-func (c *Client) AttachToSession(name string) error {
-	cmd := exec.Command("tmux", "attach-session", "-t", name)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	return cmd.Run()
+// With detachOthers, every other client attached to the session is detached
+func (c *Client) AttachToSession(name string, detachOthers bool) error {
+	args := []string{"attach-session"}
+	if detachOthers {
+		args = append(args, "-d")
+	}
+	args = append(args, "-t", name)
+
+	return c.runner.Interactive(c.binary, c.args(args...)...)
+}
+
+// AttachedClients returns how many clients are attached to a session
+func (c *Client) AttachedClients(name string) (int, error) {
+	output, err := c.output("display-message", "-p", "-t", name, "#{session_attached}")
+	if err != nil {
+		return 0, fmt.Errorf("failed to query session %s: %w", name, err)
+	}
+
+	count, err := strconv.Atoi(strings.TrimSpace(string(output)))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected attached count %q: %w", strings.TrimSpace(string(output)), err)
+	}
+
+	return count, nil
 }
 
 // IsInsideTmux checks if we're currently running inside tmux
+// When the client targets a named socket, only a tmux on that same server counts -
+// switch-client can't move a client to a different server
 func (c *Client) IsInsideTmux() bool {
 	// tmux sets the TMUX environment variable when you're inside a session
-	// In Go, os.Getenv() retrieves environment variables
-	return os.Getenv("TMUX") != ""
+	// Its value looks like "/tmp/tmux-1000/default,1234,0" - socket path first
+	env := os.Getenv("TMUX")
+	if env == "" {
+		return false
+	}
+	socketPath, _, _ := strings.Cut(env, ",")
+	switch {
+	case c.socketPath != "":
+		return filepath.Clean(socketPath) == filepath.Clean(c.socketPath)
+	case c.socketName != "":
+		return filepath.Base(socketPath) == c.socketName
+	default:
+		return true
+	}
+}
+
+// CurrentSession returns the name of the session sess is running in
+// Outside tmux there's no such session, and that's an error
+func (c *Client) CurrentSession() (string, error) {
+	// Without a client to answer for, tmux would name whichever session it
+	// thinks is most recent, which isn't the one sess is in
+	if !c.IsInsideTmux() {
+		return "", fmt.Errorf("not in a tmux session")
+	}
+
+	// tmux display-message -p '#{session_name}' answers for the client's session
+	output, err := c.output("display-message", "-p", "#{session_name}")
+	if err != nil {
+		return "", fmt.Errorf("failed to get the current session: %w", err)
+	}
+
+	return strings.TrimSpace(string(output)), nil
+}
+
+// ShowEnvironment returns a session's tmux environment
+func (c *Client) ShowEnvironment(name string) (map[string]string, error) {
+	output, err := c.output("show-environment", "-t", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environment of session %s: %w", name, err)
+	}
+
+	return parseEnvironment(string(output)), nil
+}
+
+// parseEnvironment parses show-environment output, one VAR=value per line
+// A line of just -VAR means the variable is removed in this session, which
+// is left out rather than copied as an empty value
+func parseEnvironment(output string) map[string]string {
+	env := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		if line == "" || strings.HasPrefix(line, "-") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue // skip malformed lines
+		}
+		env[key] = value
+	}
+
+	return env
 }
 
 // SwitchToLastSession switches to the previously active session
@@ -184,8 +502,17 @@ func (c *Client) SwitchToLastSession() error {
 	}
 
 	// tmux switch-client -l (l for "last")
-	cmd := exec.Command("tmux", "switch-client", "-l")
-	return cmd.Run()
+	return c.runner.Run(c.binary, c.args("switch-client", "-l")...)
+}
+
+// DetachSession detaches the current client, leaving its session running
+func (c *Client) DetachSession() error {
+	if !c.IsInsideTmux() {
+		return fmt.Errorf("not in a tmux session")
+	}
+
+	// tmux detach-client (with no -t, the client we're running in)
+	return c.runner.Run(c.binary, c.args("detach-client")...)
 }
 
 // DeleteSession deletes a tmux session
@@ -198,16 +525,326 @@ func (c *Client) DeleteSession(name string) error {
 		return fmt.Errorf("session '%s' does not exist", name)
 	}
 
-	cmd := exec.Command("tmux", "kill-session", "-t", name)
-	if err := cmd.Run(); err != nil {
+	if err := c.runner.Run(c.binary, c.args("kill-session", "-t", name)...); err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
 
 	return nil
 }
 
+// RenameSession renames a tmux session
+func (c *Client) RenameSession(oldName, newName string) error {
+	// tmux rename-session -t <old> <new>
+	if err := c.runner.Run(c.binary, c.args("rename-session", "-t", oldName, newName)...); err != nil {
+		return fmt.Errorf("failed to rename session %s: %w", oldName, err)
+	}
+
+	return nil
+}
+
+// listWindowsFormat is the list-windows line format parseWindows parses
+// Names and paths are free text, so they go last, after the fixed fields
+// pane_current_command, like pane_current_path, is the window's active pane's
+const listWindowsFormat = "#{window_index}\t#{window_active}\t#{window_panes}\t#{window_layout}\t#{pane_current_command}\t#{window_name}\t#{pane_current_path}"
+
+// ServerRunning reports whether the tmux server answers
+// tmux info fails when there's no server, so its exit status is enough
+func (c *Client) ServerRunning() bool {
+	return c.runner.Run(c.binary, c.args("info")...) == nil
+}
+
+// KillServer ends the tmux server
+func (c *Client) KillServer() error {
+	// tmux kill-server
+	if err := c.runner.Run(c.binary, c.args("kill-server")...); err != nil {
+		return fmt.Errorf("failed to kill the tmux server: %w", err)
+	}
+
+	return nil
+}
+
+// ListWindows returns the windows of a session
+func (c *Client) ListWindows(name string) ([]session.Window, error) {
+	// Tabs separate the fields because window names and paths may contain spaces
+	output, err := c.output("list-windows", "-t", name, "-F", listWindowsFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list windows for session %s: %w", name, err)
+	}
+
+	return parseWindows(string(output)), nil
+}
+
+// parseWindows parses the output of list-windows into Window values
+func parseWindows(output string) []session.Window {
+	windows := []session.Window{}
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+
+		parts := strings.SplitN(line, "\t", 7)
+		if len(parts) != 7 {
+			continue // skip malformed lines
+		}
+
+		index, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		// A bad pane count isn't worth losing the window over
+		paneCount, _ := strconv.Atoi(parts[2])
+
+		windows = append(windows, session.Window{
+			Index:     index,
+			Name:      parts[5],
+			Directory: parts[6],
+			Command:   parts[4],
+			Active:    parts[1] == "1",
+			PaneCount: paneCount,
+			Layout:    parts[3],
+		})
+	}
+
+	return windows
+}
+
+// NewWindow appends a window to a session without selecting it
+func (c *Client) NewWindow(sessionName, name, directory string) error {
+	// The trailing colon targets the session itself, so tmux picks the next free index
+	// -d keeps the current window selected, so the user lands on the first window
+	args := []string{"new-window", "-d", "-t", sessionName + ":"}
+	if name != "" {
+		args = append(args, "-n", name)
+	}
+	if directory != "" {
+		args = append(args, "-c", directory)
+	}
+
+	if err := c.runner.Run(c.binary, c.args(args...)...); err != nil {
+		return fmt.Errorf("failed to create window in session %s: %w", sessionName, err)
+	}
+
+	return nil
+}
+
+// SendKeys types a command into the target pane and presses Enter
+func (c *Client) SendKeys(target, command string) error {
+	// tmux send-keys -t <target> "<command>" C-m
+	// C-m is the Enter key
+	if err := c.runner.Run(c.binary, c.args("send-keys", "-t", target, command, "C-m")...); err != nil {
+		return fmt.Errorf("failed to send keys to %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// ListPanes returns the targets of every pane in a session, in window and pane order
+func (c *Client) ListPanes(name string) ([]string, error) {
+	// -s lists the panes of all the session's windows, not just the current one
+	// Only the indexes are listed, one pane per line: the session's name
+	// may contain spaces, so it's added here rather than split back out
+	output, err := c.output("list-panes", "-s", "-t", name, "-F",
+		"#{window_index}.#{pane_index}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes for session %s: %w", name, err)
+	}
+
+	var panes []string
+	for _, line := range strings.Split(strings.TrimRight(string(output), "\n"), "\n") {
+		if line != "" {
+			panes = append(panes, name+":"+line)
+		}
+	}
+	return panes, nil
+}
+
+// listPaneDetailsFormat is the list-panes line format parsePaneDetails parses
+const listPaneDetailsFormat = "#{window_index}\t#{pane_current_command}\t#{pane_current_path}"
+
+// ListPaneDetails returns every pane in a session with what it's running and where
+func (c *Client) ListPaneDetails(name string) ([]session.Pane, error) {
+	// -s lists the panes of all the session's windows, in window and pane order
+	output, err := c.output("list-panes", "-s", "-t", name, "-F", listPaneDetailsFormat)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list panes for session %s: %w", name, err)
+	}
+
+	return parsePaneDetails(string(output)), nil
+}
+
+// parsePaneDetails parses list-panes output in listPaneDetailsFormat
+func parsePaneDetails(output string) []session.Pane {
+	panes := []session.Pane{}
+	for _, line := range strings.Split(strings.TrimRight(output, "\n"), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue // skip malformed (and empty) lines
+		}
+
+		windowIndex, err := strconv.Atoi(parts[0])
+		if err != nil {
+			continue
+		}
+
+		panes = append(panes, session.Pane{
+			WindowIndex: windowIndex,
+			Command:     parts[1],
+			Directory:   parts[2],
+		})
+	}
+
+	return panes
+}
+
+// SplitWindow adds a pane to the target window, starting in directory
+func (c *Client) SplitWindow(target, directory string) error {
+	// tmux split-window -t <target> [-c <directory>]
+	args := []string{"split-window", "-t", target}
+	if directory != "" {
+		args = append(args, "-c", directory)
+	}
+
+	if err := c.runner.Run(c.binary, c.args(args...)...); err != nil {
+		return fmt.Errorf("failed to split window %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// SelectLayout arranges the target window's panes by a window_layout string
+func (c *Client) SelectLayout(target, layout string) error {
+	// tmux select-layout -t <target> <layout>
+	if err := c.runner.Run(c.binary, c.args("select-layout", "-t", target, layout)...); err != nil {
+		return fmt.Errorf("failed to select layout for %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// RenameWindow renames the target window
+func (c *Client) RenameWindow(target, name string) error {
+	// tmux rename-window -t <target> <name>
+	if err := c.runner.Run(c.binary, c.args("rename-window", "-t", target, name)...); err != nil {
+		return fmt.Errorf("failed to rename window %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// MoveWindow moves a session's window to the end of another session
+func (c *Client) MoveWindow(srcSession string, windowIndex int, dstSession string) error {
+	// tmux move-window -d -s <src>:<index> -t <dst>:
+	// The trailing colon targets the session itself, so tmux picks the next
+	// free index instead of failing when the source index is taken
+	// -d leaves the destination's current window selected
+	src := srcSession + ":" + strconv.Itoa(windowIndex)
+	if err := c.runner.Run(c.binary, c.args("move-window", "-d", "-s", src, "-t", dstSession+":")...); err != nil {
+		return fmt.Errorf("failed to move window %s to session %s: %w", src, dstSession, err)
+	}
+
+	return nil
+}
+
+// CapturePane returns the text in a pane
+// scrollback adds that many lines of history above what's on screen
+func (c *Client) CapturePane(target string, scrollback int) (string, error) {
+	// tmux capture-pane -p -t <target> [-S -<lines>]
+	// -p prints to stdout instead of a paste buffer, and a negative start
+	// line reaches back into the history
+	args := []string{"capture-pane", "-p", "-t", target}
+	if scrollback > 0 {
+		args = append(args, "-S", "-"+strconv.Itoa(scrollback))
+	}
+
+	output, err := c.output(args...)
+	if err != nil {
+		return "", fmt.Errorf("failed to capture pane %s: %w", target, err)
+	}
+
+	return string(output), nil
+}
+
+// SetOption sets a tmux option on a session
+func (c *Client) SetOption(sessionName, key, value string) error {
+	// tmux set-option -t <session> <key> <value>
+	if err := c.runner.Run(c.binary, c.args("set-option", "-t", sessionName, key, value)...); err != nil {
+		return fmt.Errorf("failed to set option %s on session %s: %w", key, sessionName, err)
+	}
+
+	return nil
+}
+
+// GetOption returns the value of a tmux option for a session
+// An option the session doesn't set itself has the global value (-A), and
+// a user option (@name) that was never set is "" rather than an error
+func (c *Client) GetOption(sessionName, key string) (string, error) {
+	// tmux show-options -A -v -t <session> <key>
+	output, err := c.output("show-options", "-A", "-v", "-t", sessionName, key)
+	if err != nil {
+		var cmdErr *runner.CommandError
+		if strings.HasPrefix(key, "@") && errors.As(err, &cmdErr) && strings.Contains(cmdErr.Stderr, "invalid option") {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read option %s of session %s: %w", key, sessionName, err)
+	}
+
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// SetSyncPanes turns synchronize-panes on or off for a window
+func (c *Client) SetSyncPanes(window string, on bool) error {
+	value := "off"
+	if on {
+		value = "on"
+	}
+
+	// tmux setw [-t <window>] synchronize-panes on|off
+	// Without -t, tmux uses the current window
+	args := []string{"setw"}
+	if window != "" {
+		args = append(args, "-t", window)
+	}
+	args = append(args, "synchronize-panes", value)
+
+	if err := c.runner.Run(c.binary, c.args(args...)...); err != nil {
+		return fmt.Errorf("failed to set synchronize-panes: %w", err)
+	}
+
+	return nil
+}
+
+// GetSyncPanes reports whether synchronize-panes is on for a window
+func (c *Client) GetSyncPanes(window string) (bool, error) {
+	// tmux showw -v [-t <window>] synchronize-panes prints "on" or "off"
+	args := []string{"showw", "-v"}
+	if window != "" {
+		args = append(args, "-t", window)
+	}
+	args = append(args, "synchronize-panes")
+
+	output, err := c.output(args...)
+	if err != nil {
+		return false, fmt.Errorf("failed to read synchronize-panes: %w", err)
+	}
+
+	// An unset option prints nothing, which means off
+	return strings.TrimSpace(string(output)) == "on", nil
+}
+
 // ReloadConfig reloads tmux configuration in all active sessions
 func (c *Client) ReloadConfig() error {
+	// Find the config first, so a missing one fails before anything is reloaded
+	configPath, err := ResolveConfigPath(ConfigCandidates(c.configPath))
+	if err != nil {
+		return err
+	}
+
+	// Without this, no server would look the same as a server with no sessions
+	if !c.ServerRunning() {
+		return session.ErrServerNotRunning
+	}
+
 	// Get all active sessions
 	sessions, err := c.ListSessions()
 	if err != nil {
@@ -219,18 +856,68 @@ func (c *Client) ReloadConfig() error {
 	}
 
 	// Reload config in each session
-	configPath := os.ExpandEnv("$HOME/.config/tmux/tmux.conf")
 	for _, sess := range sessions {
-		cmd := exec.Command("tmux", "source-file", "-t", sess.Name, configPath)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to reload config for session %s: %w", sess.Name, err)
+		if err := c.sourceConfig(sess.Name, configPath); err != nil {
+			return err
 		}
-		fmt.Printf("  ✓ Reloaded session: %s\n", sess.Name)
 	}
 
 	return nil
 }
 
+// ConfigCandidates lists the places a tmux config is looked for, in order:
+// the explicit path, $SESS_TMUX_CONF, $XDG_CONFIG_HOME/tmux/tmux.conf,
+// ~/.config/tmux/tmux.conf, then ~/.tmux.conf
+// Unset variables are left out rather than producing relative paths
+func ConfigCandidates(explicit string) []string {
+	var candidates []string
+	if explicit != "" {
+		candidates = append(candidates, explicit)
+	}
+	if env := os.Getenv("SESS_TMUX_CONF"); env != "" {
+		candidates = append(candidates, env)
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		candidates = append(candidates, filepath.Join(xdg, "tmux", "tmux.conf"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		candidates = append(candidates,
+			filepath.Join(home, ".config", "tmux", "tmux.conf"),
+			filepath.Join(home, ".tmux.conf"),
+		)
+	}
+	return candidates
+}
+
+// ResolveConfigPath returns the first candidate that exists
+func ResolveConfigPath(candidates []string) (string, error) {
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("no tmux config found (looked in %s)", strings.Join(candidates, ", "))
+}
+
+// ReloadConfigFor reloads tmux configuration in a single session
+func (c *Client) ReloadConfigFor(name string) error {
+	configPath, err := ResolveConfigPath(ConfigCandidates(c.configPath))
+	if err != nil {
+		return err
+	}
+	return c.sourceConfig(name, configPath)
+}
+
+// sourceConfig has tmux source configPath for the named session
+func (c *Client) sourceConfig(name, configPath string) error {
+	if err := c.runner.Run(c.binary, c.args("source-file", "-t", name, configPath)...); err != nil {
+		return fmt.Errorf("failed to reload config for session %s: %w", name, err)
+	}
+	fmt.Printf("  ✓ Reloaded session: %s\n", name)
+
+	return nil
+}
+
 // Verify that Client implements the TmuxClient interface at compile time
 // This is a Go idiom - if Client doesn't implement TmuxClient, this won't compile
 // The _ means we're declaring a variable but never using it
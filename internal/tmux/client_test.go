@@ -0,0 +1,853 @@
+package tmux
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/datapointchris/sess/internal/runner"
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// stubConfigLoader is a config loader with no default sessions
+type stubConfigLoader struct{}
+
+func (s stubConfigLoader) LoadDefaultSessions(platform string) ([]session.SessionConfig, error) {
+	return nil, nil
+}
+
+func (s stubConfigLoader) GetSessionConfig(name, platform string) (*session.SessionConfig, error) {
+	return nil, errors.New("session not found")
+}
+
+// TestSocketAppliedAcrossGoFlow checks that every tmux call made while
+// resolving and switching to a session targets the selected socket
+func TestSocketAppliedAcrossGoFlow(t *testing.T) {
+	// Pretend we're inside a tmux client on the "work" server
+	t.Setenv("TMUX", "/tmp/tmux-1000/work,1234,0")
+
+	r := &fakeRunner{}
+	tmuxClient := NewClientWithRunner(r).WithSocket("work")
+	manager := session.NewManager(tmuxClient, NewTmuxinatorClient(tmuxClient), stubConfigLoader{}, "linux")
+
+	if err := manager.GoToSession("api"); err != nil {
+		t.Fatalf("GoToSession() unexpected error: %v", err)
+	}
+
+	if len(r.calls) == 0 {
+		t.Fatal("expected tmux to be called")
+	}
+
+	// tmux requires -L before the subcommand
+	for _, call := range r.calls {
+		if call[0] != "tmux" {
+			continue
+		}
+		if len(call) < 4 || call[1] != "-L" || call[2] != "work" {
+			t.Errorf("call %v does not target socket work", call)
+		}
+	}
+
+	last := r.calls[len(r.calls)-1]
+	if last[3] != "switch-client" || last[5] != "api" {
+		t.Errorf("last call = %v, want switch-client to api", last)
+	}
+}
+
+// TestIsInsideTmuxWithSocket checks that only a tmux client on the same server counts
+func TestIsInsideTmuxWithSocket(t *testing.T) {
+	tests := []struct {
+		name   string
+		env    string
+		socket string
+		want   bool
+	}{
+		{name: "not in tmux", env: "", socket: "", want: false},
+		{name: "default server", env: "/tmp/tmux-1000/default,1,0", socket: "", want: true},
+		{name: "same socket", env: "/tmp/tmux-1000/work,1,0", socket: "work", want: true},
+		{name: "different socket", env: "/tmp/tmux-1000/default,1,0", socket: "work", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", tt.env)
+
+			client := NewClientWithRunner(&fakeRunner{}).WithSocket(tt.socket)
+			if got := client.IsInsideTmux(); got != tt.want {
+				t.Errorf("IsInsideTmux() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSocketArgs checks that the server selection flag comes before the subcommand
+func TestSocketArgs(t *testing.T) {
+	tests := []struct {
+		name   string
+		client func(*Client) *Client
+		want   string
+	}{
+		{name: "default server", client: func(c *Client) *Client { return c }, want: "tmux has-session -t api"},
+		{name: "socket name", client: func(c *Client) *Client { return c.WithSocket("work") }, want: "tmux -L work has-session -t api"},
+		{name: "socket path", client: func(c *Client) *Client { return c.WithSocketPath("/run/tmux/work") }, want: "tmux -S /run/tmux/work has-session -t api"},
+		{
+			name:   "path replaces name",
+			client: func(c *Client) *Client { return c.WithSocket("work").WithSocketPath("/run/tmux/work") },
+			want:   "tmux -S /run/tmux/work has-session -t api",
+		},
+		{
+			name:   "name replaces path",
+			client: func(c *Client) *Client { return c.WithSocketPath("/run/tmux/work").WithSocket("other") },
+			want:   "tmux -L other has-session -t api",
+		},
+		{
+			name:   "empty path keeps name",
+			client: func(c *Client) *Client { return c.WithSocket("work").WithSocketPath("") },
+			want:   "tmux -L work has-session -t api",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRunner{}
+			client := tt.client(NewClientWithRunner(r))
+
+			_, _ = client.SessionExists("api")
+
+			if len(r.calls) != 1 {
+				t.Fatalf("ran %d commands, want 1", len(r.calls))
+			}
+			if got := strings.Join(r.calls[0], " "); got != tt.want {
+				t.Errorf("ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseWindows checks that list-windows lines parse into windows
+func TestParseWindows(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []session.Window
+	}{
+		{
+			name:   "single window",
+			output: "1\t1\t1\tc0e8,80x24,0,0,1\tzsh\tzsh\t/code/api\n",
+			want:   []session.Window{{Index: 1, Name: "zsh", Command: "zsh", Directory: "/code/api", Active: true, PaneCount: 1, Layout: "c0e8,80x24,0,0,1"}},
+		},
+		{
+			name:   "multiple windows",
+			output: "1\t0\t2\ta1\tnvim\teditor\t/code/api\n2\t1\t1\ta2\tzsh\tserver\t/code/api/cmd\n3\t0\t3\ta3\tzsh\tlogs\t/var/log\n",
+			want: []session.Window{
+				{Index: 1, Name: "editor", Command: "nvim", Directory: "/code/api", PaneCount: 2, Layout: "a1"},
+				{Index: 2, Name: "server", Command: "zsh", Directory: "/code/api/cmd", Active: true, PaneCount: 1, Layout: "a2"},
+				{Index: 3, Name: "logs", Command: "zsh", Directory: "/var/log", PaneCount: 3, Layout: "a3"},
+			},
+		},
+		{
+			name:   "names and paths with spaces",
+			output: "0\t1\t1\tb1\tzsh\tmy notes\t/home/me/My Documents\n",
+			want:   []session.Window{{Index: 0, Name: "my notes", Command: "zsh", Directory: "/home/me/My Documents", Active: true, PaneCount: 1, Layout: "b1"}},
+		},
+		{
+			name:   "malformed lines skipped",
+			output: "garbage\nx\t1\t1\tl\tzsh\tzsh\t/tmp\n1\t1\t1\tl\tzsh\tzsh\t/tmp\n",
+			want:   []session.Window{{Index: 1, Name: "zsh", Command: "zsh", Directory: "/tmp", Active: true, PaneCount: 1, Layout: "l"}},
+		},
+		{name: "empty output", output: "", want: []session.Window{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseWindows(tt.output); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseWindows() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestRenameSessionArgs checks the rename-session argument list
+func TestRenameSessionArgs(t *testing.T) {
+	r := &fakeRunner{}
+	client := NewClientWithRunner(r).WithSocket("work")
+
+	if err := client.RenameSession("scratch", "api-spike"); err != nil {
+		t.Fatalf("RenameSession() unexpected error: %v", err)
+	}
+
+	want := "tmux -L work rename-session -t scratch api-spike"
+	if len(r.calls) != 1 || strings.Join(r.calls[0], " ") != want {
+		t.Errorf("ran %v, want %q", r.calls, want)
+	}
+}
+
+// TestDetachSession checks that detach-client only runs inside tmux
+func TestDetachSession(t *testing.T) {
+	r := &fakeRunner{}
+	client := NewClientWithRunner(r)
+
+	t.Setenv("TMUX", "")
+	if err := client.DetachSession(); err == nil {
+		t.Error("DetachSession() outside tmux returned no error")
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("ran %v outside tmux, want nothing", r.calls)
+	}
+
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	if err := client.DetachSession(); err != nil {
+		t.Fatalf("DetachSession() unexpected error: %v", err)
+	}
+	if len(r.calls) != 1 || strings.Join(r.calls[0], " ") != "tmux detach-client" {
+		t.Errorf("ran %v, want %q", r.calls, "tmux detach-client")
+	}
+}
+
+// TestCurrentSession checks that the session name is read from display-message, and only inside tmux
+func TestCurrentSession(t *testing.T) {
+	r := &fakeRunner{output: map[string]string{"tmux -u display-message -p #{session_name}": "my project\n"}}
+	client := NewClientWithRunner(r)
+
+	t.Setenv("TMUX", "")
+	if _, err := client.CurrentSession(); err == nil {
+		t.Error("CurrentSession() outside tmux returned no error")
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("ran %v outside tmux, want nothing", r.calls)
+	}
+
+	t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+	name, err := client.CurrentSession()
+	if err != nil {
+		t.Fatalf("CurrentSession() unexpected error: %v", err)
+	}
+	if name != "my project" {
+		t.Errorf("CurrentSession() = %q, want %q", name, "my project")
+	}
+}
+
+// TestParsePaneDetails checks that list-panes lines parse into panes
+func TestParsePaneDetails(t *testing.T) {
+	output := "1\tnvim\t/code/api\n1\t-zsh\t/code/my project\nx\tzsh\t/tmp\n2\ttail\t/var/log\n"
+	want := []session.Pane{
+		{WindowIndex: 1, Command: "nvim", Directory: "/code/api"},
+		{WindowIndex: 1, Command: "-zsh", Directory: "/code/my project"},
+		{WindowIndex: 2, Command: "tail", Directory: "/var/log"},
+	}
+
+	if got := parsePaneDetails(output); !reflect.DeepEqual(got, want) {
+		t.Errorf("parsePaneDetails() = %+v, want %+v", got, want)
+	}
+	if got := parsePaneDetails(""); len(got) != 0 {
+		t.Errorf("parsePaneDetails(\"\") = %+v, want none", got)
+	}
+}
+
+// TestLayoutArgs checks the commands that rebuild a window's panes
+func TestLayoutArgs(t *testing.T) {
+	r := &fakeRunner{}
+	client := NewClientWithRunner(r)
+
+	if err := client.RenameWindow("api:{end}", "editor"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SplitWindow("api:{end}", "/code/api"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SelectLayout("api:{end}", "a1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.MoveWindow("api", 3, "web"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"tmux rename-window -t api:{end} editor",
+		"tmux split-window -t api:{end} -c /code/api",
+		"tmux select-layout -t api:{end} a1",
+		"tmux move-window -d -s api:3 -t web:",
+	}
+	for i, call := range r.calls {
+		if got := strings.Join(call, " "); i >= len(want) || got != want[i] {
+			t.Errorf("call %d = %q, want %v", i, got, want)
+		}
+	}
+}
+
+// TestIsInsideTmuxWithSocketPath checks that a socket path must match the client's server exactly
+func TestIsInsideTmuxWithSocketPath(t *testing.T) {
+	tests := []struct {
+		name string
+		env  string
+		path string
+		want bool
+	}{
+		{name: "same path", env: "/run/tmux/work,1,0", path: "/run/tmux/work", want: true},
+		{name: "same path, unclean", env: "/run/tmux/work,1,0", path: "/run/tmux//work", want: true},
+		{name: "same name, other directory", env: "/tmp/tmux-1000/work,1,0", path: "/run/tmux/work", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TMUX", tt.env)
+
+			client := NewClientWithRunner(&fakeRunner{}).WithSocketPath(tt.path)
+			if got := client.IsInsideTmux(); got != tt.want {
+				t.Errorf("IsInsideTmux() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAttachToSessionArgs checks the attach-session argument list
+func TestAttachToSessionArgs(t *testing.T) {
+	tests := []struct {
+		name         string
+		detachOthers bool
+		want         string
+	}{
+		{name: "default", detachOthers: false, want: "tmux attach-session -t api"},
+		{name: "takeover", detachOthers: true, want: "tmux attach-session -d -t api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRunner{}
+			client := NewClientWithRunner(r)
+
+			if err := client.AttachToSession("api", tt.detachOthers); err != nil {
+				t.Fatalf("AttachToSession() unexpected error: %v", err)
+			}
+
+			if len(r.calls) != 1 {
+				t.Fatalf("ran %d commands, want 1", len(r.calls))
+			}
+			if got := strings.Join(r.calls[0], " "); got != tt.want {
+				t.Errorf("ran %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReloadConfigForTarget checks that source-file targets the named session
+func TestReloadConfigForTarget(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SESS_TMUX_CONF", "")
+	configPath := writeFile(t, filepath.Join(home, ".config", "tmux", "tmux.conf"))
+
+	r := &fakeRunner{}
+	client := NewClientWithRunner(r)
+
+	if err := client.ReloadConfigFor("api"); err != nil {
+		t.Fatalf("ReloadConfigFor() unexpected error: %v", err)
+	}
+
+	want := "tmux source-file -t api " + configPath
+	if len(r.calls) != 1 || strings.Join(r.calls[0], " ") != want {
+		t.Errorf("ran %v, want %q", r.calls, want)
+	}
+}
+
+// TestReloadConfigServerDown checks that reloading with no tmux server says
+// so, rather than that there are no sessions
+func TestReloadConfigServerDown(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+	t.Setenv("SESS_TMUX_CONF", "")
+	writeFile(t, filepath.Join(home, ".config", "tmux", "tmux.conf"))
+
+	noServer := &runner.CommandError{Name: "tmux", Stderr: "no server running on /tmp/tmux-501/default", Err: errors.New("exit status 1")}
+
+	tests := []struct {
+		name    string
+		errs    map[string]error
+		wantErr string
+	}{
+		{name: "no server", errs: map[string]error{"tmux info": noServer}, wantErr: "tmux is not running"},
+		{name: "server with no sessions", wantErr: "no active tmux sessions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithRunner(&fakeRunner{errs: tt.errs})
+
+			err := client.ReloadConfig()
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("ReloadConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestGetOption checks reading a session option, including a user option
+// that was never set and a session that doesn't exist
+func TestGetOption(t *testing.T) {
+	show := func(key string) string { return "tmux -u show-options -A -v -t api " + key }
+	r := &fakeRunner{
+		output: map[string]string{show("status-style"): "bg=red\n"},
+		errs: map[string]error{
+			show("@unset"): &runner.CommandError{Name: "tmux", Stderr: "invalid option: @unset", Err: errors.New("exit status 1")},
+			show("bogus"):  &runner.CommandError{Name: "tmux", Stderr: "invalid option: bogus", Err: errors.New("exit status 1")},
+			"tmux -u show-options -A -v -t gone status-style": &runner.CommandError{Name: "tmux", Stderr: "no such session: gone", Err: errors.New("exit status 1")},
+		},
+	}
+	client := NewClientWithRunner(r)
+
+	tests := []struct {
+		session string
+		key     string
+		want    string
+		wantErr bool
+	}{
+		{session: "api", key: "status-style", want: "bg=red"},
+		{session: "api", key: "@unset", want: ""},
+		{session: "api", key: "bogus", wantErr: true},
+		{session: "gone", key: "status-style", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.session+" "+tt.key, func(t *testing.T) {
+			got, err := client.GetOption(tt.session, tt.key)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetOption() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("GetOption() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// writeFile creates an empty file at path, and its directory
+func writeFile(t *testing.T, path string) string {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestResolveConfigPath checks the order the tmux config locations are tried in
+func TestResolveConfigPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		explicit string
+		env      string
+		xdg      bool
+		files    []string // created relative to home
+		want     string   // relative to home, "" for an error
+	}{
+		{name: "explicit wins", explicit: "custom.conf", env: "env.conf", files: []string{"custom.conf", "env.conf", ".tmux.conf"}, want: "custom.conf"},
+		{name: "env before xdg", env: "env.conf", xdg: true, files: []string{"env.conf", "xdg/tmux/tmux.conf"}, want: "env.conf"},
+		{name: "xdg before home config", xdg: true, files: []string{"xdg/tmux/tmux.conf", ".config/tmux/tmux.conf"}, want: "xdg/tmux/tmux.conf"},
+		{name: "home config before dotfile", files: []string{".config/tmux/tmux.conf", ".tmux.conf"}, want: ".config/tmux/tmux.conf"},
+		{name: "dotfile", files: []string{".tmux.conf"}, want: ".tmux.conf"},
+		{name: "missing explicit falls through", explicit: "missing.conf", files: []string{".tmux.conf"}, want: ".tmux.conf"},
+		{name: "nothing exists", explicit: "missing.conf"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			home := t.TempDir()
+			t.Setenv("HOME", home)
+			t.Setenv("XDG_CONFIG_HOME", "")
+			if tt.xdg {
+				t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, "xdg"))
+			}
+			t.Setenv("SESS_TMUX_CONF", "")
+			if tt.env != "" {
+				t.Setenv("SESS_TMUX_CONF", filepath.Join(home, tt.env))
+			}
+			for _, file := range tt.files {
+				writeFile(t, filepath.Join(home, file))
+			}
+
+			explicit := ""
+			if tt.explicit != "" {
+				explicit = filepath.Join(home, tt.explicit)
+			}
+
+			got, err := ResolveConfigPath(ConfigCandidates(explicit))
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("ResolveConfigPath() = %s, want an error", got)
+				}
+				// The error names every place that was checked
+				for _, checked := range []string{"missing.conf", ".config/tmux/tmux.conf", ".tmux.conf"} {
+					if !strings.Contains(err.Error(), checked) {
+						t.Errorf("error %q doesn't mention %s", err, checked)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveConfigPath() unexpected error: %v", err)
+			}
+			if want := filepath.Join(home, tt.want); got != want {
+				t.Errorf("ResolveConfigPath() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+// TestSwitchFallbackFor tests the decision made when switch-client fails
+func TestSwitchFallbackFor(t *testing.T) {
+	noClient := errors.New("tmux: no current client")
+
+	tests := []struct {
+		name        string
+		err         error
+		hasTerminal bool
+		want        switchFallback
+	}{
+		{name: "switch worked", err: nil, want: fallbackNone},
+		{name: "other failure", err: errors.New("tmux: can't find session: api"), hasTerminal: true, want: fallbackNone},
+		{name: "no client, terminal available", err: noClient, hasTerminal: true, want: fallbackAttach},
+		{name: "no client, no terminal", err: noClient, hasTerminal: false, want: fallbackDetached},
+		{name: "client gone", err: errors.New("tmux: can't find client: /dev/pts/3"), hasTerminal: false, want: fallbackDetached},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := switchFallbackFor(tt.err, func() bool { return tt.hasTerminal })
+			if got != tt.want {
+				t.Errorf("switchFallbackFor() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSwitchWithoutClient tests the fallbacks as seen through the commands they run
+func TestSwitchWithoutClient(t *testing.T) {
+	t.Run("attaches when there's a terminal", func(t *testing.T) {
+		t.Setenv("TMUX", "/tmp/tmux-1000/default,1,0")
+		r := &fakeRunner{errs: map[string]error{"tmux switch-client -t api": errors.New("tmux: no current client")}}
+		client := NewClientWithRunner(r)
+		client.hasTerminal = func() bool { return true }
+
+		if err := client.SwitchToSession("api", true); err != nil {
+			t.Fatalf("SwitchToSession() unexpected error: %v", err)
+		}
+
+		last := strings.Join(r.calls[len(r.calls)-1], " ")
+		if last != "tmux attach-session -t api" {
+			t.Errorf("last command = %q, want an attach", last)
+		}
+		if r.env == nil || slices.ContainsFunc(r.env, func(entry string) bool { return strings.HasPrefix(entry, "TMUX=") }) {
+			t.Errorf("attach env = %v, want it without TMUX so tmux doesn't refuse a nested attach", r.env)
+		}
+		if os.Getenv("TMUX") == "" {
+			t.Error("TMUX was cleared for all of sess, not just the attach")
+		}
+	})
+
+	t.Run("leaves the session detached without a terminal", func(t *testing.T) {
+		r := &fakeRunner{errs: map[string]error{"tmux switch-client -t api": errors.New("tmux: no current client")}}
+		client := NewClientWithRunner(r)
+		client.hasTerminal = func() bool { return false }
+
+		if err := client.SwitchToSession("api", true); err != nil {
+			t.Fatalf("SwitchToSession() unexpected error: %v", err)
+		}
+		if len(r.calls) != 1 {
+			t.Errorf("ran %v, want only the failed switch", r.calls)
+		}
+	})
+
+	t.Run("other errors are returned", func(t *testing.T) {
+		r := &fakeRunner{errs: map[string]error{"tmux switch-client -t api": errors.New("tmux: can't find session: api")}}
+		client := NewClientWithRunner(r)
+		client.hasTerminal = func() bool { return true }
+
+		if err := client.SwitchToSession("api", true); err == nil {
+			t.Error("SwitchToSession() expected error but got none")
+		}
+	})
+}
+
+// TestCapturePaneArgs checks the capture-pane argument list
+func TestCapturePaneArgs(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     string
+		scrollback int
+		want       string
+	}{
+		{name: "visible screen", target: "api:", scrollback: 0, want: "tmux -u capture-pane -p -t api:"},
+		{name: "with history", target: "api:1.0", scrollback: 500, want: "tmux -u capture-pane -p -t api:1.0 -S -500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRunner{}
+			client := NewClientWithRunner(r)
+
+			if _, err := client.CapturePane(tt.target, tt.scrollback); err != nil {
+				t.Fatalf("CapturePane() unexpected error: %v", err)
+			}
+
+			if len(r.calls) != 1 || strings.Join(r.calls[0], " ") != tt.want {
+				t.Errorf("ran %v, want %q", r.calls, tt.want)
+			}
+		})
+	}
+}
+
+// TestListPanes checks that every window's panes are listed as targets
+func TestListPanes(t *testing.T) {
+	r := &fakeRunner{output: map[string]string{
+		"tmux -u list-panes -s -t my api -F #{window_index}.#{pane_index}": "1.0\n1.1\n2.0\n",
+	}}
+	client := NewClientWithRunner(r)
+
+	// The session's name has a space, which mustn't split its targets
+	panes, err := client.ListPanes("my api")
+	if err != nil {
+		t.Fatalf("ListPanes() unexpected error: %v", err)
+	}
+	if got := strings.Join(panes, ","); got != "my api:1.0,my api:1.1,my api:2.0" {
+		t.Errorf("ListPanes() = %s, want my api:1.0,my api:1.1,my api:2.0", got)
+	}
+}
+
+// TestBinary checks that every command runs the configured tmux binary
+func TestBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		binary string
+		want   string
+	}{
+		{name: "default", binary: "", want: "tmux"},
+		{name: "custom path", binary: "/opt/tmux/bin/tmux", want: "/opt/tmux/bin/tmux"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRunner{}
+			client := NewClientWithRunner(r).WithBinary(tt.binary).WithSocket("work")
+			if got := client.Binary(); got != tt.want {
+				t.Errorf("Binary() = %q, want %q", got, tt.want)
+			}
+
+			_, _ = client.ListSessions()
+			_, _ = client.SessionExists("api")
+			_ = client.AttachToSession("api", false)
+
+			if len(r.calls) != 3 {
+				t.Fatalf("ran %d commands, want 3", len(r.calls))
+			}
+			for _, call := range r.calls {
+				if call[0] != tt.want {
+					t.Errorf("ran %q, want it to start with %s", strings.Join(call, " "), tt.want)
+				}
+			}
+		})
+	}
+}
+
+// TestListSessionsErrors checks that a missing server means no sessions,
+// while any other failure is reported
+func TestListSessionsErrors(t *testing.T) {
+	command := "tmux -u list-sessions -F " + listSessionsFormat
+
+	tests := []struct {
+		name    string
+		err     error
+		wantErr bool
+	}{
+		{
+			name: "no server running",
+			err:  &runner.CommandError{Name: "tmux", Stderr: "no server running on /tmp/tmux-501/default", Err: errors.New("exit status 1")},
+		},
+		{
+			name: "no socket file",
+			err:  &runner.CommandError{Name: "tmux", Stderr: "error connecting to /tmp/tmux-501/default (No such file or directory)", Err: errors.New("exit status 1")},
+		},
+		{
+			name:    "tmux not installed",
+			err:     errors.New(`exec: "tmux": executable file not found in $PATH`),
+			wantErr: true,
+		},
+		{
+			name:    "other tmux failure",
+			err:     &runner.CommandError{Name: "tmux", Stderr: "server exited unexpectedly", Err: errors.New("exit status 1")},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewClientWithRunner(&fakeRunner{errs: map[string]error{command: tt.err}})
+
+			sessions, err := client.ListSessions()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ListSessions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(sessions) != 0 {
+				t.Errorf("ListSessions() = %+v, want no sessions", sessions)
+			}
+		})
+	}
+}
+
+// TestParseSessions checks that list-sessions lines parse into sessions,
+// including names with colons that a colon-separated format would drop
+func TestParseSessions(t *testing.T) {
+	tests := []struct {
+		name        string
+		output      string
+		wantNames   []string
+		wantWindows []int
+	}{
+		{name: "plain name", output: "api\t3\t1700000000\t\n", wantNames: []string{"api"}, wantWindows: []int{3}},
+		{name: "one colon", output: "feature:auth\t2\t1700000000\t\n", wantNames: []string{"feature:auth"}, wantWindows: []int{2}},
+		{name: "several colons", output: "192.168.1.5:8080:ssh\t1\t1700000000\t\n", wantNames: []string{"192.168.1.5:8080:ssh"}, wantWindows: []int{1}},
+		{name: "trailing colon", output: "logs:\t4\t1700000000\t\n", wantNames: []string{"logs:"}, wantWindows: []int{4}},
+		{
+			name:        "mixed lines",
+			output:      "api\t3\t1700000000\t1700000000\t\nfeature:auth\t2\t1700000000\t1700000000\tFeature: auth\nlogs:\t1\t1700000000\t1700000000\t\n",
+			wantNames:   []string{"api", "feature:auth", "logs:"},
+			wantWindows: []int{3, 2, 1},
+		},
+		{name: "malformed line skipped", output: "garbage\napi\t1\t1700000000\t\n", wantNames: []string{"api"}, wantWindows: []int{1}},
+		{name: "empty output", output: "", wantNames: []string{}, wantWindows: []int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := parseSessions(tt.output)
+
+			names := []string{}
+			windows := []int{}
+			for _, sess := range sessions {
+				names = append(names, sess.Name)
+				windows = append(windows, sess.WindowCount)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("names = %q, want %q", names, tt.wantNames)
+			}
+			if !reflect.DeepEqual(windows, tt.wantWindows) {
+				t.Errorf("window counts = %v, want %v", windows, tt.wantWindows)
+			}
+		})
+	}
+}
+
+// TestParseSessionsCreatedAt checks that the creation time comes from tmux,
+// and that a bad or missing timestamp gives the zero time
+func TestParseSessionsCreatedAt(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   time.Time
+	}{
+		{name: "timestamp", output: "api\t1\t1700000000\t\n", want: time.Unix(1700000000, 0)},
+		{name: "malformed", output: "api\t1\tyesterday\t\n", want: time.Time{}},
+		{name: "empty", output: "api\t1\t\t\n", want: time.Time{}},
+		{name: "missing", output: "api\t1\n", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := parseSessions(tt.output)
+			if len(sessions) != 1 {
+				t.Fatalf("parseSessions() returned %d sessions, want 1", len(sessions))
+			}
+			if !sessions[0].CreatedAt.Equal(tt.want) {
+				t.Errorf("CreatedAt = %v, want %v", sessions[0].CreatedAt, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseSessionsLastActivity checks that the last activity time comes
+// from tmux, and that a bad or missing timestamp gives the zero time
+func TestParseSessionsLastActivity(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   time.Time
+	}{
+		{name: "timestamp", output: "api\t1\t1700000000\t1700003600\t\n", want: time.Unix(1700003600, 0)},
+		{name: "malformed", output: "api\t1\t1700000000\tsoon\t\n", want: time.Time{}},
+		{name: "empty", output: "api\t1\t1700000000\t\t\n", want: time.Time{}},
+		{name: "missing", output: "api\t1\t1700000000\n", want: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sessions := parseSessions(tt.output)
+			if len(sessions) != 1 {
+				t.Fatalf("parseSessions() returned %d sessions, want 1", len(sessions))
+			}
+			if !sessions[0].LastActivity.Equal(tt.want) {
+				t.Errorf("LastActivity = %v, want %v", sessions[0].LastActivity, tt.want)
+			}
+		})
+	}
+}
+
+// TestDisplayName checks that a display name is stored on creation and read back when listing
+func TestDisplayName(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	r := &fakeRunner{output: map[string]string{
+		"tmux -u list-sessions -F " + listSessionsFormat: "My-Notes\t1\t1700000000\t1700000500\tMy Notes\napi\t3\t1700000000\t1700000600\t\n",
+	}}
+	client := NewClientWithRunner(r)
+
+	if err := client.CreateDetachedSession(session.Session{Name: "My-Notes", DisplayName: "My Notes"}); err != nil {
+		t.Fatalf("CreateDetachedSession() unexpected error: %v", err)
+	}
+	want := "tmux new-session -d -s My-Notes ; set-option -t My-Notes @sess_display_name My Notes"
+	if got := strings.Join(r.calls[0], " "); got != want {
+		t.Errorf("ran %q, want %q", got, want)
+	}
+
+	sessions, err := client.ListSessions()
+	if err != nil {
+		t.Fatalf("ListSessions() unexpected error: %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].DisplayName != "My Notes" || sessions[1].DisplayName != "" || sessions[1].WindowCount != 3 {
+		t.Errorf("ListSessions() = %+v", sessions)
+	}
+}
+
+// TestCloneEnvironment checks reading a session's environment and passing it to new-session
+func TestCloneEnvironment(t *testing.T) {
+	t.Setenv("TMUX", "")
+
+	r := &fakeRunner{output: map[string]string{
+		"tmux -u show-environment -t work": "AWS_PROFILE=staging\n-DISPLAY\nGREETING=a=b\n",
+	}}
+	client := NewClientWithRunner(r)
+
+	env, err := client.ShowEnvironment("work")
+	if err != nil {
+		t.Fatalf("ShowEnvironment() unexpected error: %v", err)
+	}
+	want := map[string]string{"AWS_PROFILE": "staging", "GREETING": "a=b"}
+	if !reflect.DeepEqual(env, want) {
+		t.Errorf("ShowEnvironment() = %v, want %v", env, want)
+	}
+
+	if err := client.CreateDetachedSession(session.Session{Name: "spike", Environment: env}); err != nil {
+		t.Fatalf("CreateDetachedSession() unexpected error: %v", err)
+	}
+	got := strings.Join(r.calls[len(r.calls)-1], " ")
+	if got != "tmux new-session -d -s spike -e AWS_PROFILE=staging -e GREETING=a=b" {
+		t.Errorf("ran %q", got)
+	}
+}
@@ -0,0 +1,71 @@
+package tmux
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// SocketDir returns the directory tmux keeps its server sockets in
+// This is where "tmux -L <name>" looks: $TMUX_TMPDIR/tmux-<uid>, or
+// /tmp/tmux-<uid> when TMUX_TMPDIR isn't set
+func SocketDir() string {
+	base := os.Getenv("TMUX_TMPDIR")
+	if base == "" {
+		base = "/tmp"
+	}
+	return filepath.Join(base, fmt.Sprintf("tmux-%d", os.Getuid()))
+}
+
+// DiscoverSockets returns the names of the tmux sockets in dir, sorted
+// Only actual socket files count; a missing dir just means no servers
+func DiscoverSockets(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tmux socket directory %s: %w", dir, err)
+	}
+
+	sockets := []string{}
+	for _, entry := range entries {
+		if entry.Type()&fs.ModeSocket != 0 {
+			sockets = append(sockets, entry.Name())
+		}
+	}
+
+	sort.Strings(sockets)
+	return sockets, nil
+}
+
+// ListSessionsAllSockets lists the sessions of every tmux server with a socket
+// in dir, each tagged with the socket it belongs to
+// A dead socket (left behind by a server that crashed) has no sessions, so
+// it simply contributes nothing
+func (c *Client) ListSessionsAllSockets(dir string) ([]session.Session, error) {
+	sockets, err := DiscoverSockets(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []session.Session
+	for _, socket := range sockets {
+		sessions, err := c.WithSocket(socket).ListSessions()
+		if err != nil {
+			continue
+		}
+
+		for _, sess := range sessions {
+			sess.Socket = socket
+			all = append(all, sess)
+		}
+	}
+
+	return all, nil
+}
@@ -0,0 +1,87 @@
+package tmux
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// makeSocket creates a real Unix socket file named name in dir
+func makeSocket(t *testing.T, dir, name string) {
+	t.Helper()
+
+	listener, err := net.Listen("unix", filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("failed to create socket %s: %v", name, err)
+	}
+	t.Cleanup(func() { listener.Close() })
+}
+
+// TestDiscoverSockets tests that only socket files are picked up
+func TestDiscoverSockets(t *testing.T) {
+	dir := t.TempDir()
+	makeSocket(t, dir, "work")
+	makeSocket(t, dir, "default")
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "subdir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	sockets, err := DiscoverSockets(dir)
+	if err != nil {
+		t.Fatalf("DiscoverSockets() returned error: %v", err)
+	}
+	if strings.Join(sockets, ",") != "default,work" {
+		t.Errorf("DiscoverSockets() = %v, want [default work]", sockets)
+	}
+
+	// No tmux server has ever run
+	sockets, err = DiscoverSockets(filepath.Join(dir, "missing"))
+	if err != nil || len(sockets) != 0 {
+		t.Errorf("DiscoverSockets() on a missing dir = %v, %v, want no sockets and no error", sockets, err)
+	}
+}
+
+// TestSocketDir tests where the socket directory is looked for
+func TestSocketDir(t *testing.T) {
+	t.Setenv("TMUX_TMPDIR", "/run/user/1000")
+	if got := SocketDir(); !strings.HasPrefix(got, "/run/user/1000/tmux-") {
+		t.Errorf("SocketDir() = %q, want it under $TMUX_TMPDIR", got)
+	}
+
+	t.Setenv("TMUX_TMPDIR", "")
+	if got := SocketDir(); !strings.HasPrefix(got, "/tmp/tmux-") {
+		t.Errorf("SocketDir() = %q, want it under /tmp", got)
+	}
+}
+
+// TestListSessionsAllSockets tests that each server's sessions are tagged with its socket
+func TestListSessionsAllSockets(t *testing.T) {
+	dir := t.TempDir()
+	makeSocket(t, dir, "default")
+	makeSocket(t, dir, "work")
+	makeSocket(t, dir, "dead") // No server answers, so no output
+
+	format := "list-sessions -F " + listSessionsFormat
+	r := &fakeRunner{output: map[string]string{
+		"tmux -u -L default " + format: "dotfiles\t2\t1700000000\t\n",
+		"tmux -u -L work " + format:    "api\t3\t1700000000\t\nweb\t1\t1700000000\t\n",
+	}}
+
+	sessions, err := NewClientWithRunner(r).ListSessionsAllSockets(dir)
+	if err != nil {
+		t.Fatalf("ListSessionsAllSockets() returned error: %v", err)
+	}
+
+	var got []string
+	for _, sess := range sessions {
+		got = append(got, sess.Socket+"/"+sess.Name)
+	}
+	if strings.Join(got, ",") != "default/dotfiles,work/api,work/web" {
+		t.Errorf("sessions = %v, want default/dotfiles, work/api, work/web", got)
+	}
+}
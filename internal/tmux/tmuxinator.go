@@ -1,70 +1,240 @@
 package tmux
 
 import (
-	"os/exec"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
 	"strings"
+	"sync"
 
+	"github.com/datapointchris/sess/internal/runner"
 	"github.com/datapointchris/sess/internal/session"
+	"gopkg.in/yaml.v3"
 )
 
+// TmuxinatorBinaries are the commands tmuxinator can be run as, in order of preference
+// mux is the short alias tmuxinator installs, and some setups only have that one
+var TmuxinatorBinaries = []string{"tmuxinator", "mux"}
+
 // TmuxinatorClient handles tmuxinator project operations
 type TmuxinatorClient struct {
-	// We could add configuration here if needed
 	tmuxClient *Client
+	runner     runner.Runner
+
+	// candidates are the commands tmuxinator may be run as, and binary is the
+	// first of them found on PATH ("" when none is). It's looked up once,
+	// the first time it's needed.
+	candidates []string
+	binaryOnce sync.Once
+	binary     string
+
+	// projectDirs are where the project files are looked for, to find the
+	// name of the session a project runs in
+	projectDirs []string
+
+	// Listing projects is slow (tmuxinator is a Ruby program), so the list is
+	// loaded once and kept until Invalidate. load's sync.Once also makes
+	// concurrent callers wait for the first load instead of starting their own.
+	loadMu sync.Mutex
+	load   *projectLoad
+}
+
+// projectLoad is one load of the project list
+type projectLoad struct {
+	once     sync.Once
+	projects []string
 }
 
 // NewTmuxinatorClient creates a new tmuxinator client
+// It shares the tmux client's runner so both execute commands the same way
 func NewTmuxinatorClient(tmuxClient *Client) *TmuxinatorClient {
+	return NewTmuxinatorClientWithRunner(tmuxClient, tmuxClient.runner)
+}
+
+// NewTmuxinatorClientWithRunner creates a tmuxinator client that executes commands through r
+func NewTmuxinatorClientWithRunner(tmuxClient *Client, r runner.Runner) *TmuxinatorClient {
+	return NewTmuxinatorClientWithBinary(tmuxClient, r, TmuxinatorBinaries...)
+}
+
+// NewTmuxinatorClientWithBinary creates a tmuxinator client that runs the
+// first of binaries found on PATH, instead of tmuxinator or mux
+func NewTmuxinatorClientWithBinary(tmuxClient *Client, r runner.Runner, binaries ...string) *TmuxinatorClient {
 	return &TmuxinatorClient{
-		tmuxClient: tmuxClient,
+		tmuxClient:  tmuxClient,
+		runner:      r,
+		candidates:  binaries,
+		projectDirs: projectDirectories(),
 	}
 }
 
-// IsInstalled checks if tmuxinator is available
+// projectDirectories lists the directories tmuxinator keeps project files in, in
+// the order it looks: $TMUXINATOR_CONFIG, $XDG_CONFIG_HOME/tmuxinator,
+// ~/.config/tmuxinator, then ~/.tmuxinator
+// Unset variables are left out rather than producing relative paths
+func projectDirectories() []string {
+	var dirs []string
+	if env := os.Getenv("TMUXINATOR_CONFIG"); env != "" {
+		dirs = append(dirs, env)
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dirs = append(dirs, filepath.Join(xdg, "tmuxinator"))
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs,
+			filepath.Join(home, ".config", "tmuxinator"),
+			filepath.Join(home, ".tmuxinator"),
+		)
+	}
+	return dirs
+}
+
+// sessionName returns the name of the session a project runs in
+// tmuxinator names it after the project file's name (or older
+// project_name) key, which doesn't have to match the project. When the file
+// can't be found or read, or the name is an ERB template that only
+// tmuxinator can fill in, it's the project's name.
+func (t *TmuxinatorClient) sessionName(project string) string {
+	for _, dir := range t.projectDirs {
+		for _, ext := range []string{".yml", ".yaml"} {
+			data, err := os.ReadFile(filepath.Join(dir, project+ext))
+			if err != nil {
+				continue
+			}
+			if name := projectSessionName(data); name != "" {
+				return name
+			}
+			return project
+		}
+	}
+	return project
+}
+
+// projectSessionName reads the session name out of a tmuxinator project
+// file, or returns "" if it doesn't set one sess can use
+func projectSessionName(data []byte) string {
+	var project struct {
+		Name        string `yaml:"name"`
+		ProjectName string `yaml:"project_name"`
+	}
+	if err := yaml.Unmarshal(data, &project); err != nil {
+		return ""
+	}
+
+	// project_name wins, as it does in tmuxinator
+	name := project.ProjectName
+	if name == "" {
+		name = project.Name
+	}
+	if strings.Contains(name, "<%") {
+		return ""
+	}
+	return strings.TrimSpace(name)
+}
+
+// Binary returns the command tmuxinator is run as, or "" if it isn't installed
+func (t *TmuxinatorClient) Binary() string {
+	t.binaryOnce.Do(func() {
+		for _, name := range t.candidates {
+			if _, err := t.runner.LookPath(name); err == nil {
+				t.binary = name
+				return
+			}
+		}
+	})
+	return t.binary
+}
+
+// command returns the command to run tmuxinator as
+// When nothing is on PATH it's the preferred name, so the error names it
+func (t *TmuxinatorClient) command() string {
+	if binary := t.Binary(); binary != "" || len(t.candidates) == 0 {
+		return binary
+	}
+	return t.candidates[0]
+}
+
+// IsInstalled checks if tmuxinator is available, under either of its names
 func (t *TmuxinatorClient) IsInstalled() bool {
-	// Check if tmuxinator command exists
-	// exec.LookPath searches for an executable in PATH
-	_, err := exec.LookPath("tmuxinator")
-	return err == nil
+	return t.Binary() != ""
+}
+
+// Prefetch starts loading the project list in the background
+// Later calls to ListProjects or ProjectExists wait for it to finish
+// instead of running tmuxinator a second time
+func (t *TmuxinatorClient) Prefetch() {
+	go t.loaded()
+}
+
+// Invalidate drops the cached project list, so the next ListProjects runs
+// tmuxinator again. Watches call it so new projects show up
+func (t *TmuxinatorClient) Invalidate() {
+	t.loadMu.Lock()
+	defer t.loadMu.Unlock()
+	t.load = nil
 }
 
 // ListProjects returns all available tmuxinator projects
 func (t *TmuxinatorClient) ListProjects() ([]string, error) {
+	// Return a copy so callers can't modify the cache
+	return slices.Clone(t.loaded().projects), nil
+}
+
+// loaded returns the current load of the project list, loading it first if needed
+func (t *TmuxinatorClient) loaded() *projectLoad {
+	t.loadMu.Lock()
+	if t.load == nil {
+		t.load = &projectLoad{}
+	}
+	load := t.load
+	t.loadMu.Unlock()
+
+	load.once.Do(func() { load.projects = t.loadProjects() })
+	return load
+}
+
+// loadProjects runs tmuxinator and parses the project list
+func (t *TmuxinatorClient) loadProjects() []string {
 	if !t.IsInstalled() {
-		// If tmuxinator isn't installed, return empty list
-		return []string{}, nil
+		// If tmuxinator isn't installed, there are no projects
+		return nil
 	}
 
 	// Run: tmuxinator list
-	cmd := exec.Command("tmuxinator", "list")
-	output, err := cmd.CombinedOutput()
+	output, err := t.runner.Output(t.command(), "list")
 	if err != nil {
-		// If command fails, return empty list
-		return []string{}, nil
+		// If command fails, treat it as no projects
+		return nil
 	}
 
-	// Parse the output
-	// tmuxinator list output looks like:
-	// tmuxinator projects:
-	// project1 project2 project3
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(lines) < 2 {
-		return []string{}, nil
-	}
+	return parseProjects(string(output))
+}
+
+// ansiEscape matches terminal color codes, which some setups force into
+// tmuxinator's output even when it isn't writing to a terminal
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*[A-Za-z]`)
 
-	// Skip the first line (header) and get the project names
-	// Projects are space-separated on subsequent lines
+// parseProjects parses the output of tmuxinator list
+// Versions differ in the header and in the layout: older ones print
+//
+//	tmuxinator projects:
+//	project1 project2 project3
+//
+// padded into columns, while "list --newline" prints one project per line
+// Any line ending in ":" is taken as a header, and every word on the other
+// lines is a project
+func parseProjects(output string) []string {
 	var projects []string
-	for _, line := range lines[1:] {
-		// Split by whitespace and add all non-empty entries
-		for _, project := range strings.Fields(line) {
-			if project != "" {
-				projects = append(projects, project)
-			}
+	for _, line := range strings.Split(ansiEscape.ReplaceAllString(output, ""), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasSuffix(line, ":") {
+			continue
 		}
+		projects = append(projects, strings.Fields(line)...)
 	}
 
-	return projects, nil
+	return projects
 }
 
 // ProjectExists checks if a tmuxinator project exists
@@ -84,27 +254,124 @@ func (t *TmuxinatorClient) ProjectExists(name string) (bool, error) {
 	return false, nil
 }
 
-// StartProject starts a tmuxinator project
-func (t *TmuxinatorClient) StartProject(name string, fromTmux bool) error {
-	var cmd *exec.Cmd
-
-	if fromTmux {
-		// If we're in tmux, start without attaching then switch
-		// tmuxinator start <name> --no-attach
-		cmd = exec.Command("tmuxinator", "start", name, "--no-attach")
-		if err := cmd.Run(); err != nil {
-			return err
-		}
+// StartProject starts a tmuxinator project and returns the name of its session
+func (t *TmuxinatorClient) StartProject(name string, fromTmux bool) (string, error) {
+	if err := t.checkServer(name); err != nil {
+		return "", err
+	}
+	target := t.sessionName(name)
 
-		// Switch to the newly created session
-		// Tmuxinator creates a session with the same name as the project
-		return t.tmuxClient.SwitchToSession(name, true)
-	} else {
+	if !fromTmux {
 		// If we're not in tmux, start and attach
 		// tmuxinator start <name>
-		cmd = exec.Command("tmuxinator", "start", name)
-		return cmd.Run()
+		// This ends up attaching to tmux, so it needs the terminal, and
+		// tmuxinator attaches to the session if it's already running
+		return target, t.runner.Interactive(t.command(), "start", name)
+	}
+
+	// If we're in tmux, start without attaching then switch
+	// The sessions before and after show which one the project created
+	before, err := t.tmuxClient.ListSessions()
+	if err != nil {
+		return "", err
+	}
+
+	// The project's session is already running, maybe under another name
+	if hasSession(before, target) {
+		return target, t.tmuxClient.SwitchToSession(target, true)
+	}
+
+	// tmuxinator start <name> --no-attach
+	if err := t.runner.Run(t.command(), "start", name, "--no-attach"); err != nil {
+		return "", err
+	}
+
+	after, err := t.tmuxClient.ListSessions()
+	if err != nil {
+		return "", err
+	}
+	started, err := startedSession(name, target, before, after)
+	if err != nil {
+		return "", err
+	}
+
+	// Switch to the newly created session
+	return started, t.tmuxClient.SwitchToSession(started, true)
+}
+
+// checkServer refuses to run a project when sess talks to a tmux server
+// other than the default one. tmuxinator has no option to pick a server
+// (only the project file can, with socket_name), so the project would
+// start or stop somewhere else than the sessions sess lists
+func (t *TmuxinatorClient) checkServer(project string) error {
+	if t.tmuxClient.socketName != "" || t.tmuxClient.socketPath != "" {
+		return fmt.Errorf("tmuxinator project %s can't be started or stopped with --socket or --socket-path: tmuxinator always uses the tmux server its project file names", project)
 	}
+	return nil
+}
+
+// hasSession reports whether a session called name is in sessions
+func hasSession(sessions []session.Session, name string) bool {
+	for _, sess := range sessions {
+		if sess.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// startedSession works out which session tmuxinator started for a project
+// expected is the session the project file names, which wins if it's new.
+// The file can't always be read (an ERB name, say), so otherwise a single
+// new session wins. With no new session, the project created nothing.
+func startedSession(project, expected string, before, after []session.Session) (string, error) {
+	var created []string
+	for _, sess := range after {
+		if !hasSession(before, sess.Name) {
+			created = append(created, sess.Name)
+		}
+	}
+
+	switch {
+	case slices.Contains(created, expected):
+		return expected, nil
+	case len(created) == 1:
+		return created[0], nil
+	case len(created) == 0:
+		return "", fmt.Errorf("tmuxinator project %s didn't start a session", project)
+	default:
+		return "", fmt.Errorf("tmuxinator project %s started several sessions (%s), not sure which to switch to", project, strings.Join(created, ", "))
+	}
+}
+
+// StartProjectDetached starts a tmuxinator project in the background and
+// returns the name of its session
+func (t *TmuxinatorClient) StartProjectDetached(name string) (string, error) {
+	if err := t.checkServer(name); err != nil {
+		return "", err
+	}
+
+	// tmuxinator start <name> --no-attach
+	if err := t.runner.Run(t.command(), "start", name, "--no-attach"); err != nil {
+		return "", fmt.Errorf("failed to start tmuxinator project %s: %w", name, err)
+	}
+
+	return t.sessionName(name), nil
+}
+
+// StopProject stops a tmuxinator project
+// Unlike kill-session, this runs the project's stop hooks first
+func (t *TmuxinatorClient) StopProject(name string) error {
+	if err := t.checkServer(name); err != nil {
+		return err
+	}
+
+	// tmuxinator stop <name>
+	if err := t.runner.Run(t.command(), "stop", name); err != nil {
+		return fmt.Errorf("failed to stop tmuxinator project %s: %w", name, err)
+	}
+
+	return nil
 }
 
 // Verify interface implementation at compile time
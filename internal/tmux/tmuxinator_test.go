@@ -0,0 +1,484 @@
+package tmux
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeRunner records every command instead of executing it
+// output maps a command line (e.g. "tmuxinator list") to what it prints,
+// and errs maps a command line to the error Run or Output returns for it
+type fakeRunner struct {
+	mu        sync.Mutex
+	calls     [][]string
+	output    map[string]string
+	errs      map[string]error
+	installed map[string]bool
+	delay     time.Duration
+	outputs   atomic.Int32
+
+	// then replaces output once the command it's keyed by has run, like
+	// a session appearing after tmuxinator start
+	then map[string]map[string]string
+
+	// env is the environment the last InteractiveEnv command got
+	env []string
+}
+
+func (f *fakeRunner) record(name string, args []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, append([]string{name}, args...))
+}
+
+func (f *fakeRunner) Run(name string, args ...string) error {
+	f.record(name, args)
+	key := strings.Join(append([]string{name}, args...), " ")
+	if output, ok := f.then[key]; ok {
+		f.output = output
+	}
+	return f.errs[key]
+}
+
+func (f *fakeRunner) Output(name string, args ...string) ([]byte, error) {
+	f.record(name, args)
+	f.outputs.Add(1)
+
+	// Simulate a slow subprocess
+	time.Sleep(f.delay)
+
+	key := strings.Join(append([]string{name}, args...), " ")
+	return []byte(f.output[key]), f.errs[key]
+}
+
+// ran reports whether a command line was run
+func (f *fakeRunner) ran(command string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, call := range f.calls {
+		if strings.Join(call, " ") == command {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeRunner) Interactive(name string, args ...string) error {
+	f.record(name, args)
+	return nil
+}
+
+func (f *fakeRunner) InteractiveEnv(env []string, name string, args ...string) error {
+	f.mu.Lock()
+	f.env = env
+	f.mu.Unlock()
+	f.record(name, args)
+	return nil
+}
+
+func (f *fakeRunner) LookPath(name string) (string, error) {
+	if f.installed[name] {
+		return "/usr/bin/" + name, nil
+	}
+	return "", errors.New("not found")
+}
+
+// TestPrefetchConcurrent checks that a prefetch and many concurrent readers
+// share a single tmuxinator invocation
+// Run with -race to verify the cache is free of data races
+func TestPrefetchConcurrent(t *testing.T) {
+	r := &fakeRunner{
+		installed: map[string]bool{"tmuxinator": true},
+		output:    map[string]string{"tmuxinator list": "tmuxinator projects:\napi web\ninfra\n"},
+		delay:     50 * time.Millisecond,
+	}
+	client := NewTmuxinatorClientWithRunner(NewClient(), r)
+
+	client.Prefetch()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			projects, err := client.ListProjects()
+			if err != nil {
+				t.Errorf("ListProjects() returned error: %v", err)
+				return
+			}
+			if strings.Join(projects, ",") != "api,web,infra" {
+				t.Errorf("ListProjects() = %v, want [api web infra]", projects)
+			}
+
+			exists, _ := client.ProjectExists("infra")
+			if !exists {
+				t.Error("ProjectExists(infra) = false, want true")
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := r.outputs.Load(); got != 1 {
+		t.Errorf("tmuxinator list ran %d times, want 1", got)
+	}
+}
+
+// TestInvalidate checks that projects are listed again after Invalidate,
+// and only then
+func TestInvalidate(t *testing.T) {
+	r := &fakeRunner{
+		installed: map[string]bool{"tmuxinator": true},
+		output:    map[string]string{"tmuxinator list": "tmuxinator projects:\napi\n"},
+	}
+	client := NewTmuxinatorClientWithRunner(NewClient(), r)
+
+	if _, err := client.ListProjects(); err != nil {
+		t.Fatalf("ListProjects() returned error: %v", err)
+	}
+	r.output["tmuxinator list"] = "tmuxinator projects:\napi web\n"
+
+	projects, _ := client.ListProjects()
+	if strings.Join(projects, ",") != "api" {
+		t.Errorf("ListProjects() = %v before Invalidate, want the cached [api]", projects)
+	}
+
+	client.Invalidate()
+	projects, _ = client.ListProjects()
+	if strings.Join(projects, ",") != "api,web" {
+		t.Errorf("ListProjects() = %v after Invalidate, want [api web]", projects)
+	}
+	if got := r.outputs.Load(); got != 2 {
+		t.Errorf("tmuxinator list ran %d times, want 2", got)
+	}
+}
+
+// TestListProjectsNotInstalled checks that a missing tmuxinator yields no projects
+func TestListProjectsNotInstalled(t *testing.T) {
+	r := &fakeRunner{}
+	client := NewTmuxinatorClientWithRunner(NewClient(), r)
+
+	projects, err := client.ListProjects()
+	if err != nil {
+		t.Fatalf("ListProjects() returned error: %v", err)
+	}
+	if len(projects) != 0 {
+		t.Errorf("ListProjects() = %v, want none", projects)
+	}
+	if len(r.calls) != 0 {
+		t.Errorf("ran %v, want no commands", r.calls)
+	}
+}
+
+// TestStopProject checks the tmuxinator stop invocation
+func TestStopProject(t *testing.T) {
+	r := &fakeRunner{}
+	client := NewTmuxinatorClientWithRunner(NewClient(), r)
+
+	if err := client.StopProject("api"); err != nil {
+		t.Fatalf("StopProject() unexpected error: %v", err)
+	}
+	if len(r.calls) != 1 || strings.Join(r.calls[0], " ") != "tmuxinator stop api" {
+		t.Errorf("ran %v, want tmuxinator stop api", r.calls)
+	}
+}
+
+// TestProjectsOnOtherServer checks that projects aren't started or stopped
+// while sess is pointed at another tmux server
+func TestProjectsOnOtherServer(t *testing.T) {
+	for name, tmuxClient := range map[string]*Client{
+		"socket":      NewClient().WithSocket("work"),
+		"socket path": NewClient().WithSocketPath("/tmp/tmux-1000/work"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			r := &fakeRunner{}
+			client := NewTmuxinatorClientWithRunner(tmuxClient, r)
+
+			if _, err := client.StartProject("api", false); err == nil {
+				t.Error("StartProject() expected error but got none")
+			}
+			if _, err := client.StartProjectDetached("api"); err == nil {
+				t.Error("StartProjectDetached() expected error but got none")
+			}
+			if err := client.StopProject("api"); err == nil {
+				t.Error("StopProject() expected error but got none")
+			}
+			if len(r.calls) != 0 {
+				t.Errorf("ran %v, want nothing", r.calls)
+			}
+		})
+	}
+}
+
+// TestStartProjectDetached checks that a detached start never attaches or switches
+func TestStartProjectDetached(t *testing.T) {
+	r := &fakeRunner{}
+	client := NewTmuxinatorClientWithRunner(NewClientWithRunner(r), r)
+	client.projectDirs = []string{projectDir(t, "api", "name: backend\n")}
+
+	started, err := client.StartProjectDetached("api")
+	if err != nil {
+		t.Fatalf("StartProjectDetached() unexpected error: %v", err)
+	}
+	if started != "backend" {
+		t.Errorf("StartProjectDetached() = %q, want the session the project file names", started)
+	}
+	if len(r.calls) != 1 || strings.Join(r.calls[0], " ") != "tmuxinator start api --no-attach" {
+		t.Errorf("ran %v, want tmuxinator start api --no-attach", r.calls)
+	}
+}
+
+// TestStartProjectFromTmux checks that starting a project inside tmux
+// switches to the session it created, even when it isn't named after the project
+func TestStartProjectFromTmux(t *testing.T) {
+	list := "tmux -u list-sessions -F " + listSessionsFormat
+	start := "tmuxinator start api --no-attach"
+
+	tests := []struct {
+		name        string
+		projectFile string
+		before      string
+		after       string
+		wantSwitch  string
+		wantStart   bool
+		wantErr     bool
+	}{
+		{name: "named after the project", before: "notes\t1\t0\t0\t\n", after: "notes\t1\t0\t0\t\napi\t2\t0\t0\t\n", wantSwitch: "api", wantStart: true},
+		{name: "renamed without a project file", before: "notes\t1\t0\t0\t\n", after: "notes\t1\t0\t0\t\nbackend\t2\t0\t0\t\n", wantSwitch: "backend", wantStart: true},
+		{name: "renamed in the project file", projectFile: "name: backend\n", after: "backend\t1\t0\t0\t\nworker\t1\t0\t0\t\n", wantSwitch: "backend", wantStart: true},
+		{name: "renamed and already running", projectFile: "name: backend\n", before: "backend\t2\t0\t0\t\n", after: "backend\t2\t0\t0\t\n", wantSwitch: "backend"},
+		{name: "already running", before: "api\t2\t0\t0\t\n", after: "api\t2\t0\t0\t\n", wantSwitch: "api"},
+		{name: "no new session", before: "notes\t1\t0\t0\t\n", after: "notes\t1\t0\t0\t\n", wantStart: true, wantErr: true},
+		{name: "several new sessions", after: "backend\t1\t0\t0\t\nworker\t1\t0\t0\t\n", wantStart: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRunner{
+				output: map[string]string{list: tt.before},
+				then:   map[string]map[string]string{start: {list: tt.after}},
+			}
+			client := NewTmuxinatorClientWithRunner(NewClientWithRunner(r), r)
+			client.projectDirs = []string{t.TempDir()}
+			if tt.projectFile != "" {
+				client.projectDirs = []string{projectDir(t, "api", tt.projectFile)}
+			}
+
+			got, err := client.StartProject("api", true)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("StartProject() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.wantSwitch {
+				t.Errorf("StartProject() = %q, want %q", got, tt.wantSwitch)
+			}
+			if ran := r.ran(start); ran != tt.wantStart {
+				t.Errorf("ran %s = %v, want %v", start, ran, tt.wantStart)
+			}
+
+			var switched []string
+			for _, call := range r.calls {
+				if len(call) > 1 && call[1] == "switch-client" {
+					switched = append(switched, call[len(call)-1])
+				}
+			}
+			if tt.wantErr {
+				if len(switched) != 0 {
+					t.Errorf("switched to %v, want nothing", switched)
+				}
+				return
+			}
+			if len(switched) != 1 || switched[0] != tt.wantSwitch {
+				t.Errorf("switched to %v, want %s", switched, tt.wantSwitch)
+			}
+		})
+	}
+}
+
+// TestStartProjectOutsideTmux checks that starting a project from outside
+// tmux hands the terminal to tmuxinator and reports the session the project
+// file names
+func TestStartProjectOutsideTmux(t *testing.T) {
+	r := &fakeRunner{}
+	client := NewTmuxinatorClientWithRunner(NewClientWithRunner(r), r)
+	client.projectDirs = []string{projectDir(t, "api", "name: backend\n")}
+
+	got, err := client.StartProject("api", false)
+	if err != nil {
+		t.Fatalf("StartProject() unexpected error: %v", err)
+	}
+	if got != "backend" {
+		t.Errorf("StartProject() = %q, want backend", got)
+	}
+	if len(r.calls) != 1 || strings.Join(r.calls[0], " ") != "tmuxinator start api" {
+		t.Errorf("ran %v, want tmuxinator start api", r.calls)
+	}
+}
+
+// TestProjectSessionName checks which session name is read from a project file
+func TestProjectSessionName(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want string
+	}{
+		{name: "name", file: "name: backend\nroot: ~/code/api\n", want: "backend"},
+		{name: "project_name wins", file: "name: backend\nproject_name: legacy\n", want: "legacy"},
+		{name: "no name", file: "root: ~/code/api\n", want: ""},
+		{name: "erb template", file: "name: <%= @args[0] %>\n", want: ""},
+		{name: "not yaml", file: "name: [unclosed\n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := projectSessionName([]byte(tt.file)); got != tt.want {
+				t.Errorf("projectSessionName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// projectDir writes a tmuxinator project file into a temporary directory
+// and returns the directory
+func projectDir(t *testing.T, project, contents string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, project+".yml"), []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// TestTmuxinatorBinary checks which command tmuxinator is run as, depending on what's on PATH
+func TestTmuxinatorBinary(t *testing.T) {
+	tests := []struct {
+		name          string
+		installed     map[string]bool
+		binaries      []string
+		wantBinary    string
+		wantInstalled bool
+		wantCommand   string
+	}{
+		{
+			name:          "tmuxinator only",
+			installed:     map[string]bool{"tmuxinator": true},
+			wantBinary:    "tmuxinator",
+			wantInstalled: true,
+			wantCommand:   "tmuxinator list",
+		},
+		{
+			name:          "mux only",
+			installed:     map[string]bool{"mux": true},
+			wantBinary:    "mux",
+			wantInstalled: true,
+			wantCommand:   "mux list",
+		},
+		{
+			name:          "both prefers tmuxinator",
+			installed:     map[string]bool{"tmuxinator": true, "mux": true},
+			wantBinary:    "tmuxinator",
+			wantInstalled: true,
+			wantCommand:   "tmuxinator list",
+		},
+		{
+			name:      "neither",
+			installed: map[string]bool{},
+		},
+		{
+			name:          "explicit binary",
+			installed:     map[string]bool{"tmuxinator": true, "my-tmuxinator": true},
+			binaries:      []string{"my-tmuxinator"},
+			wantBinary:    "my-tmuxinator",
+			wantInstalled: true,
+			wantCommand:   "my-tmuxinator list",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &fakeRunner{installed: tt.installed}
+			client := NewTmuxinatorClientWithRunner(NewClient(), r)
+			if tt.binaries != nil {
+				client = NewTmuxinatorClientWithBinary(NewClient(), r, tt.binaries...)
+			}
+
+			if got := client.Binary(); got != tt.wantBinary {
+				t.Errorf("Binary() = %q, want %q", got, tt.wantBinary)
+			}
+			if got := client.IsInstalled(); got != tt.wantInstalled {
+				t.Errorf("IsInstalled() = %v, want %v", got, tt.wantInstalled)
+			}
+
+			if _, err := client.ListProjects(); err != nil {
+				t.Fatalf("ListProjects() returned error: %v", err)
+			}
+			var commands []string
+			for _, call := range r.calls {
+				commands = append(commands, strings.Join(call, " "))
+			}
+			if got := strings.Join(commands, "; "); got != tt.wantCommand {
+				t.Errorf("ran %q, want %q", got, tt.wantCommand)
+			}
+		})
+	}
+}
+
+// TestParseProjects checks parsing tmuxinator list output in the layouts
+// different tmuxinator versions print
+func TestParseProjects(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   []string
+	}{
+		{
+			name:   "single line",
+			output: "tmuxinator projects:\napi dotfiles web\n",
+			want:   []string{"api", "dotfiles", "web"},
+		},
+		{
+			name:   "padded columns",
+			output: "tmuxinator projects:\napi             dotfiles        infra\nweb\n",
+			want:   []string{"api", "dotfiles", "infra", "web"},
+		},
+		{
+			name:   "one per line (--newline)",
+			output: "tmuxinator projects:\napi\ndotfiles\nweb\n",
+			want:   []string{"api", "dotfiles", "web"},
+		},
+		{
+			name:   "colored header",
+			output: "\x1b[1;32mtmuxinator projects:\x1b[0m\n\x1b[36mapi\x1b[0m  web\n",
+			want:   []string{"api", "web"},
+		},
+		{
+			name:   "other header",
+			output: "Projects:\n\napi web\n",
+			want:   []string{"api", "web"},
+		},
+		{
+			name:   "no projects",
+			output: "tmuxinator projects:\n",
+			want:   nil,
+		},
+		{
+			name:   "empty",
+			output: "",
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseProjects(tt.output)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("parseProjects() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
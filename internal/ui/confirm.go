@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// PromptConfirmer asks yes/no questions with a plain "[y/N]" prompt
+// A full TUI would be overkill for one keypress, and this works after the
+// picker has already exited
+type PromptConfirmer struct {
+	in  io.Reader
+	out io.Writer
+}
+
+// NewPromptConfirmer creates a confirmer that reads answers from in and writes prompts to out
+func NewPromptConfirmer(in io.Reader, out io.Writer) *PromptConfirmer {
+	return &PromptConfirmer{in: in, out: out}
+}
+
+// Confirm prints the question and reads one line
+// Only "y" or "yes" count as yes, so just pressing enter means no
+func (p *PromptConfirmer) Confirm(question string) (bool, error) {
+	fmt.Fprintf(p.out, "%s [y/N] ", question)
+
+	line, err := bufio.NewReader(p.in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes", nil
+}
+
+// IsTerminal reports whether f is an interactive terminal rather than a pipe or file
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Verify interface implementation at compile time
+var _ session.Confirmer = (*PromptConfirmer)(nil)
@@ -0,0 +1,38 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestPromptConfirmer tests which answers count as yes
+func TestPromptConfirmer(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{input: "y\n", want: true},
+		{input: "YES\n", want: true},
+		{input: "  y  \n", want: true},
+		{input: "n\n", want: false},
+		{input: "\n", want: false},
+		{input: "", want: false}, // EOF, e.g. ctrl-d
+		{input: "yep\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSpace(tt.input), func(t *testing.T) {
+			var out strings.Builder
+			got, err := NewPromptConfirmer(strings.NewReader(tt.input), &out).Confirm("Create it?")
+			if err != nil {
+				t.Fatalf("Confirm() returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Confirm() with %q = %v, want %v", tt.input, got, tt.want)
+			}
+			if out.String() != "Create it? [y/N] " {
+				t.Errorf("prompt = %q, want %q", out.String(), "Create it? [y/N] ")
+			}
+		})
+	}
+}
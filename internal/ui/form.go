@@ -0,0 +1,183 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// Styles for the config form
+var (
+	// labelStyle is for the field labels
+	labelStyle = lipgloss.NewStyle().Width(20)
+
+	// errorStyle is for validation errors
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+
+	// helpStyle is for the key hints at the bottom
+	helpStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("241"))
+)
+
+// Indexes of the form fields, in display order
+const (
+	fieldName = iota
+	fieldDirectory
+	fieldDescription
+	fieldTmuxinator
+	fieldCount
+)
+
+// fieldLabels are shown next to each input
+var fieldLabels = [fieldCount]string{
+	fieldName:        "Name",
+	fieldDirectory:   "Directory",
+	fieldDescription: "Description",
+	fieldTmuxinator:  "Tmuxinator project",
+}
+
+// FormModel is a form for creating a new default session config entry
+type FormModel struct {
+	inputs    [fieldCount]textinput.Model
+	focus     int                   // Index of the focused input
+	err       error                 // Validation error from the last submit
+	result    session.SessionConfig // The config built when the form is submitted
+	submitted bool                  // Whether the user submitted (vs canceled)
+}
+
+// NewFormModel creates an empty config form
+func NewFormModel() FormModel {
+	placeholders := [fieldCount]string{
+		fieldName:        "myproject",
+		fieldDirectory:   "~/code/myproject",
+		fieldDescription: "optional",
+		fieldTmuxinator:  "optional",
+	}
+
+	var m FormModel
+	for i := range m.inputs {
+		input := textinput.New()
+		input.Placeholder = placeholders[i]
+		input.Prompt = ""
+		m.inputs[i] = input
+	}
+	m.inputs[fieldName].Focus()
+
+	return m
+}
+
+// BuildSessionConfig turns raw form values into a validated SessionConfig
+// It's separate from the TUI so the rules can be tested directly
+func BuildSessionConfig(name, directory, description, tmuxinatorProject string) (session.SessionConfig, error) {
+	config := session.SessionConfig{
+		Name:              strings.TrimSpace(name),
+		Directory:         strings.TrimSpace(directory),
+		Description:       strings.TrimSpace(description),
+		TmuxinatorProject: strings.TrimSpace(tmuxinatorProject),
+	}
+
+	if config.Name == "" {
+		return config, fmt.Errorf("name is required")
+	}
+
+	// tmux doesn't allow these in session names
+	if strings.ContainsAny(config.Name, ".:") {
+		return config, fmt.Errorf("name can't contain '.' or ':'")
+	}
+
+	if config.Directory == "" && config.TmuxinatorProject == "" {
+		return config, fmt.Errorf("directory or tmuxinator project is required")
+	}
+
+	return config, nil
+}
+
+// Init is called when the program starts
+func (m FormModel) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+// Update handles key presses: navigation between fields, submit, and cancel
+func (m FormModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyMsg); ok {
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			return m, tea.Quit
+
+		case "tab", "down":
+			return m, m.setFocus(m.focus + 1)
+
+		case "shift+tab", "up":
+			return m, m.setFocus(m.focus - 1)
+
+		case "enter":
+			// Enter moves to the next field, and submits from the last one
+			if m.focus < fieldCount-1 {
+				return m, m.setFocus(m.focus + 1)
+			}
+
+			config, err := BuildSessionConfig(
+				m.inputs[fieldName].Value(),
+				m.inputs[fieldDirectory].Value(),
+				m.inputs[fieldDescription].Value(),
+				m.inputs[fieldTmuxinator].Value(),
+			)
+			if err != nil {
+				m.err = err
+				return m, nil
+			}
+
+			m.result = config
+			m.submitted = true
+			return m, tea.Quit
+		}
+	}
+
+	// Everything else goes to the focused input
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return m, cmd
+}
+
+// setFocus moves focus to the given field, wrapping around at either end
+func (m *FormModel) setFocus(index int) tea.Cmd {
+	m.inputs[m.focus].Blur()
+	m.focus = (index + fieldCount) % fieldCount
+	return m.inputs[m.focus].Focus()
+}
+
+// View renders the form
+func (m FormModel) View() string {
+	if m.submitted {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("New Default Session"))
+	b.WriteString("\n\n")
+
+	for i, input := range m.inputs {
+		b.WriteString(labelStyle.Render(fieldLabels[i]))
+		b.WriteString(input.View())
+		b.WriteString("\n")
+	}
+
+	if m.err != nil {
+		b.WriteString("\n")
+		b.WriteString(errorStyle.Render("✗ " + m.err.Error()))
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render("tab/↓ next • shift+tab/↑ previous • enter on last field to save • esc cancel"))
+
+	return docStyle.Render(b.String())
+}
+
+// Result returns the built config and whether the user submitted the form
+func (m FormModel) Result() (session.SessionConfig, bool) {
+	return m.result, m.submitted
+}
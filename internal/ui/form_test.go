@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// TestBuildSessionConfig tests turning form values into a SessionConfig
+func TestBuildSessionConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		values    [4]string // name, directory, description, tmuxinator project
+		want      session.SessionConfig
+		wantError bool
+	}{
+		{
+			name:   "all fields",
+			values: [4]string{"api", "~/code/api", "API server", "api-dev"},
+			want: session.SessionConfig{
+				Name:              "api",
+				Directory:         "~/code/api",
+				Description:       "API server",
+				TmuxinatorProject: "api-dev",
+			},
+		},
+		{
+			name:   "whitespace is trimmed",
+			values: [4]string{"  api ", " ~/code/api\t", "", ""},
+			want:   session.SessionConfig{Name: "api", Directory: "~/code/api"},
+		},
+		{
+			name:   "tmuxinator project without directory",
+			values: [4]string{"infra", "", "", "infra"},
+			want:   session.SessionConfig{Name: "infra", TmuxinatorProject: "infra"},
+		},
+		{
+			name:      "missing name",
+			values:    [4]string{"", "~/code", "", ""},
+			wantError: true,
+		},
+		{
+			name:      "name with a dot",
+			values:    [4]string{"my.project", "~/code", "", ""},
+			wantError: true,
+		},
+		{
+			name:      "nothing to start",
+			values:    [4]string{"api", "", "just a description", ""},
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildSessionConfig(tt.values[0], tt.values[1], tt.values[2], tt.values[3])
+			if tt.wantError {
+				if err == nil {
+					t.Error("BuildSessionConfig() expected error but got none")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("BuildSessionConfig() unexpected error: %v", err)
+			}
+
+			if got.Name != tt.want.Name || got.Directory != tt.want.Directory ||
+				got.Description != tt.want.Description || got.TmuxinatorProject != tt.want.TmuxinatorProject {
+				t.Errorf("BuildSessionConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,10 +3,18 @@ package ui
 import (
 	"fmt"
 	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/datapointchris/sess/internal/output"
 	"github.com/datapointchris/sess/internal/session"
 )
 
@@ -30,14 +38,8 @@ var (
 				Foreground(lipgloss.Color("170")).
 				Bold(true)
 
-	// activeStyle is for active sessions (green circle)
-	activeStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
-
-	// tmuxinatorStyle is for tmuxinator projects (yellow gear)
-	tmuxinatorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
-
-	// defaultStyle is for default sessions (blue circle)
-	defaultStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("12"))
+	// markStyle is for the check on sessions marked with space
+	markStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("170"))
 )
 
 // sessionItem implements list.Item interface for our sessions
@@ -52,9 +54,29 @@ func (i sessionItem) FilterValue() string {
 	return i.Name
 }
 
+// CreateLabel is the list entry for starting a session that isn't listed
+const CreateLabel = "+ Create New Session"
+
+// createItem is the CreateLabel entry, always last in the list
+// Choosing it asks for a name instead of quitting
+type createItem struct{}
+
+// FilterValue is required by list.Item
+func (createItem) FilterValue() string {
+	return CreateLabel
+}
+
 // sessionItemDelegate defines how to render list items
 // This implements list.ItemDelegate interface
-type sessionItemDelegate struct{}
+type sessionItemDelegate struct {
+	// marked are the sessions marked with space, by name
+	// The model replaces the delegate (and the map) on every change rather
+	// than changing the map, so copies of the model don't share marks
+	marked map[string]bool
+
+	// icons give each session type its icon and color
+	icons output.Icons
+}
 
 // Height returns how many terminal rows this item takes up
 func (d sessionItemDelegate) Height() int { return 1 }
@@ -74,29 +96,39 @@ func (d sessionItemDelegate) Render(w io.Writer, m list.Model, index int, item l
 	// Type assert the item back to sessionItem
 	// The .(sessionItem) is called a "type assertion"
 	// The ok variable tells us if the assertion succeeded
+	if _, ok := item.(createItem); ok {
+		if index == m.Index() {
+			fmt.Fprint(w, selectedItemStyle.Render("> "+CreateLabel))
+		} else {
+			fmt.Fprint(w, itemStyle.Render("  "+CreateLabel))
+		}
+		return
+	}
+
 	sess, ok := item.(sessionItem)
 	if !ok {
 		return
 	}
 
-	// Build the display string with icon
-	icon := sess.Icon()
+	// Build the display string with icon, padded so the names line up
+	icon := d.icons.Pad(sess.Type)
 	display := sess.DisplayInfo()
 
 	// Apply color based on session type
-	var styledIcon string
-	switch sess.Type {
-	case session.SessionTypeTmux:
-		styledIcon = activeStyle.Render(icon)
-	case session.SessionTypeTmuxinator:
-		styledIcon = tmuxinatorStyle.Render(icon)
-	case session.SessionTypeDefault:
-		styledIcon = defaultStyle.Render(icon)
+	styledIcon := icon
+	if color := d.icons.Theme.Color(sess.Type); color != "" && !d.icons.ASCII {
+		styledIcon = lipgloss.NewStyle().Foreground(lipgloss.Color(color)).Render(icon)
+	}
+
+	// Marked sessions get a check in front, the rest a space to keep the names aligned
+	mark := " "
+	if d.marked[sess.Name] {
+		mark = markStyle.Render("✓")
 	}
 
 	// Determine if this item is selected
 	// m.Index() returns the currently selected index
-	str := fmt.Sprintf("%s %s", styledIcon, display)
+	str := fmt.Sprintf("%s%s %s", mark, styledIcon, display)
 	if index == m.Index() {
 		// This is the selected item, use selected style
 		str = selectedItemStyle.Render("> " + str)
@@ -110,42 +142,292 @@ func (d sessionItemDelegate) Render(w io.Writer, m list.Model, index int, item l
 	fmt.Fprint(w, str)
 }
 
+// Key bindings for moving the selected session, shown in the list's help
+var (
+	moveUpKey    = key.NewBinding(key.WithKeys("K"), key.WithHelp("K", "move up"))
+	moveDownKey  = key.NewBinding(key.WithKeys("J"), key.WithHelp("J", "move down"))
+	deleteKey    = key.NewBinding(key.WithKeys("d"), key.WithHelp("d", "delete"))
+	tagKey       = key.NewBinding(key.WithKeys("t"), key.WithHelp("t", "next tag"))
+	markKey      = key.NewBinding(key.WithKeys(" "), key.WithHelp("space", "mark"))
+	deleteAllKey = key.NewBinding(key.WithKeys("D"), key.WithHelp("D", "delete marked"))
+)
+
+// listTitle is the list's title when it isn't asking a question
+const listTitle = "Tmux Sessions"
+
+// Deleter deletes active sessions from the list (the session manager in practice)
+// It's an interface so tests can check deletes without tmux
+type Deleter interface {
+	DeleteSession(name string) error
+}
+
 // Model holds the state of our UI
 // This is the "M" in the Elm Architecture (Model-Update-View)
 type Model struct {
-	list     list.Model        // The list component from bubbles
-	sessions []session.Session // All available sessions
-	choice   string            // The selected session name (when user presses Enter)
+	list      list.Model        // The list component from bubbles
+	sessions  []session.Session // All available sessions
+	choice    string            // The selected session name (when user presses Enter)
+	reordered bool              // Whether the user moved any session
+
+	// deleter deletes sessions with d (or the marked ones with D), once
+	// SetDeleter is called
+	// confirmDelete are the sessions waiting for a y/n answer (nil when none are)
+	deleter       Deleter
+	confirmDelete []string
+
+	// marked are the sessions marked with space, by name rather than by
+	// position so they stay marked while the list is filtered or reordered
+	marked map[string]bool
+
+	// icons give each session type its icon and color (the defaults when unset)
+	icons output.Icons
+
+	// tags are the tags t cycles through (nil when no session has any), and
+	// tag is the one the list is showing ("" for every session)
+	tags []string
+	tag  string
+
+	// naming is set after choosing CreateLabel, while the new name is typed
+	naming    bool
+	nameInput textinput.Model
+
+	// The preview pane, shown once SetPreview is called
+	preview     viewport.Model
+	windows     WindowLister
+	windowCache map[string]windowFetch // Windows already fetched, by session name
+	previewed   string                 // The session the preview currently shows
+
+	// refresh lists the sessions again every interval, once SetWatch is called
+	refresh  Refresher
+	interval time.Duration
+}
+
+// Refresher lists the sessions for the picker again (ListFiltered in practice)
+type Refresher func() ([]session.Session, error)
+
+// watchTickMsg says it's time to list the sessions again
+type watchTickMsg struct{}
+
+// refreshMsg carries a fresh list of sessions, or why it couldn't be had
+type refreshMsg struct {
+	sessions []session.Session
+	err      error
 }
 
 // NewModel creates a new UI model
 func NewModel(sessions []session.Session) Model {
-	// Convert sessions to list items
-	items := make([]list.Item, len(sessions))
-	for i, sess := range sessions {
-		items[i] = sessionItem{sess}
+	tags := listTags(sessions)
+	helpKeys := []key.Binding{moveUpKey, moveDownKey}
+	if tags != nil {
+		helpKeys = append(helpKeys, tagKey)
 	}
 
 	// Create the list with custom delegate
 	delegate := sessionItemDelegate{}
-	listModel := list.New(items, delegate, 0, 0)
-	listModel.Title = "Tmux Sessions"
+	listModel := list.New(listItems(sessions), delegate, 0, 0)
+	listModel.Title = listTitle
 	listModel.Styles.Title = titleStyle
 
 	// Additional list settings
 	listModel.SetShowStatusBar(false)   // We don't need the status bar
 	listModel.SetFilteringEnabled(true) // Enable fuzzy search with /
+	listModel.AdditionalShortHelpKeys = func() []key.Binding {
+		return helpKeys
+	}
+
+	nameInput := textinput.New()
+	nameInput.Placeholder = "Session name"
+	nameInput.Prompt = "> "
 
 	return Model{
-		list:     listModel,
-		sessions: sessions,
+		list:      listModel,
+		sessions:  sessions,
+		tags:      tags,
+		nameInput: nameInput,
+	}
+}
+
+// listTags returns the tags t cycles through, nil when they wouldn't tell
+// any sessions apart
+func listTags(sessions []session.Session) []string {
+	tags := session.Tags(sessions)
+	if len(tags) == 1 && tags[0] == session.UntaggedTag {
+		return nil
+	}
+	return tags
+}
+
+// listItems converts sessions to list items, with the create entry last
+func listItems(sessions []session.Session) []list.Item {
+	items := make([]list.Item, len(sessions), len(sessions)+1)
+	for i, sess := range sessions {
+		items[i] = sessionItem{sess}
+	}
+	return append(items, createItem{})
+}
+
+// SetDeleter turns on deleting active sessions with d, and the ones marked
+// with space all at once with D
+// Each delete is confirmed with y first, and the sessions leave the list
+// without closing the picker
+func (m *Model) SetDeleter(deleter Deleter) {
+	m.deleter = deleter
+	helpKeys := append(m.list.AdditionalShortHelpKeys(), deleteKey, markKey, deleteAllKey)
+	m.list.AdditionalShortHelpKeys = func() []key.Binding {
+		return helpKeys
+	}
+}
+
+// SetPreview turns on the preview pane, which shows the highlighted
+// session's details and windows; windows is called the first time each
+// active session is highlighted
+func (m *Model) SetPreview(windows WindowLister) {
+	m.windows = windows
+	m.windowCache = make(map[string]windowFetch)
+	m.preview = viewport.New(0, 0)
+	m.refreshPreview()
+}
+
+// refreshPreview renders the highlighted session into the preview, if it changed
+func (m *Model) refreshPreview() {
+	if m.windows == nil {
+		return
+	}
+
+	selected, ok := m.list.SelectedItem().(sessionItem)
+	if !ok {
+		m.previewed = ""
+		m.preview.SetContent("")
+		return
+	}
+	if selected.Name == m.previewed {
+		return
+	}
+
+	// Only active sessions have windows, and each is only fetched once
+	// A failed fetch isn't retried either, the preview just says so
+	var fetch windowFetch
+	if selected.Type == session.SessionTypeTmux {
+		cached, seen := m.windowCache[selected.Name]
+		if !seen {
+			cached.windows, cached.err = m.windows(selected.Name)
+			m.windowCache[selected.Name] = cached
+		}
+		fetch = cached
 	}
+
+	m.previewed = selected.Name
+	m.preview.SetContent(PreviewContent(selected.Session, fetch.windows, fetch.err))
+	m.preview.GotoTop()
+}
+
+// SetWatch keeps the list current: every interval, refresh lists the sessions
+// again and the list is updated in place, keeping the highlighted session
+func (m *Model) SetWatch(refresh Refresher, interval time.Duration) {
+	m.refresh = refresh
+	m.interval = interval
+}
+
+// watchTick waits out the interval before the next refresh
+func (m Model) watchTick() tea.Cmd {
+	return tea.Tick(m.interval, func(time.Time) tea.Msg { return watchTickMsg{} })
+}
+
+// applyRefresh replaces the listed sessions with fresh ones
+// The highlighted session stays highlighted wherever it ends up, and an
+// order the user set with K/J is kept, with new sessions after it
+func (m *Model) applyRefresh(sessions []session.Session) {
+	if m.reordered {
+		sessions = keepOrder(m.sessions, sessions)
+	}
+	m.sessions = sessions
+
+	m.tags = listTags(sessions)
+	if m.tag != "" && !slices.Contains(m.tags, m.tag) {
+		m.tag = ""
+		m.list.Title = m.title()
+	}
+	shown := sessions
+	if m.tag != "" {
+		shown = session.FilterByTag(sessions, m.tag)
+	}
+
+	selected := m.list.SelectedItem()
+	index := m.list.Index()
+
+	// Filter right away rather than in a command, so the selection below
+	// is found among the items actually shown
+	if filter := m.list.SetItems(listItems(shown)); filter != nil {
+		m.list, _ = m.list.Update(filter())
+	}
+
+	visible := m.list.VisibleItems()
+	target := max(min(index, len(visible)-1), 0)
+	for i, item := range visible {
+		if sameItem(item, selected) {
+			target = i
+			break
+		}
+	}
+	m.list.Select(target)
+
+	// The windows may have changed too
+	if m.windows != nil {
+		m.windowCache = make(map[string]windowFetch)
+		m.previewed = ""
+		m.refreshPreview()
+	}
+}
+
+// sameItem reports whether two list items are the same session (or both the create entry)
+func sameItem(a, b list.Item) bool {
+	switch a := a.(type) {
+	case sessionItem:
+		b, ok := b.(sessionItem)
+		return ok && a.Name == b.Name
+	case createItem:
+		_, ok := b.(createItem)
+		return ok
+	}
+	return false
+}
+
+// keepOrder returns fresh in the order of the names in old, followed by
+// the sessions old didn't have
+func keepOrder(old, fresh []session.Session) []session.Session {
+	byName := make(map[string]session.Session, len(fresh))
+	for _, sess := range fresh {
+		byName[sess.Name] = sess
+	}
+
+	ordered := make([]session.Session, 0, len(fresh))
+	for _, sess := range old {
+		if current, ok := byName[sess.Name]; ok {
+			ordered = append(ordered, current)
+			delete(byName, sess.Name)
+		}
+	}
+	for _, sess := range fresh {
+		if _, ok := byName[sess.Name]; ok {
+			ordered = append(ordered, sess)
+		}
+	}
+	return ordered
+}
+
+// windowFetch is the result of fetching a session's windows for the preview
+type windowFetch struct {
+	windows []session.Window
+	err     error
 }
 
 // Init is called when the program starts
 // It can return a command to run (or nil)
 // This is part of the Elm Architecture
 func (m Model) Init() tea.Cmd {
+	if m.refresh != nil {
+		return m.watchTick()
+	}
 	return nil
 }
 
@@ -155,28 +437,159 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	// msg is a type assertion - we're checking what type of message this is
 
+	case watchTickMsg:
+		// Listing runs tmux, so it happens in a command rather than here
+		refresh := m.refresh
+		return m, func() tea.Msg {
+			sessions, err := refresh()
+			return refreshMsg{sessions: sessions, err: err}
+		}
+
+	case refreshMsg:
+		next := m.watchTick()
+		if msg.err != nil {
+			// tmux may just be restarting, so keep the old list and try again
+			return m, tea.Batch(next, m.list.NewStatusMessage(errorStyle.Render("✗ "+msg.err.Error())))
+		}
+		// Don't pull the list out from under a question being answered
+		if m.naming || m.confirmDelete != nil {
+			return m, next
+		}
+		m.applyRefresh(msg.sessions)
+		return m, next
+
 	case tea.WindowSizeMsg:
 		// Window was resized, update list dimensions
 		h, v := docStyle.GetFrameSize()
-		m.list.SetSize(msg.Width-h, msg.Height-v)
+		width, height := msg.Width-h, msg.Height-v
+
+		if m.windows == nil {
+			m.list.SetSize(width, height)
+			return m, nil
+		}
+
+		// Split the width between the list and the preview beside it
+		listWidth := width / 2
+		ph, pv := previewStyle.GetFrameSize()
+		m.list.SetSize(listWidth, height)
+		m.preview.Width = max(width-listWidth-ph, 0)
+		m.preview.Height = max(height-pv, 0)
 		return m, nil
 
 	case tea.KeyMsg:
+		if m.naming {
+			return m.updateName(msg)
+		}
+		if m.confirmDelete != nil {
+			return m.answerDelete(msg)
+		}
+
+		// While a filter is being typed, keys are part of it
+		if m.list.FilterState() == list.Filtering && msg.String() != "ctrl+c" {
+			break
+		}
+
 		// A key was pressed
 		switch msg.String() {
 		case "ctrl+c", "q":
 			// Quit the program
 			return m, tea.Quit
 
+		case "K", "J":
+			// Moving only makes sense in the full list - in a filtered one,
+			// "up one" could be anywhere in the real order
+			if m.list.FilterState() != list.Unfiltered || m.tag != "" {
+				break
+			}
+
+			from := m.list.Index()
+			to := from - 1
+			if msg.String() == "J" {
+				to = from + 1
+			}
+			// The create entry stays at the end
+			items := m.list.Items()
+			if to < 0 || to >= len(items) || !isSession(items[from]) || !isSession(items[to]) {
+				return m, nil
+			}
+
+			cmd := m.list.SetItems(moveItem(m.list.Items(), from, to))
+			m.list.Select(to)
+			m.reordered = true
+
+			// Keep the new order when t narrows the list and widens it again
+			m.sessions = make([]session.Session, 0, len(m.sessions))
+			for _, item := range m.list.Items() {
+				if sess, ok := item.(sessionItem); ok {
+					m.sessions = append(m.sessions, sess.Session)
+				}
+			}
+			return m, cmd
+
+		case "t":
+			if m.tags == nil {
+				break
+			}
+			return m.nextTag()
+
+		case "d":
+			if m.deleter == nil {
+				break
+			}
+
+			selected, ok := m.list.SelectedItem().(sessionItem)
+			if !ok || selected.Type != session.SessionTypeTmux {
+				// Only running sessions can be killed; the others aren't
+				// anything yet, and deleting their config is a job for an editor
+				return m, m.list.NewStatusMessage(helpStyle.Render("only active sessions can be deleted"))
+			}
+
+			// Ask in the title, where the answer is expected next
+			m.confirmDelete = []string{selected.Name}
+			m.list.Title = fmt.Sprintf("Delete %s? (y/n)", selected.Name)
+			return m, nil
+
+		case " ":
+			selected, ok := m.list.SelectedItem().(sessionItem)
+			if !ok {
+				break
+			}
+			m.toggleMark(selected.Name)
+			return m, nil
+
+		case "D":
+			if m.deleter == nil {
+				break
+			}
+
+			// Only running sessions can be killed, so the others stay marked
+			var names []string
+			for _, sess := range m.sessions {
+				if m.marked[sess.Name] && sess.Type == session.SessionTypeTmux {
+					names = append(names, sess.Name)
+				}
+			}
+			if len(names) == 0 {
+				return m, m.list.NewStatusMessage(helpStyle.Render("mark active sessions with space first"))
+			}
+
+			m.confirmDelete = names
+			m.list.Title = fmt.Sprintf("Delete %d sessions (%s)? (y/n)", len(names), strings.Join(names, ", "))
+			return m, nil
+
 		case "enter":
 			// User selected a session
 			// Get the selected item
-			selected := m.list.SelectedItem()
-			if selected != nil {
-				sess := selected.(sessionItem)
-				m.choice = sess.Name
+			switch selected := m.list.SelectedItem().(type) {
+			case sessionItem:
+				m.choice = selected.Name
 				// Quit and let main.go handle the session switch
 				return m, tea.Quit
+
+			case createItem:
+				// Ask for the new session's name
+				m.naming = true
+				return m, m.nameInput.Focus()
 			}
 		}
 	}
@@ -185,9 +598,151 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// This includes arrow keys, filtering, etc.
 	var cmd tea.Cmd
 	m.list, cmd = m.list.Update(msg)
+
+	// The cursor may have moved to another session
+	m.refreshPreview()
 	return m, cmd
 }
 
+// updateName handles keys while the new session's name is typed
+func (m Model) updateName(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "ctrl+c":
+		return m, tea.Quit
+
+	case "esc":
+		// Back to the list
+		m.naming = false
+		m.nameInput.Blur()
+		m.nameInput.SetValue("")
+		return m, nil
+
+	case "enter":
+		name := strings.TrimSpace(m.nameInput.Value())
+		if name == "" {
+			return m, nil
+		}
+		m.choice = name
+		return m, tea.Quit
+	}
+
+	var cmd tea.Cmd
+	m.nameInput, cmd = m.nameInput.Update(msg)
+	return m, cmd
+}
+
+// answerDelete handles the key pressed after d or D: y deletes, anything else cancels
+// A session that fails to delete stays in the list, and the rest go ahead
+func (m Model) answerDelete(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	names := m.confirmDelete
+	m.confirmDelete = nil
+	m.list.Title = m.title()
+
+	if msg.String() == "ctrl+c" {
+		return m, tea.Quit
+	}
+	if msg.String() != "y" {
+		return m, nil
+	}
+
+	deleted := make(map[string]bool, len(names))
+	var failed []string
+	for _, name := range names {
+		if err := m.deleter.DeleteSession(name); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+		deleted[name] = true
+	}
+
+	// The sessions are gone, so drop them from the list, the marks, and the preview
+	// SetItems rather than RemoveItem, which gets the index wrong while filtered
+	remaining := make([]list.Item, 0, len(m.list.Items()))
+	for _, item := range m.list.Items() {
+		if sess, ok := item.(sessionItem); !ok || !deleted[sess.Name] {
+			remaining = append(remaining, item)
+		}
+	}
+	m.sessions = slices.DeleteFunc(slices.Clone(m.sessions), func(sess session.Session) bool {
+		return deleted[sess.Name]
+	})
+	index := m.list.Index()
+	filterCmd := m.list.SetItems(remaining)
+	m.list.Select(max(min(index, len(m.list.VisibleItems())-1), 0))
+
+	for name := range deleted {
+		delete(m.windowCache, name)
+		if m.marked[name] {
+			m.toggleMark(name)
+		}
+	}
+	m.previewed = ""
+	m.refreshPreview()
+
+	if len(failed) > 0 {
+		return m, tea.Batch(filterCmd, m.list.NewStatusMessage(errorStyle.Render("✗ "+strings.Join(failed, "; "))))
+	}
+	return m, tea.Batch(filterCmd, m.list.NewStatusMessage(helpStyle.Render("deleted "+strings.Join(names, ", "))))
+}
+
+// toggleMark marks the named session, or unmarks it if it's marked
+func (m *Model) toggleMark(name string) {
+	marked := maps.Clone(m.marked)
+	if marked == nil {
+		marked = make(map[string]bool)
+	}
+	if marked[name] {
+		delete(marked, name)
+	} else {
+		marked[name] = true
+	}
+	m.marked = marked
+	m.list.SetDelegate(sessionItemDelegate{marked: marked, icons: m.icons})
+}
+
+// SetIcons changes the icons and colors sessions are drawn with
+// ASCII icons are drawn without colors
+func (m *Model) SetIcons(icons output.Icons) {
+	m.icons = icons
+	m.list.SetDelegate(sessionItemDelegate{marked: m.marked, icons: icons})
+}
+
+// nextTag narrows the list to the next tag in turn, going back to every
+// session after the last one
+func (m Model) nextTag() (tea.Model, tea.Cmd) {
+	next := 0
+	if m.tag != "" {
+		next = slices.Index(m.tags, m.tag) + 1
+	}
+
+	m.tag = ""
+	sessions := m.sessions
+	if next < len(m.tags) {
+		m.tag = m.tags[next]
+		sessions = session.FilterByTag(m.sessions, m.tag)
+	}
+
+	cmd := m.list.SetItems(listItems(sessions))
+	m.list.Select(0)
+	m.list.Title = m.title()
+	m.refreshPreview()
+	return m, cmd
+}
+
+// title is the list's title, naming the tag it's narrowed to
+func (m Model) title() string {
+	if m.tag == "" {
+		return listTitle
+	}
+	return fmt.Sprintf("%s [%s]", listTitle, m.tag)
+}
+
+// isSession reports whether a list item is a session (not the create entry)
+func isSession(item list.Item) bool {
+	_, ok := item.(sessionItem)
+	return ok
+}
+
 // View renders the current state of the model
 // This returns a string that will be drawn to the terminal
 func (m Model) View() string {
@@ -196,11 +751,76 @@ func (m Model) View() string {
 		return ""
 	}
 
+	if m.naming {
+		return docStyle.Render(titleStyle.Render("New Session") + "\n\n" +
+			m.nameInput.View() + "\n\n" +
+			helpStyle.Render("enter create • esc back • ctrl+c cancel"))
+	}
+
 	// Render the list with document style
-	return docStyle.Render(m.list.View())
+	if m.windows == nil {
+		return docStyle.Render(m.list.View())
+	}
+
+	// The preview sits to the right of the list
+	return docStyle.Render(lipgloss.JoinHorizontal(lipgloss.Top,
+		m.list.View(),
+		previewStyle.Render(m.preview.View()),
+	))
+}
+
+// Reordered reports whether the user moved any session with K or J
+func (m Model) Reordered() bool {
+	return m.reordered
+}
+
+// Order returns saved, the custom order, rearranged to match the picker
+// After a reorder, this is what gets saved as the custom order
+// It covers every session, even while t narrows the list to a tag, and
+// sessions the picker didn't show (hidden ones, say) keep their places
+func (m Model) Order(saved []string) []string {
+	names := make([]string, len(m.sessions))
+	for i, sess := range m.sessions {
+		names[i] = sess.Name
+	}
+	return mergeOrder(saved, names)
+}
+
+// mergeOrder puts shown into the places its names had in saved, in the
+// order they're shown, leaving the rest of saved where it was
+// Shown names saved didn't have go on the end
+func mergeOrder(saved, shown []string) []string {
+	isShown := make(map[string]bool, len(shown))
+	for _, name := range shown {
+		isShown[name] = true
+	}
+
+	merged := make([]string, 0, len(saved)+len(shown))
+	next := 0
+	for _, name := range saved {
+		if !isShown[name] {
+			merged = append(merged, name)
+			continue
+		}
+		merged = append(merged, shown[next])
+		next++
+	}
+	return append(merged, shown[next:]...)
+}
+
+// moveItem returns a copy of items with the item at from moved to index to
+func moveItem(items []list.Item, from, to int) []list.Item {
+	moved := make([]list.Item, 0, len(items))
+	moved = append(moved, items[:from]...)
+	moved = append(moved, items[from+1:]...)
+
+	// Insert at to in the list without the moved item
+	moved = append(moved[:to], append([]list.Item{items[from]}, moved[to:]...)...)
+	return moved
 }
 
-// GetChoice returns the user's selection
+// GetChoice returns the user's selection: the chosen session, or the name
+// typed for a new one ("" if the user quit)
 // This is called after the program exits
 func (m Model) GetChoice() string {
 	return m.choice
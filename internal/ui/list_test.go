@@ -0,0 +1,523 @@
+package ui
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/datapointchris/sess/internal/output"
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// testSessions returns sessions named after names, in that order
+func testSessions(names ...string) []session.Session {
+	sessions := make([]session.Session, len(names))
+	for i, name := range names {
+		sessions[i] = session.Session{Name: name, Type: session.SessionTypeTmux}
+	}
+	return sessions
+}
+
+// press sends a key to the model and returns the updated model
+func press(m Model, key string) Model {
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+	return updated.(Model)
+}
+
+// TestReorder tests moving the selected session with K and J
+func TestReorder(t *testing.T) {
+	tests := []struct {
+		name          string
+		keys          []string
+		want          string
+		wantReordered bool
+	}{
+		{name: "move down", keys: []string{"J"}, want: "b,a,c", wantReordered: true},
+		{name: "move to the bottom", keys: []string{"J", "J"}, want: "b,c,a", wantReordered: true},
+		{name: "move down and back up", keys: []string{"J", "K"}, want: "a,b,c", wantReordered: true},
+		{name: "can't move past the top", keys: []string{"K"}, want: "a,b,c"},
+		{name: "select then move", keys: []string{"j", "j", "K"}, want: "a,c,b", wantReordered: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(testSessions("a", "b", "c"))
+			for _, key := range tt.keys {
+				m = press(m, key)
+			}
+
+			if got := strings.Join(m.Order(nil), ","); got != tt.want {
+				t.Errorf("Order() = %s, want %s", got, tt.want)
+			}
+			if m.Reordered() != tt.wantReordered {
+				t.Errorf("Reordered() = %v, want %v", m.Reordered(), tt.wantReordered)
+			}
+		})
+	}
+}
+
+// TestOrderKeepsUnshownSessions tests that sessions the picker didn't show
+// keep their places in the saved order
+func TestOrderKeepsUnshownSessions(t *testing.T) {
+	tests := []struct {
+		name  string
+		saved []string
+		shown []string
+		want  string
+	}{
+		{"nothing saved", nil, []string{"b", "a"}, "b,a"},
+		{"hidden sessions stay put", []string{"a", "hidden", "b", "c"}, []string{"c", "a", "b"}, "c,hidden,a,b"},
+		{"new sessions go last", []string{"a", "b"}, []string{"new", "b", "a"}, "new,b,a"},
+		{"saved sessions that are gone stay", []string{"gone", "a", "b"}, []string{"b", "a"}, "gone,b,a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(testSessions(tt.shown...))
+			if got := strings.Join(m.Order(tt.saved), ","); got != tt.want {
+				t.Errorf("Order(%v) = %s, want %s", tt.saved, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestReorderKeepsSelection tests that the moved session stays selected
+func TestReorderKeepsSelection(t *testing.T) {
+	m := NewModel(testSessions("a", "b", "c"))
+	m = press(m, "J")
+	m = press(m, "J")
+
+	updated, _ := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := updated.(Model).GetChoice(); got != "a" {
+		t.Errorf("GetChoice() = %q, want a", got)
+	}
+}
+
+// pressKey sends a special key (enter, esc, ...) to the model
+func pressKey(m Model, key tea.KeyType) Model {
+	updated, _ := m.Update(tea.KeyMsg{Type: key})
+	return updated.(Model)
+}
+
+// TestCreateNewSession tests choosing the create entry and typing a name
+func TestCreateNewSession(t *testing.T) {
+	m := NewModel(testSessions("a", "b"))
+
+	// The create entry comes after the sessions and isn't part of the order
+	m = press(m, "j")
+	m = press(m, "j")
+	if got := strings.Join(m.Order(nil), ","); got != "a,b" {
+		t.Errorf("Order() = %s, want a,b", got)
+	}
+
+	m = pressKey(m, tea.KeyEnter)
+	if m.GetChoice() != "" {
+		t.Fatalf("GetChoice() = %q after choosing the create entry, want a name prompt", m.GetChoice())
+	}
+
+	// An empty name doesn't create anything
+	m = pressKey(m, tea.KeyEnter)
+	if m.GetChoice() != "" {
+		t.Errorf("GetChoice() = %q for an empty name, want \"\"", m.GetChoice())
+	}
+
+	m = press(m, "api")
+	m = pressKey(m, tea.KeyEnter)
+	if got := m.GetChoice(); got != "api" {
+		t.Errorf("GetChoice() = %q, want api", got)
+	}
+}
+
+// TestCreateNewSessionBack tests that esc returns from the name prompt to the list
+func TestCreateNewSessionBack(t *testing.T) {
+	m := NewModel(testSessions("a"))
+	m = press(m, "j")
+	m = pressKey(m, tea.KeyEnter)
+	m = press(m, "x")
+	m = pressKey(m, tea.KeyEsc)
+
+	// Back in the list, enter picks the create entry again with an empty name
+	m = pressKey(m, tea.KeyEnter)
+	m = pressKey(m, tea.KeyEnter)
+	if m.GetChoice() != "" {
+		t.Errorf("GetChoice() = %q, want the typed name cleared", m.GetChoice())
+	}
+}
+
+// TestReorderSkipsCreateEntry tests that sessions can't be moved past the create entry
+func TestReorderSkipsCreateEntry(t *testing.T) {
+	m := NewModel(testSessions("a", "b"))
+	m = press(m, "j")
+	m = press(m, "j")
+	m = press(m, "K")
+
+	if got := strings.Join(m.Order(nil), ","); got != "a,b" || m.Reordered() {
+		t.Errorf("Order() = %s, Reordered() = %v, want a,b unchanged", got, m.Reordered())
+	}
+}
+
+// TestFilterKeys tests that keys typed into the filter don't trigger shortcuts
+func TestFilterKeys(t *testing.T) {
+	m := NewModel(testSessions("quux", "b"))
+	m = press(m, "/")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	m = updated.(Model)
+	if cmd != nil {
+		if _, quit := cmd().(tea.QuitMsg); quit {
+			t.Fatal("typing q in the filter quit the picker")
+		}
+	}
+	if m.list.FilterValue() != "q" {
+		t.Errorf("filter = %q, want q", m.list.FilterValue())
+	}
+}
+
+// fakeDeleter records deletes, failing with err when it's set
+type fakeDeleter struct {
+	deleted []string
+	err     error
+}
+
+func (f *fakeDeleter) DeleteSession(name string) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+// TestDeleteKey tests deleting the highlighted session with d
+func TestDeleteKey(t *testing.T) {
+	sessions := []session.Session{
+		{Name: "api", Type: session.SessionTypeTmux},
+		{Name: "infra", Type: session.SessionTypeTmuxinator},
+		{Name: "web", Type: session.SessionTypeTmux},
+	}
+
+	tests := []struct {
+		name        string
+		keys        []string
+		err         error
+		wantDeleted []string
+		wantOrder   string
+	}{
+		{name: "confirmed", keys: []string{"d", "y"}, wantDeleted: []string{"api"}, wantOrder: "infra,web"},
+		{name: "declined", keys: []string{"d", "n"}, wantOrder: "api,infra,web"},
+		{name: "not an active session", keys: []string{"j", "d", "y"}, wantOrder: "api,infra,web"},
+		{name: "delete fails", keys: []string{"d", "y"}, err: errors.New("tmux broke"), wantOrder: "api,infra,web"},
+		{name: "after deleting, the next one", keys: []string{"j", "j", "d", "y", "d", "y"}, wantDeleted: []string{"web"}, wantOrder: "api,infra"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleter := &fakeDeleter{err: tt.err}
+			m := NewModel(sessions)
+			m.SetDeleter(deleter)
+
+			for _, key := range tt.keys {
+				m = press(m, key)
+			}
+
+			if !reflect.DeepEqual(deleter.deleted, tt.wantDeleted) {
+				t.Errorf("deleted %v, want %v", deleter.deleted, tt.wantDeleted)
+			}
+			if got := strings.Join(m.Order(nil), ","); got != tt.wantOrder {
+				t.Errorf("Order() = %s, want %s", got, tt.wantOrder)
+			}
+			if m.GetChoice() != "" {
+				t.Errorf("GetChoice() = %q, want the picker still open", m.GetChoice())
+			}
+		})
+	}
+}
+
+// markedNames returns the sessions marked with space, in the order they're listed
+func markedNames(m Model) string {
+	var names []string
+	for _, sess := range m.sessions {
+		if m.marked[sess.Name] {
+			names = append(names, sess.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// TestMarkKey tests marking sessions with space
+func TestMarkKey(t *testing.T) {
+	m := NewModel(testSessions("api", "dotfiles", "web"))
+
+	m = press(m, " ")
+	m = press(m, "j")
+	m = press(m, "j")
+	m = press(m, " ")
+	if got := markedNames(m); got != "api,web" {
+		t.Errorf("marked %s, want api,web", got)
+	}
+
+	// Space again unmarks, and the create entry can't be marked
+	m = press(m, " ")
+	m = press(m, "j")
+	m = press(m, " ")
+	if got := markedNames(m); got != "api" {
+		t.Errorf("marked %s, want api", got)
+	}
+
+	resized, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+	if view := resized.(Model).View(); !strings.Contains(view, "✓") {
+		t.Errorf("View() doesn't show the mark:\n%s", view)
+	}
+}
+
+// TestSetIcons tests that the list draws the theme's icons, or the ASCII markers
+func TestSetIcons(t *testing.T) {
+	tests := []struct {
+		name  string
+		icons output.Icons
+		want  string
+	}{
+		{name: "theme", icons: output.Icons{Theme: session.Theme{session.SessionTypeTmux: {Icon: "▶"}}}, want: "▶"},
+		{name: "ascii", icons: output.Icons{Theme: session.Theme{session.SessionTypeTmux: {Icon: "▶"}}, ASCII: true}, want: "* api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewModel(testSessions("api"))
+			m.SetIcons(tt.icons)
+
+			resized, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 20})
+			view := resized.(Model).View()
+			if !strings.Contains(view, tt.want) || strings.Contains(view, "●") {
+				t.Errorf("View() doesn't draw %q:\n%s", tt.want, view)
+			}
+		})
+	}
+}
+
+// TestDeleteMarked tests deleting every marked session at once with D
+func TestDeleteMarked(t *testing.T) {
+	sessions := []session.Session{
+		{Name: "api", Type: session.SessionTypeTmux},
+		{Name: "infra", Type: session.SessionTypeTmuxinator},
+		{Name: "web", Type: session.SessionTypeTmux},
+		{Name: "notes", Type: session.SessionTypeTmux},
+	}
+	markAll := []string{" ", "j", " ", "j", " "}
+
+	tests := []struct {
+		name          string
+		keys          []string
+		err           error
+		wantDeleted   []string
+		wantOrder     string
+		wantSelection string
+	}{
+		{
+			name:          "confirmed",
+			keys:          append(slices.Clone(markAll), "D", "y"),
+			wantDeleted:   []string{"api", "web"},
+			wantOrder:     "infra,notes",
+			wantSelection: "infra", // Not running, so it wasn't deleted
+		},
+		{
+			name:          "declined",
+			keys:          append(slices.Clone(markAll), "D", "n"),
+			wantOrder:     "api,infra,web,notes",
+			wantSelection: "api,infra,web",
+		},
+		{
+			name:      "nothing marked",
+			keys:      []string{"D", "y"},
+			wantOrder: "api,infra,web,notes",
+		},
+		{
+			name:          "deletes fail",
+			keys:          append(slices.Clone(markAll), "D", "y"),
+			err:           errors.New("tmux broke"),
+			wantOrder:     "api,infra,web,notes",
+			wantSelection: "api,infra,web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleter := &fakeDeleter{err: tt.err}
+			m := NewModel(sessions)
+			m.SetDeleter(deleter)
+
+			for _, key := range tt.keys {
+				m = press(m, key)
+			}
+
+			if !reflect.DeepEqual(deleter.deleted, tt.wantDeleted) {
+				t.Errorf("deleted %v, want %v", deleter.deleted, tt.wantDeleted)
+			}
+			if got := strings.Join(m.Order(nil), ","); got != tt.wantOrder {
+				t.Errorf("Order() = %s, want %s", got, tt.wantOrder)
+			}
+			if got := markedNames(m); got != tt.wantSelection {
+				t.Errorf("marked %s, want %s", got, tt.wantSelection)
+			}
+			if m.GetChoice() != "" {
+				t.Errorf("GetChoice() = %q, want the picker still open", m.GetChoice())
+			}
+		})
+	}
+}
+
+// TestDeleteKeyWithoutDeleter tests that d does nothing until SetDeleter is called
+func TestDeleteKeyWithoutDeleter(t *testing.T) {
+	m := NewModel(testSessions("api"))
+	m = press(m, "d")
+	m = press(m, "y")
+
+	if got := strings.Join(m.Order(nil), ","); got != "api" {
+		t.Errorf("Order() = %s, want api", got)
+	}
+}
+
+// listedNames returns the names of the sessions the list is showing
+func listedNames(m Model) string {
+	var names []string
+	for _, item := range m.list.Items() {
+		if sess, ok := item.(sessionItem); ok {
+			names = append(names, sess.Name)
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// TestTagKey tests cycling the list through tags with t
+func TestTagKey(t *testing.T) {
+	sessions := testSessions("api", "dotfiles", "scratch", "web")
+	sessions[0].Tags = []string{"work"}
+	sessions[1].Tags = []string{"personal"}
+	sessions[3].Tags = []string{"work"}
+
+	m := NewModel(sessions)
+	want := []struct {
+		title  string
+		listed string
+	}{
+		{title: "Tmux Sessions [personal]", listed: "dotfiles"},
+		{title: "Tmux Sessions [work]", listed: "api,web"},
+		{title: "Tmux Sessions [untagged]", listed: "scratch"},
+		{title: "Tmux Sessions", listed: "api,dotfiles,scratch,web"},
+	}
+	for _, step := range want {
+		m = press(m, "t")
+		if m.list.Title != step.title || listedNames(m) != step.listed {
+			t.Errorf("after t: %q listing %s, want %q listing %s", m.list.Title, listedNames(m), step.title, step.listed)
+		}
+	}
+
+	t.Run("order survives narrowing", func(t *testing.T) {
+		m := NewModel(sessions)
+		m = press(m, "J")
+		m = press(m, "t")
+		m = press(m, "t")
+		m = press(m, "J") // ignored, the list only shows one tag
+
+		if got := strings.Join(m.Order(nil), ","); got != "dotfiles,api,scratch,web" {
+			t.Errorf("Order() = %s, want dotfiles,api,scratch,web", got)
+		}
+	})
+
+	t.Run("untagged sessions only", func(t *testing.T) {
+		m := press(NewModel(testSessions("a", "b")), "t")
+		if m.list.Title != listTitle || listedNames(m) != "a,b" {
+			t.Errorf("t with no tags: %q listing %s, want the full list", m.list.Title, listedNames(m))
+		}
+	})
+}
+
+// refreshed sends the model a fresh list of sessions, as --watch does
+func refreshed(m Model, sessions []session.Session, err error) (Model, tea.Cmd) {
+	updated, cmd := m.Update(refreshMsg{sessions: sessions, err: err})
+	return updated.(Model), cmd
+}
+
+// selectedName returns the highlighted session's name ("" for the create entry)
+func selectedName(m Model) string {
+	if sess, ok := m.list.SelectedItem().(sessionItem); ok {
+		return sess.Name
+	}
+	return ""
+}
+
+// TestWatch tests keeping the list current with SetWatch
+func TestWatch(t *testing.T) {
+	watched := func(names ...string) Model {
+		m := NewModel(testSessions(names...))
+		m.SetWatch(func() ([]session.Session, error) { return nil, nil }, time.Second)
+		updated, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 40})
+		return updated.(Model)
+	}
+
+	tests := []struct {
+		name         string
+		keys         []string
+		fresh        []string
+		wantListed   string
+		wantSelected string
+	}{
+		{name: "selection follows a session that moved", keys: []string{"j", "j"}, fresh: []string{"new", "a", "b", "c"}, wantListed: "new,a,b,c", wantSelected: "c"},
+		{name: "selection stays put when the session is gone", keys: []string{"j"}, fresh: []string{"a", "c"}, wantListed: "a,c", wantSelected: "c"},
+		{name: "last session gone", keys: []string{"j", "j"}, fresh: []string{"a", "b"}, wantListed: "a,b", wantSelected: ""},
+		{name: "moved sessions keep their place", keys: []string{"J"}, fresh: []string{"a", "b", "c", "d"}, wantListed: "b,a,c,d", wantSelected: "a"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := watched("a", "b", "c")
+			for _, key := range tt.keys {
+				m = press(m, key)
+			}
+
+			m, cmd := refreshed(m, testSessions(tt.fresh...), nil)
+			if cmd == nil {
+				t.Error("refresh didn't schedule the next one")
+			}
+			if got := listedNames(m); got != tt.wantListed {
+				t.Errorf("listed %s, want %s", got, tt.wantListed)
+			}
+			if got := selectedName(m); got != tt.wantSelected {
+				t.Errorf("selected %q, want %q", got, tt.wantSelected)
+			}
+		})
+	}
+
+	t.Run("failed refresh keeps the list", func(t *testing.T) {
+		m := watched("a", "b")
+		m, cmd := refreshed(m, nil, errors.New("no server running"))
+		if cmd == nil {
+			t.Error("failed refresh didn't schedule the next one")
+		}
+		if got := listedNames(m); got != "a,b" {
+			t.Errorf("listed %s, want a,b", got)
+		}
+	})
+
+	t.Run("waits while a delete is confirmed", func(t *testing.T) {
+		m := watched("a", "b")
+		m.SetDeleter(&fakeDeleter{})
+		m = press(m, "d")
+		m, _ = refreshed(m, testSessions("c"), nil)
+		if got := listedNames(m); got != "a,b" {
+			t.Errorf("listed %s, want a,b", got)
+		}
+	})
+
+	t.Run("only ticks when watching", func(t *testing.T) {
+		if NewModel(testSessions("a")).Init() != nil {
+			t.Error("Init() without SetWatch returned a command")
+		}
+		if watched("a").Init() == nil {
+			t.Error("Init() with SetWatch returned no command")
+		}
+	})
+}
@@ -0,0 +1,108 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// Picker asks the user to choose from a list of strings, or to type one
+// It's how sess drives external pickers like gum and fzf; the built-in
+// list works on sessions rather than strings, so it isn't one
+// Both methods return "" when the user cancels
+type Picker interface {
+	// Choose shows options under header and returns the one chosen
+	Choose(options []string, header string) (string, error)
+
+	// Input asks for a line of text, showing placeholder until something is typed
+	Input(placeholder string) (string, error)
+}
+
+// GumPicker picks with gum choose and gum input
+type GumPicker struct{}
+
+// Choose runs gum choose with the options as arguments
+func (GumPicker) Choose(options []string, header string) (string, error) {
+	return runPicker(gumCommand(gumChooseArgs(options, header)...))
+}
+
+// Input runs gum input
+func (GumPicker) Input(placeholder string) (string, error) {
+	return runPicker(gumCommand("input", "--placeholder", placeholder))
+}
+
+// gumCommand builds a gum command that reads the terminal
+func gumCommand(args ...string) *exec.Cmd {
+	cmd := exec.Command("gum", args...)
+	cmd.Stdin = os.Stdin
+	return cmd
+}
+
+// gumChooseArgs builds the gum choose command line
+func gumChooseArgs(options []string, header string) []string {
+	return append([]string{"choose", "--header=" + header}, options...)
+}
+
+// FzfPicker picks with fzf
+type FzfPicker struct{}
+
+// Choose runs fzf with the options on stdin, one per line
+func (FzfPicker) Choose(options []string, header string) (string, error) {
+	return runPicker(fzfCommand(strings.Join(options, "\n"), fzfChooseArgs(header)...))
+}
+
+// Input runs fzf with nothing to choose from and returns what was typed
+// --print-query prints the query even though it matches nothing
+func (FzfPicker) Input(placeholder string) (string, error) {
+	return runPicker(fzfCommand("", fzfInputArgs(placeholder)...))
+}
+
+// fzfCommand builds an fzf command that chooses from input
+// fzf always gets input on stdin, even when it's empty: given the terminal
+// instead, it lists every file under the working directory
+func fzfCommand(input string, args ...string) *exec.Cmd {
+	cmd := exec.Command("fzf", args...)
+	cmd.Stdin = strings.NewReader(input)
+	return cmd
+}
+
+// fzfChooseArgs builds the fzf command line for choosing
+// The options keep their order (--no-sort) with the first one at the top,
+// the way the other pickers show them
+func fzfChooseArgs(header string) []string {
+	return []string{"--header=" + header, "--layout=reverse", "--no-sort", "--no-multi"}
+}
+
+// fzfInputArgs builds the fzf command line for typing a name
+func fzfInputArgs(placeholder string) []string {
+	return []string{"--print-query", "--header=" + placeholder, "--layout=reverse", "--prompt=> "}
+}
+
+// runPicker runs a picker command and returns the first line it printed
+// The picker draws on the terminal through stderr (and /dev/tty), so only
+// stdout is captured. A picker that exits non-zero without printing
+// anything was canceled, which isn't an error.
+func runPicker(cmd *exec.Cmd) (string, error) {
+	name := cmd.Args[0]
+	if cmd.Err != nil {
+		return "", fmt.Errorf("%s is not installed (or use --ui=bubbletea)", name)
+	}
+	cmd.Stderr = os.Stderr
+
+	output, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		return "", fmt.Errorf("failed to run %s: %w", name, err)
+	}
+
+	line, _, _ := strings.Cut(string(output), "\n")
+	return strings.TrimSpace(line), nil
+}
+
+// Verify interface implementation at compile time
+var (
+	_ Picker = GumPicker{}
+	_ Picker = FzfPicker{}
+)
@@ -0,0 +1,77 @@
+package ui
+
+import (
+	"io"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// TestFzfArgs checks the fzf command lines for choosing and for typing a name
+func TestFzfArgs(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []string
+		want []string
+	}{
+		{
+			name: "choose",
+			got:  fzfChooseArgs("Tmux Sessions"),
+			want: []string{"--header=Tmux Sessions", "--layout=reverse", "--no-sort", "--no-multi"},
+		},
+		{
+			name: "input",
+			got:  fzfInputArgs("Session name"),
+			want: []string{"--print-query", "--header=Session name", "--layout=reverse", "--prompt=> "},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !reflect.DeepEqual(tt.got, tt.want) {
+				t.Errorf("args = %q, want %q", tt.got, tt.want)
+			}
+		})
+	}
+}
+
+// TestGumChooseArgs checks that the options follow the header as arguments
+func TestGumChooseArgs(t *testing.T) {
+	got := gumChooseArgs([]string{"api", "web"}, "Tmux Sessions")
+	want := []string{"choose", "--header=Tmux Sessions", "api", "web"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("gumChooseArgs() = %q, want %q", got, want)
+	}
+}
+
+// TestPickerStdin checks what each picker reads: gum the terminal, and fzf
+// only the options, so it never falls back to listing files
+func TestPickerStdin(t *testing.T) {
+	if cmd := gumCommand("input"); cmd.Stdin != os.Stdin {
+		t.Errorf("gum stdin = %v, want os.Stdin", cmd.Stdin)
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "choose", input: "api\nweb"},
+		{name: "input", input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := fzfCommand(tt.input)
+			if cmd.Stdin == nil || cmd.Stdin == os.Stdin {
+				t.Fatalf("fzf stdin = %v, want a reader of the options", cmd.Stdin)
+			}
+			got, err := io.ReadAll(cmd.Stdin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.input {
+				t.Errorf("fzf stdin = %q, want %q", got, tt.input)
+			}
+		})
+	}
+}
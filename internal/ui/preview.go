@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// previewStyle frames the preview pane next to the list
+var previewStyle = lipgloss.NewStyle().
+	Border(lipgloss.RoundedBorder()).
+	BorderForeground(lipgloss.Color("241")).
+	Padding(0, 1)
+
+// WindowLister fetches a session's windows for the preview
+// It's a function rather than the tmux client so the UI doesn't depend on tmux
+type WindowLister func(name string) ([]session.Window, error)
+
+// PreviewContent renders the details shown in the preview pane for a session
+// windows is only used for active sessions, which are the only ones that have
+// any; err is why they couldn't be fetched, if they couldn't
+// It's separate from the layout so it can be tested as plain text
+func PreviewContent(sess session.Session, windows []session.Window, err error) string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render(sess.Name))
+	b.WriteString("\n\n")
+
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&b, "%-12s %s\n", label+":", value)
+		}
+	}
+
+	switch sess.Type {
+	case session.SessionTypeTmux:
+		field("Type", "active")
+	case session.SessionTypeTmuxinator:
+		field("Type", "tmuxinator project")
+	case session.SessionTypeDefault:
+		field("Type", "default (not started)")
+	}
+	if sess.DisplayName != "" {
+		field("Opened as", sess.DisplayName)
+	}
+	field("Directory", sess.Directory)
+	field("Description", sess.Description)
+	field("Tmuxinator", sess.TmuxinatorProject)
+	field("Tags", strings.Join(sess.Tags, ", "))
+	field("Note", sess.Metadata[session.MetaNote])
+
+	if sess.Type != session.SessionTypeTmux {
+		return b.String()
+	}
+
+	b.WriteString("\nWindows:\n")
+	if err != nil {
+		b.WriteString("  (no preview)\n")
+		return b.String()
+	}
+	if len(windows) == 0 {
+		b.WriteString("  (none)\n")
+	}
+	for _, window := range windows {
+		// Mark the current window like tmux's status bar does
+		marker := "  "
+		if window.Active {
+			marker = "* "
+		}
+		panes := ""
+		if window.PaneCount > 1 {
+			panes = fmt.Sprintf(" (%d panes)", window.PaneCount)
+		}
+		command := ""
+		if window.Command != "" {
+			command = fmt.Sprintf("%-10s ", window.Command)
+		}
+		fmt.Fprintf(&b, "%s%d: %-12s %s%s%s\n", marker, window.Index, window.Name, command, window.Directory, panes)
+	}
+
+	return b.String()
+}
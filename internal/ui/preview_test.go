@@ -0,0 +1,116 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// TestPreviewContent tests the details rendered for the highlighted session
+func TestPreviewContent(t *testing.T) {
+	windows := []session.Window{
+		{Index: 1, Name: "editor", Directory: "/code/api", Command: "nvim", Active: true, PaneCount: 2},
+		{Index: 2, Name: "server", Directory: "/code/api/cmd", PaneCount: 1},
+	}
+
+	tests := []struct {
+		name    string
+		sess    session.Session
+		windows []session.Window
+		err     error
+		want    []string
+		notWant []string
+	}{
+		{
+			name:    "active session lists its windows",
+			sess:    session.Session{Name: "api", Type: session.SessionTypeTmux},
+			windows: windows,
+			want:    []string{"api", "active", "Windows:", "* 1: editor", "nvim", "/code/api (2 panes)", "  2: server", "/code/api/cmd\n"},
+		},
+		{
+			name:    "windows that couldn't be fetched",
+			sess:    session.Session{Name: "api", Type: session.SessionTypeTmux},
+			err:     errors.New("can't find session: api"),
+			want:    []string{"Windows:", "(no preview)"},
+			notWant: []string{"(none)"},
+		},
+		{
+			name: "active session without windows",
+			sess: session.Session{Name: "api", Type: session.SessionTypeTmux},
+			want: []string{"Windows:", "(none)"},
+		},
+		{
+			name: "default session shows its config",
+			sess: session.Session{
+				Name:        "notes",
+				Type:        session.SessionTypeDefault,
+				Directory:   "/home/me/notes",
+				Description: "Personal notes",
+			},
+			windows: windows,
+			want:    []string{"notes", "default (not started)", "Directory:", "/home/me/notes", "Description:", "Personal notes"},
+			notWant: []string{"Windows:", "editor"},
+		},
+		{
+			name:    "tmuxinator project",
+			sess:    session.Session{Name: "web", Type: session.SessionTypeTmuxinator, TmuxinatorProject: "web"},
+			want:    []string{"tmuxinator project", "Tmuxinator:"},
+			notWant: []string{"Windows:", "Directory:"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := PreviewContent(tt.sess, tt.windows, tt.err)
+
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("PreviewContent() missing %q in:\n%s", want, got)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(got, notWant) {
+					t.Errorf("PreviewContent() unexpectedly contains %q in:\n%s", notWant, got)
+				}
+			}
+		})
+	}
+}
+
+// TestPreviewFetchesWindowsLazily tests that windows are only fetched for
+// active sessions as they're highlighted, and only once each
+func TestPreviewFetchesWindowsLazily(t *testing.T) {
+	sessions := testSessions("a", "b")
+	sessions = append(sessions, session.Session{Name: "c", Type: session.SessionTypeDefault})
+
+	fetched := map[string]int{}
+	m := NewModel(sessions)
+	m.SetPreview(func(name string) ([]session.Window, error) {
+		fetched[name]++
+		return []session.Window{{Index: 1, Name: name + "-window"}}, nil
+	})
+
+	// Only the first session is highlighted to begin with
+	if len(fetched) != 1 || fetched["a"] != 1 {
+		t.Fatalf("after SetPreview fetched = %v, want only a", fetched)
+	}
+
+	// Down to b and c, then back up to a
+	for _, key := range []string{"j", "j", "k", "k"} {
+		m = press(m, key)
+	}
+
+	if fetched["a"] != 1 || fetched["b"] != 1 {
+		t.Errorf("fetched = %v, want a and b once each", fetched)
+	}
+	if fetched["c"] != 0 {
+		t.Errorf("fetched windows for default session c")
+	}
+	resized, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 30})
+	if !strings.Contains(resized.(Model).View(), "a-window") {
+		t.Errorf("View() doesn't show the highlighted session's windows")
+	}
+}
@@ -0,0 +1,58 @@
+package zoxide
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/datapointchris/sess/internal/runner"
+	"github.com/datapointchris/sess/internal/session"
+)
+
+// Client queries zoxide's database of frequently used directories
+type Client struct {
+	runner runner.Runner
+}
+
+// NewClient creates a zoxide client that runs commands through r
+func NewClient(r runner.Runner) *Client {
+	return &Client{runner: r}
+}
+
+// IsInstalled checks if zoxide is available
+func (c *Client) IsInstalled() bool {
+	_, err := c.runner.LookPath("zoxide")
+	return err == nil
+}
+
+// Query returns the highest ranked directory matching the query
+// Each word of the query is a separate zoxide keyword, like "z code api"
+func (c *Client) Query(query string) (string, error) {
+	keywords := strings.Fields(query)
+	if len(keywords) == 0 {
+		return "", fmt.Errorf("empty zoxide query")
+	}
+
+	// Run: zoxide query -- <keywords>
+	// The -- keeps a keyword starting with - from being read as a flag
+	args := append([]string{"query", "--"}, keywords...)
+	output, err := c.runner.Output("zoxide", args...)
+	if err != nil {
+		// zoxide exits non-zero when nothing matches
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("no zoxide match for %q", query)
+		}
+		return "", err
+	}
+
+	dir := strings.TrimSpace(string(output))
+	if dir == "" {
+		return "", fmt.Errorf("no zoxide match for %q", query)
+	}
+	return dir, nil
+}
+
+// Verify interface implementation at compile time
+var _ session.ZoxideClient = (*Client)(nil)
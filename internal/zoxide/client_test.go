@@ -0,0 +1,105 @@
+package zoxide
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+// fakeRunner returns canned zoxide output and records the commands it was asked to run
+type fakeRunner struct {
+	installed bool
+	output    string
+	err       error
+	calls     []string
+}
+
+func (f *fakeRunner) Run(name string, args ...string) error { return nil }
+
+func (f *fakeRunner) Output(name string, args ...string) ([]byte, error) {
+	f.calls = append(f.calls, strings.Join(append([]string{name}, args...), " "))
+	return []byte(f.output), f.err
+}
+
+func (f *fakeRunner) Interactive(name string, args ...string) error { return nil }
+
+func (f *fakeRunner) InteractiveEnv(env []string, name string, args ...string) error { return nil }
+
+func (f *fakeRunner) LookPath(name string) (string, error) {
+	if !f.installed {
+		return "", errors.New("not found")
+	}
+	return "/usr/bin/" + name, nil
+}
+
+// TestIsInstalled tests zoxide detection through the runner
+func TestIsInstalled(t *testing.T) {
+	if NewClient(&fakeRunner{installed: false}).IsInstalled() {
+		t.Error("IsInstalled() = true, want false when zoxide isn't in PATH")
+	}
+	if !NewClient(&fakeRunner{installed: true}).IsInstalled() {
+		t.Error("IsInstalled() = false, want true")
+	}
+}
+
+// TestQuery tests the zoxide command line and how its answers are read
+func TestQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		output    string
+		err       error
+		wantCall  string
+		wantDir   string
+		wantError bool
+	}{
+		{
+			name:     "single keyword",
+			query:    "api",
+			output:   "/home/me/code/api\n",
+			wantCall: "zoxide query -- api",
+			wantDir:  "/home/me/code/api",
+		},
+		{
+			name:     "several keywords",
+			query:    "code  api",
+			output:   "/home/me/code/api\n",
+			wantCall: "zoxide query -- code api",
+			wantDir:  "/home/me/code/api",
+		},
+		{
+			name:      "no match",
+			query:     "nothing",
+			err:       &exec.ExitError{},
+			wantCall:  "zoxide query -- nothing",
+			wantError: true,
+		},
+		{
+			name:      "empty query",
+			query:     "  ",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runner := &fakeRunner{installed: true, output: tt.output, err: tt.err}
+
+			dir, err := NewClient(runner).Query(tt.query)
+			if tt.wantError && err == nil {
+				t.Error("Query() expected error but got none")
+			}
+			if !tt.wantError && err != nil {
+				t.Fatalf("Query() unexpected error: %v", err)
+			}
+			if dir != tt.wantDir {
+				t.Errorf("Query() = %q, want %q", dir, tt.wantDir)
+			}
+
+			if tt.wantCall != "" && (len(runner.calls) != 1 || runner.calls[0] != tt.wantCall) {
+				t.Errorf("ran %v, want %q", runner.calls, tt.wantCall)
+			}
+		})
+	}
+}